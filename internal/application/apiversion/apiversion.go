@@ -0,0 +1,193 @@
+// Package apiversion serves the versions of the API side by side, e.g.
+// /api/v1 and /api/v2, and signals the ones on their way out. Each version
+// registers its routes on a group of its own, so versions share the handlers
+// that didn't change. Unversioned paths such as /api/users are served by the
+// version the API-Version header asks for, the default one without it.
+// Deprecated versions and routes answer with the Deprecation and Sunset
+// headers, and the clients that still call them are logged.
+package apiversion
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Header is the header requests pick the version of unversioned paths with,
+// and responses say the version that served them in.
+const Header = "API-Version"
+
+// Version is a version of the API, served under <prefix>/<Name>.
+type Version struct {
+	// Name is "v" and the major version, e.g. "v1"
+	Name string
+	// Register registers the routes of the version on its group
+	Register func(api *gin.RouterGroup)
+	// Deprecation is set once the whole version is deprecated
+	Deprecation *Deprecation
+	// Routes deprecates single routes of the version, keyed by method and
+	// path relative to its group, e.g. "GET /users/:id"
+	Routes map[string]Deprecation
+}
+
+// Deprecation says since when something is deprecated and when it goes away.
+type Deprecation struct {
+	Since time.Time
+	// Sunset is when it stops being served, zero when not scheduled yet
+	Sunset time.Time
+	// Link documents the deprecation, e.g. the guide to the next version
+	Link string
+}
+
+// Deprecated returns the deprecation of a route of v, its own or the one of
+// the whole version, and false when it is current.
+func (v Version) Deprecated(method, path string) (Deprecation, bool) {
+	if d, ok := v.Routes[method+" "+path]; ok {
+		return d, true
+	}
+	if v.Deprecation != nil {
+		return *v.Deprecation, true
+	}
+	return Deprecation{}, false
+}
+
+var (
+	prefix   string
+	versions []Version
+
+	// nameRe matches version names, the first segment of versioned paths
+	nameRe = regexp.MustCompile(`^v\d+$`)
+)
+
+// Mount serves each version under prefix, running middleware before its
+// routes. The first version is the default one of unversioned paths, so
+// clients that never asked for a version keep the API they were written for.
+func Mount(engine *gin.Engine, pathPrefix string, vs []Version, middleware ...gin.HandlerFunc) {
+	prefix, versions = pathPrefix, vs
+	for _, v := range vs {
+		api := engine.Group(prefix + "/" + v.Name)
+		api.Use(Middleware(v, api.BasePath()))
+		api.Use(middleware...)
+		v.Register(api)
+	}
+}
+
+// Versions returns the mounted versions, oldest first.
+func Versions() []Version {
+	return versions
+}
+
+// Prefix returns the path the versions are mounted under.
+func Prefix() string {
+	return prefix
+}
+
+// Default returns the version of unversioned paths.
+func Default() string {
+	if len(versions) == 0 {
+		return ""
+	}
+	return versions[0].Name
+}
+
+// Latest returns the newest version.
+func Latest() string {
+	if len(versions) == 0 {
+		return ""
+	}
+	return versions[len(versions)-1].Name
+}
+
+// Lookup returns the version named name.
+func Lookup(name string) (Version, bool) {
+	for _, v := range versions {
+		if v.Name == name {
+			return v, true
+		}
+	}
+	return Version{}, false
+}
+
+// Names returns the names of the mounted versions.
+func Names() []string {
+	names := make([]string, len(versions))
+	for i, v := range versions {
+		names[i] = v.Name
+	}
+	return names
+}
+
+// requestedKey holds the version the header of a request asked for.
+type requestedKey struct{}
+
+// Negotiate serves the unversioned paths under the prefix with the version
+// the API-Version header asks for, "2" or "v2", and the default version
+// without it. The path is rewritten before next routes it, so the versions
+// only register versioned paths.
+func Negotiate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rest, ok := strings.CutPrefix(r.URL.Path, prefix+"/")
+		if !ok || prefix == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if first, _, _ := strings.Cut(rest, "/"); nameRe.MatchString(first) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		version := Default()
+		if requested := strings.TrimSpace(r.Header.Get(Header)); requested != "" {
+			if version = normalize(requested); version == "" {
+				// Routes to no version, the request fails as unsupported
+				version = "unsupported"
+			}
+			r = r.WithContext(context.WithValue(r.Context(), requestedKey{}, requested))
+		}
+		w.Header().Add("Vary", Header)
+
+		r.URL.Path = prefix + "/" + version + "/" + rest
+		if r.URL.RawPath != "" {
+			r.URL.RawPath = prefix + "/" + version + "/" + strings.TrimPrefix(r.URL.RawPath, prefix+"/")
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// normalize returns the version name of a header value, "" when it names
+// none: "2", "v2" and "2.1" name v2.
+func normalize(requested string) string {
+	v := strings.ToLower(requested)
+	if !strings.HasPrefix(v, "v") {
+		v = "v" + v
+	}
+	major, _, _ := strings.Cut(v, ".")
+	if nameRe.MatchString(major) {
+		return major
+	}
+	return ""
+}
+
+// Unsupported returns the version a request that matched no route asked for
+// when it isn't one of the mounted versions, in its header or in its path.
+func Unsupported(r *http.Request) (string, bool) {
+	if requested, ok := r.Context().Value(requestedKey{}).(string); ok {
+		if _, known := Lookup(normalize(requested)); !known {
+			return requested, true
+		}
+		return "", false
+	}
+	rest, ok := strings.CutPrefix(r.URL.Path, prefix+"/")
+	if !ok || prefix == "" {
+		return "", false
+	}
+	first, _, _ := strings.Cut(rest, "/")
+	if _, known := Lookup(first); nameRe.MatchString(first) && !known {
+		return first, true
+	}
+	return "", false
+}
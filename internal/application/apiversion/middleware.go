@@ -0,0 +1,107 @@
+package apiversion
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"boilerplate-golang/internal/infrastructure/logger"
+)
+
+const (
+	// logEvery is how often a client calling a deprecated route is logged again
+	logEvery = 24 * time.Hour
+	// maxLogged caps the clients remembered; past it, calls are logged
+	// without being remembered until old entries are swept
+	maxLogged = 10000
+	// maxAgentLen truncates the user agents written to the log
+	maxAgentLen = 200
+)
+
+// logged holds when each client was last logged calling each deprecated
+// route, so busy clients are logged once a day rather than on every call.
+var (
+	loggedMu  sync.Mutex
+	logged    = make(map[string]time.Time)
+	lastSweep time.Time
+)
+
+// Middleware says the version serving the requests of v's group under base,
+// and signals its deprecated routes with the Deprecation (RFC 9745), Sunset
+// (RFC 8594) and Link headers. The clients calling them are logged once the
+// request is authenticated.
+func Middleware(v Version, base string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header(Header, v.Name)
+
+		route := strings.TrimPrefix(c.FullPath(), base)
+		d, deprecated := v.Deprecated(c.Request.Method, route)
+		if !deprecated {
+			c.Next()
+			return
+		}
+
+		c.Header("Deprecation", "@"+strconv.FormatInt(d.Since.Unix(), 10))
+		if !d.Sunset.IsZero() {
+			c.Header("Sunset", d.Sunset.UTC().Format(http.TimeFormat))
+		}
+		if d.Link != "" {
+			c.Writer.Header().Add("Link", "<"+d.Link+">; rel=\"deprecation\"")
+		}
+
+		c.Next()
+		logCaller(c, v.Name+" "+c.Request.Method+" "+route, d)
+	}
+}
+
+// logCaller logs the client calling a deprecated route: the user when the
+// request is authenticated, its IP otherwise, and its user agent.
+func logCaller(c *gin.Context, route string, d Deprecation) {
+	client := "ip " + c.ClientIP()
+	if userID := c.GetString("user_id"); userID != "" {
+		client = "user " + userID
+	}
+	if !shouldLog(route+"|"+client, time.Now()) {
+		return
+	}
+
+	agent := c.Request.UserAgent()
+	if len(agent) > maxAgentLen {
+		agent = agent[:maxAgentLen] + "..."
+	}
+	sunset := "not scheduled"
+	if !d.Sunset.IsZero() {
+		sunset = d.Sunset.UTC().Format(time.DateOnly)
+	}
+	logger.Warn("Deprecated API %s called by %s (%s), sunset %s", route, client, agent, sunset)
+}
+
+// shouldLog reports whether key wasn't logged within logEvery, and remembers
+// it. The entries past logEvery are swept hourly, and every minute while the
+// map is full.
+func shouldLog(key string, now time.Time) bool {
+	loggedMu.Lock()
+	defer loggedMu.Unlock()
+
+	sinceSweep := now.Sub(lastSweep)
+	if sinceSweep >= time.Hour || (len(logged) >= maxLogged && sinceSweep >= time.Minute) {
+		for k, last := range logged {
+			if now.Sub(last) >= logEvery {
+				delete(logged, k)
+			}
+		}
+		lastSweep = now
+	}
+
+	if last, ok := logged[key]; ok && now.Sub(last) < logEvery {
+		return false
+	}
+	if len(logged) < maxLogged {
+		logged[key] = now
+	}
+	return true
+}
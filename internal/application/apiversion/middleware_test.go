@@ -0,0 +1,53 @@
+package apiversion
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func resetLogged() {
+	loggedMu.Lock()
+	logged = make(map[string]time.Time)
+	lastSweep = time.Time{}
+	loggedMu.Unlock()
+}
+
+func TestShouldLogOncePerPeriod(t *testing.T) {
+	resetLogged()
+	now := time.Now()
+
+	if !shouldLog("v1 GET /users|ip 1.2.3.4", now) {
+		t.Error("first call not logged")
+	}
+	if shouldLog("v1 GET /users|ip 1.2.3.4", now.Add(time.Hour)) {
+		t.Error("second call within a day logged again")
+	}
+	if !shouldLog("v1 GET /users|ip 5.6.7.8", now.Add(time.Hour)) {
+		t.Error("another client not logged")
+	}
+	if !shouldLog("v1 GET /users|ip 1.2.3.4", now.Add(logEvery+time.Second)) {
+		t.Error("call after a day not logged again")
+	}
+}
+
+func TestShouldLogStaysBounded(t *testing.T) {
+	resetLogged()
+	now := time.Now()
+
+	for i := 0; i < maxLogged+500; i++ {
+		if !shouldLog(fmt.Sprintf("route|ip %d", i), now) {
+			t.Fatalf("new client %d not logged", i)
+		}
+	}
+	if len(logged) > maxLogged {
+		t.Errorf("%d clients remembered, want at most %d", len(logged), maxLogged)
+	}
+
+	// Expired entries make room again
+	later := now.Add(logEvery + time.Minute)
+	shouldLog("route|ip new", later)
+	if len(logged) != 1 {
+		t.Errorf("%d clients remembered after the sweep, want 1", len(logged))
+	}
+}
@@ -0,0 +1,187 @@
+// Package apperror defines the errors the application reports to API clients.
+// An AppError has a kind, which decides the HTTP status, a stable machine
+// readable code clients can branch on, a message safe to show them, per-field
+// details for invalid input and the underlying cause, which is only logged.
+package apperror
+
+import (
+	"errors"
+	"net/http"
+	"reflect"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// Kind classifies an error by how the client should treat it.
+type Kind string
+
+const (
+	KindBadRequest   Kind = "bad_request"
+	KindUnauthorized Kind = "unauthorized"
+	KindForbidden    Kind = "forbidden"
+	KindNotFound     Kind = "not_found"
+	KindConflict     Kind = "conflict"
+	KindPrecondition Kind = "precondition_failed"
+	KindValidation   Kind = "validation_failed"
+	KindInternal     Kind = "internal"
+)
+
+var statuses = map[Kind]int{
+	KindBadRequest:   http.StatusBadRequest,
+	KindUnauthorized: http.StatusUnauthorized,
+	KindForbidden:    http.StatusForbidden,
+	KindNotFound:     http.StatusNotFound,
+	KindConflict:     http.StatusConflict,
+	KindPrecondition: http.StatusPreconditionFailed,
+	KindValidation:   http.StatusUnprocessableEntity,
+	KindInternal:     http.StatusInternalServerError,
+}
+
+// Status returns the HTTP status errors of the kind are sent with; unknown
+// kinds are server errors.
+func (k Kind) Status() int {
+	if status, ok := statuses[k]; ok {
+		return status
+	}
+	return http.StatusInternalServerError
+}
+
+// KindOf returns the kind sent with the HTTP status, KindInternal for statuses
+// without one.
+func KindOf(status int) Kind {
+	for kind, s := range statuses {
+		if s == status {
+			return kind
+		}
+	}
+	return KindInternal
+}
+
+// internalMessage is all clients learn about a server-side failure.
+const internalMessage = "Internal server error"
+
+// FieldError describes why one field of the input was rejected.
+type FieldError struct {
+	// Field is the JSON path of the field, e.g. "email" or "items[2].name"
+	Field string `json:"field"`
+	// Rule is the validation rule that failed, e.g. "required" or "max", and
+	// Param its parameter, e.g. the maximum
+	Rule    string `json:"rule"`
+	Param   string `json:"param,omitempty"`
+	Message string `json:"message"`
+
+	// cause is the validator's error, which can be translated
+	cause validator.FieldError
+}
+
+// AppError is an error with everything needed to report it to the client.
+type AppError struct {
+	Kind Kind
+	// Code is stable across releases; it defaults to the kind
+	Code    string
+	Message string
+	Fields  []FieldError
+	// Params fill in the message when it is translated, see package i18n
+	Params []string
+	// Err is the cause, logged but never sent to the client
+	Err error
+}
+
+func (e *AppError) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *AppError) Unwrap() error {
+	return e.Err
+}
+
+// Status returns the HTTP status the error is sent with.
+func (e *AppError) Status() int {
+	return e.Kind.Status()
+}
+
+// New returns an error of kind with the machine code and client message. An
+// empty code defaults to the kind.
+func New(kind Kind, code, message string) *AppError {
+	if code == "" {
+		code = string(kind)
+	}
+	return &AppError{Kind: kind, Code: code, Message: message}
+}
+
+// Wrap is New with the underlying cause.
+func Wrap(err error, kind Kind, code, message string) *AppError {
+	e := New(kind, code, message)
+	e.Err = err
+	return e
+}
+
+// WithFields adds field details to e and returns it.
+func (e *AppError) WithFields(fields ...FieldError) *AppError {
+	e.Fields = append(e.Fields, fields...)
+	return e
+}
+
+// WithParams sets the params of the translated message and returns e.
+func (e *AppError) WithParams(params ...string) *AppError {
+	e.Params = params
+	return e
+}
+
+// BadRequest reports a request the server can't make sense of.
+func BadRequest(code, message string) *AppError {
+	return New(KindBadRequest, code, message)
+}
+
+// Unauthorized reports missing or rejected credentials.
+func Unauthorized(code, message string) *AppError {
+	return New(KindUnauthorized, code, message)
+}
+
+// Forbidden reports that the caller may not do what they asked.
+func Forbidden(code, message string) *AppError {
+	return New(KindForbidden, code, message)
+}
+
+// NotFound reports that the requested record does not exist.
+func NotFound(code, message string) *AppError {
+	return New(KindNotFound, code, message)
+}
+
+// Conflict reports that the request clashes with the current state.
+func Conflict(code, message string) *AppError {
+	return New(KindConflict, code, message)
+}
+
+// Validation reports well-formed input that breaks the rules of its fields.
+func Validation(fields ...FieldError) *AppError {
+	e := New(KindValidation, "", validationMessage(fields))
+	e.Fields = fields
+	return e
+}
+
+// InvalidField is Validation for a single field breaking rule, whose
+// parameter is param.
+func InvalidField(field, rule, param string) *AppError {
+	return Validation(FieldError{Field: field, Rule: rule, Param: param,
+		Message: field + " " + ruleMessage(rule, param, reflect.Invalid)})
+}
+
+// Internal reports a server-side failure. Clients get a generic message; err
+// is only logged.
+func Internal(err error) *AppError {
+	return Wrap(err, KindInternal, "", internalMessage)
+}
+
+// As returns err as an AppError. Other errors are internal errors, so their
+// text never reaches the client.
+func As(err error) *AppError {
+	var e *AppError
+	if errors.As(err, &e) {
+		return e
+	}
+	return Internal(err)
+}
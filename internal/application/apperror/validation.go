@@ -0,0 +1,160 @@
+package apperror
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+)
+
+// FromBinding turns the error of binding a request body into the error sent to
+// the client: field details for values that break their rules or have the
+// wrong type, a bad request for a body that isn't JSON at all. The validator
+// should report JSON field names, see JSONFieldName.
+func FromBinding(err error) *AppError {
+	var (
+		invalid   validator.ValidationErrors
+		typeErr   *json.UnmarshalTypeError
+		syntaxErr *json.SyntaxError
+		tooLarge  *http.MaxBytesError
+	)
+	switch {
+	case errors.As(err, &invalid):
+		fields := make([]FieldError, len(invalid))
+		for i, fe := range invalid {
+			field := fieldPath(fe)
+			fields[i] = FieldError{Field: field, Rule: fe.Tag(), Param: fe.Param(),
+				Message: field + " " + ruleMessage(fe.Tag(), fe.Param(), fe.Kind()), cause: fe}
+		}
+		return Validation(fields...)
+	case errors.As(err, &typeErr):
+		field := typeErr.Field
+		if field == "" {
+			return Wrap(err, KindBadRequest, "invalid_body", "Request body must be a JSON object")
+		}
+		return Validation(FieldError{Field: field, Rule: "type", Param: jsonType(typeErr.Type),
+			Message: field + " must be " + article(jsonType(typeErr.Type))})
+	case errors.As(err, &tooLarge):
+		return Wrap(err, KindBadRequest, "body_too_large", fmt.Sprintf("Request body must be at most %d bytes", tooLarge.Limit)).
+			WithParams(strconv.FormatInt(tooLarge.Limit, 10))
+	case errors.Is(err, io.EOF):
+		return Wrap(err, KindBadRequest, "missing_body", "Request body is required")
+	case errors.As(err, &syntaxErr), errors.Is(err, io.ErrUnexpectedEOF):
+		return Wrap(err, KindBadRequest, "invalid_body", "Request body must be valid JSON")
+	default:
+		return Wrap(err, KindBadRequest, "invalid_body", "Request body is invalid")
+	}
+}
+
+// JSONFieldName names struct fields after their json tag in validation errors,
+// for validator.RegisterTagNameFunc.
+func JSONFieldName(f reflect.StructField) string {
+	name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+	switch name {
+	case "-":
+		return ""
+	case "":
+		return f.Name
+	}
+	return name
+}
+
+// fieldPath is the path of the field below the request struct, e.g.
+// "items[2].name" for "CreateRequest.items[2].name".
+func fieldPath(fe validator.FieldError) string {
+	if _, path, ok := strings.Cut(fe.Namespace(), "."); ok {
+		return path
+	}
+	return fe.Field()
+}
+
+// ruleMessage explains the failed rule to the client, for a field of kind
+// (reflect.Invalid when unknown).
+func ruleMessage(rule, param string, kind reflect.Kind) string {
+	switch rule {
+	case "required":
+		return "is a required field"
+	case "email":
+		return "must be a valid email address"
+	case "boolean":
+		return "must be true or false"
+	case "json":
+		return "must be a JSON object mapping fields to column names"
+	case "oneof":
+		return "must be one of [" + param + "]"
+	case "min", "max", "len":
+		bound := map[string]string{"min": "at least ", "max": "at most ", "len": "exactly "}[rule]
+		switch kind {
+		case reflect.String:
+			return "must be " + bound + param + " characters long"
+		case reflect.Slice, reflect.Array, reflect.Map:
+			return "must have " + bound + param + " items"
+		}
+		return "must be " + bound + param
+	case "bcp47_language_tag":
+		return "must be a language tag such as en-US"
+	case "timezone":
+		return "must be an IANA time zone such as Europe/Paris"
+	}
+	return "is invalid"
+}
+
+// Translate returns the message of f in the language of trans, as the
+// validator translates it, and false when it has no translation for the rule.
+func (f FieldError) Translate(trans ut.Translator) (string, bool) {
+	if f.cause == nil || trans == nil {
+		return "", false
+	}
+	msg := f.cause.Translate(trans)
+	// Untranslated rules come back as the validator's error text
+	return msg, msg != f.cause.Error()
+}
+
+// jsonType names the JSON type a Go value is decoded from.
+func jsonType(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "integer"
+	case reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.Slice, reflect.Array:
+		return "array"
+	case reflect.Map, reflect.Struct:
+		return "object"
+	case reflect.Pointer:
+		return jsonType(t.Elem())
+	}
+	return "value"
+}
+
+// article prefixes a JSON type name with its indefinite article.
+func article(name string) string {
+	if strings.IndexByte("aeiou", name[0]) >= 0 {
+		return "an " + name
+	}
+	return "a " + name
+}
+
+// validationMessage summarises the field errors in one line, for clients that
+// only show the message.
+func validationMessage(fields []FieldError) string {
+	switch len(fields) {
+	case 0:
+		return "Request is invalid"
+	case 1:
+		return fields[0].Message
+	}
+	return fmt.Sprintf("%s (and %d more)", fields[0].Message, len(fields)-1)
+}
@@ -0,0 +1,41 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"boilerplate-golang/internal/application/entity"
+	"boilerplate-golang/internal/infrastructure/dbmanager"
+	"boilerplate-golang/internal/infrastructure/logger"
+)
+
+// Record adds an entry to the audit log for action on the target record, with
+// the acting user, client IP and request ID from ctx. details is stored as
+// JSON and may be nil. Call it with the context of the transaction that
+// performs the action, so the entry is stored if and only if it commits.
+func Record(ctx context.Context, action, targetType, targetID string, details interface{}) error {
+	var data []byte
+	if details != nil {
+		var err error
+		if data, err = json.Marshal(details); err != nil {
+			return fmt.Errorf("failed to encode %s details: %w", action, err)
+		}
+	}
+
+	entry := entity.AuditLog{
+		ActorID:    logger.UserID(ctx),
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   targetID,
+		Details:    string(data),
+		IP:         logger.ClientIP(ctx),
+		RequestID:  logger.RequestID(ctx),
+		CreatedAt:  time.Now().UTC(),
+	}
+	if err := dbmanager.DB(ctx).Create(&entry).Error; err != nil {
+		return fmt.Errorf("failed to record %s: %w", action, err)
+	}
+	return nil
+}
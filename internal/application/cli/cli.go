@@ -0,0 +1,270 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"boilerplate-golang/internal/application/apiversion"
+	"boilerplate-golang/internal/application/router"
+	"boilerplate-golang/internal/application/seed"
+	"boilerplate-golang/internal/infrastructure/config"
+	"boilerplate-golang/internal/infrastructure/dbmanager"
+)
+
+// Run executes a command-line subcommand and returns the process exit code.
+// Configuration must already be loaded.
+func Run(args []string) int {
+	switch args[0] {
+	case "migrate":
+		return runMigrate(args[1:])
+	case "seed":
+		return runSeed(args[1:])
+	case "reencrypt":
+		return runReencrypt(args[1:])
+	case "openapi":
+		return runOpenAPI(args[1:])
+	default:
+		usage()
+		return 2
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, `usage:
+  migrate up [--dry-run]              apply pending migrations
+  migrate down [--steps N] [--dry-run] revert the last N migrations (default 1)
+  migrate status                      list migrations and whether they are applied
+  migrate generate <name> [--dir DIR] write a migration from entity changes
+  seed [--env ENV] [name...]          run all (or the named) seeders for ENV (default app.env)
+  seed list                           list seeders and the environments they run in
+  reencrypt [--all] [--batch N] [--dry-run]
+                                      rewrite encrypted columns under the active key
+  openapi [--version V] [--out FILE] [--check]
+                                      write the OpenAPI spec of an API version (default
+                                      the latest) to FILE or stdout; --check fails when
+                                      a route of any version is undocumented`)
+}
+
+// runMigrate handles `migrate <up|down|status|generate>`.
+func runMigrate(args []string) int {
+	if len(args) == 0 {
+		usage()
+		return 2
+	}
+
+	fs := flag.NewFlagSet("migrate "+args[0], flag.ContinueOnError)
+	dryRun := fs.Bool("dry-run", false, "print SQL without executing it")
+	steps := fs.Int("steps", 1, "number of migrations to revert")
+	dir := fs.String("dir", "internal/infrastructure/dbmanager/migrations", "directory for generated migrations")
+
+	var name string
+	rest := args[1:]
+	if args[0] == "generate" && len(rest) > 0 {
+		name, rest = rest[0], rest[1:]
+	}
+	if err := fs.Parse(rest); err != nil {
+		return 2
+	}
+
+	if err := dbmanager.Connect(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	db := dbmanager.GetDB()
+	if db == nil {
+		fmt.Fprintln(os.Stderr, "database is not configured")
+		return 1
+	}
+
+	switch args[0] {
+	case "up":
+		n, err := dbmanager.MigrateUp(db, *dryRun, os.Stdout)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		if *dryRun {
+			fmt.Printf("%d migration(s) pending\n", n)
+		} else {
+			fmt.Printf("%d migration(s) applied\n", n)
+		}
+	case "down":
+		n, err := dbmanager.MigrateDown(db, *steps, *dryRun, os.Stdout)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		if *dryRun {
+			fmt.Printf("%d migration(s) would be reverted\n", n)
+		} else {
+			fmt.Printf("%d migration(s) reverted\n", n)
+		}
+	case "status":
+		statuses, err := dbmanager.MigrationStatuses(db)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		for _, s := range statuses {
+			state := "pending"
+			if s.Applied {
+				state = "applied " + s.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%04d  %-40s %s\n", s.Version, s.Name, state)
+		}
+	case "generate":
+		if name == "" {
+			usage()
+			return 2
+		}
+		path, err := dbmanager.GenerateMigration(db, name, *dir)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		if path == "" {
+			fmt.Println("schema is in sync with entities, nothing to generate")
+		} else {
+			fmt.Printf("created %s\n", path)
+		}
+	default:
+		usage()
+		return 2
+	}
+	return 0
+}
+
+// runSeed handles `seed [--env ENV] [name...]` and `seed list`.
+func runSeed(args []string) int {
+	if len(args) > 0 && args[0] == "list" {
+		for _, s := range seed.Seeders() {
+			envs := "all"
+			if len(s.Envs) > 0 {
+				envs = strings.Join(s.Envs, ",")
+			}
+			fmt.Printf("%-20s %s\n", s.Name, envs)
+		}
+		return 0
+	}
+
+	fs := flag.NewFlagSet("seed", flag.ContinueOnError)
+	env := fs.String("env", config.Get().App.Env, "environment whose seeders run")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	if err := dbmanager.Connect(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	if dbmanager.GetDB() == nil {
+		fmt.Fprintln(os.Stderr, "database is not configured")
+		return 1
+	}
+
+	if err := seed.Run(context.Background(), *env, fs.Args()...); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	fmt.Println("seeding complete")
+	return 0
+}
+
+// runReencrypt handles `reencrypt [--all] [--batch N] [--dry-run]`.
+func runReencrypt(args []string) int {
+	fs := flag.NewFlagSet("reencrypt", flag.ContinueOnError)
+	all := fs.Bool("all", false, "rewrite every row, not only those under an old key")
+	batch := fs.Int("batch", 500, "rows per transaction")
+	dryRun := fs.Bool("dry-run", false, "count the rows without rewriting them")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *batch < 1 {
+		fmt.Fprintln(os.Stderr, "--batch must be positive")
+		return 2
+	}
+
+	if err := dbmanager.Connect(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	db := dbmanager.GetDB()
+	if db == nil {
+		fmt.Fprintln(os.Stderr, "database is not configured")
+		return 1
+	}
+
+	n, err := dbmanager.Reencrypt(db, *batch, *all, *dryRun, os.Stdout)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	if *dryRun {
+		fmt.Printf("%d row(s) would be reencrypted\n", n)
+	} else {
+		fmt.Printf("%d row(s) reencrypted\n", n)
+	}
+	return 0
+}
+
+// runOpenAPI handles `openapi [--version V] [--out FILE] [--check]`. The routes are
+// registered on an engine of their own, without a database.
+func runOpenAPI(args []string) int {
+	fs := flag.NewFlagSet("openapi", flag.ContinueOnError)
+	version := fs.String("version", "", "API version to document, e.g. v1 (default the latest)")
+	out := fs.String("out", "", "file to write the spec to instead of stdout")
+	check := fs.Bool("check", false, "fail when a route is missing from the spec or an entry has no route")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	gin.SetMode(gin.ReleaseMode)
+	engine := gin.New()
+	router.Register(engine, nil)
+
+	if *check {
+		undocumented, stale := router.CheckDocs(engine)
+		for _, route := range undocumented {
+			fmt.Fprintf(os.Stderr, "undocumented route: %s\n", route)
+		}
+		for _, route := range stale {
+			fmt.Fprintf(os.Stderr, "documented route is not registered: %s\n", route)
+		}
+		if len(undocumented) > 0 || len(stale) > 0 {
+			return 1
+		}
+		fmt.Println("every route is documented")
+		return 0
+	}
+
+	if *version == "" {
+		*version = apiversion.Latest()
+	}
+	doc, ok := router.Spec(engine, *version)
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown API version %q, versions: %s\n", *version, strings.Join(apiversion.Names(), ", "))
+		return 2
+	}
+	spec, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	spec = append(spec, '\n')
+	if *out == "" {
+		_, err = os.Stdout.Write(spec)
+	} else {
+		err = os.WriteFile(*out, spec, 0o644)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,20 @@
+package controller
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"boilerplate-golang/internal/application/service"
+)
+
+// AuditController handles audit log requests
+type AuditController struct {
+}
+
+// GetAuditLogs handles GET /api/admin/audit-logs
+func (ac *AuditController) GetAuditLogs(c *gin.Context) {
+	spec, ok := parseQuery(c, c.Request.URL.Query(), service.AuditLogQuery)
+	if !ok {
+		return
+	}
+	respond(c, service.IAuditService.GetAuditLogs(c.Request.Context(), spec))
+}
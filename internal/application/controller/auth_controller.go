@@ -0,0 +1,66 @@
+package controller
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"boilerplate-golang/internal/application/apperror"
+	"boilerplate-golang/internal/application/dto"
+	"boilerplate-golang/internal/application/service"
+)
+
+// AuthController handles login, logout, password reset and email confirmation requests
+type AuthController struct {
+}
+
+// Login handles POST /api/auth/login
+func (ac *AuthController) Login(c *gin.Context) {
+	var req dto.LoginRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	respond(c, service.IAuthService.Login(c.Request.Context(), req.Email, req.Password, c.Request.UserAgent(), c.ClientIP()))
+}
+
+// Logout handles POST /api/auth/logout
+func (ac *AuthController) Logout(c *gin.Context) {
+	respond(c, service.IAuthService.Logout(c.Request.Context(), c.GetString("user_id"), c.GetString("session_id")))
+}
+
+// PreviewEmailChange handles GET /api/auth/confirm-email?token=..., with the
+// token of the link mailed to a new email address. It changes nothing.
+func (ac *AuthController) PreviewEmailChange(c *gin.Context) {
+	token := c.Query("token")
+	if token == "" {
+		fail(c, apperror.InvalidField("token", "required", ""))
+		return
+	}
+	respond(c, service.IAuthService.PreviewEmailChange(c.Request.Context(), token))
+}
+
+// ConfirmEmail handles POST /api/auth/confirm-email
+func (ac *AuthController) ConfirmEmail(c *gin.Context) {
+	var req dto.EmailChangeConfirmRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	respond(c, service.IAuthService.ConfirmEmailChange(c.Request.Context(), req.Token))
+}
+
+// ForgotPassword handles POST /api/auth/forgot-password
+func (ac *AuthController) ForgotPassword(c *gin.Context) {
+	var req dto.ForgotPasswordRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	respond(c, service.IAuthService.RequestPasswordReset(c.Request.Context(), req.Email))
+}
+
+// ResetPassword handles POST /api/auth/reset-password with the token from a
+// password reset or invite link
+func (ac *AuthController) ResetPassword(c *gin.Context) {
+	var req dto.ResetPasswordRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	respond(c, service.IAuthService.ResetPassword(c.Request.Context(), req.Token, req.Password))
+}
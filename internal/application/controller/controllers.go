@@ -4,4 +4,14 @@ package controller
 var (
 	// User related
 	UserCtrl  = &UserController{}
+	AuthCtrl  = &AuthController{}
+	PrivacyCtrl = &PrivacyController{}
+	InvitationCtrl = &InvitationController{}
+
+	// Files
+	FileCtrl = &FileController{}
+
+	// Admin related
+	HistoryCtrl = &HistoryController{}
+	AuditCtrl = &AuditController{}
 )
@@ -0,0 +1,45 @@
+package controller
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"boilerplate-golang/internal/application/apperror"
+	"boilerplate-golang/internal/application/dto"
+)
+
+// versionConflict is the error code of updates made on a stale version.
+const versionConflict = "version_conflict"
+
+// setETag sets the ETag of a versioned entity.
+func setETag(c *gin.Context, version int64) {
+	c.Header("ETag", `"`+strconv.FormatInt(version, 10)+`"`)
+}
+
+// ifMatchVersion reads the version required by the If-Match header. It returns
+// ok=false when the header is absent or "*" (any version matches).
+func ifMatchVersion(c *gin.Context) (version int64, ok bool, err error) {
+	tag := strings.TrimSpace(c.GetHeader("If-Match"))
+	if tag == "" || tag == "*" {
+		return 0, false, nil
+	}
+	tag = strings.TrimPrefix(tag, "W/")
+	version, err = strconv.ParseInt(strings.Trim(tag, `"`), 10, 64)
+	if err != nil || version < 1 {
+		return 0, false, errors.New("If-Match must be an ETag returned by this API")
+	}
+	return version, true, nil
+}
+
+// checkPrecondition turns the version conflict of an update made with If-Match
+// into 412 Precondition Failed. Other conflicts, such as a taken username,
+// keep their 409.
+func checkPrecondition(res dto.ResponseDto, precondition bool, msg string) dto.ResponseDto {
+	if precondition && res.Code == dto.CodeConflict && res.ErrorCode == versionConflict {
+		return *dto.FailWith(apperror.New(apperror.KindPrecondition, "precondition_failed", msg))
+	}
+	return res
+}
@@ -0,0 +1,45 @@
+package controller
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+
+	"github.com/gin-gonic/gin"
+
+	"boilerplate-golang/internal/application/apperror"
+	"boilerplate-golang/internal/infrastructure/storagemanager"
+)
+
+// FileController serves the files of the local storage driver
+type FileController struct {
+}
+
+// GetFile handles GET /api/files/*key. Private files (data exports) are only
+// served through the signed links storagemanager.SignedURL creates.
+func (fc *FileController) GetFile(c *gin.Context) {
+	key, err := storagemanager.CleanKey(c.Param("key"))
+	if err != nil {
+		fail(c, apperror.NotFound("", "File not found"))
+		return
+	}
+	path, err := storagemanager.LocalFile(key, c.Query("expires"), c.Query("signature"))
+	if errors.Is(err, storagemanager.ErrInvalidSignature) {
+		fail(c, apperror.Forbidden("invalid_link", "Link is invalid or has expired"))
+		return
+	}
+	if err != nil {
+		fail(c, apperror.NotFound("", "File not found"))
+		return
+	}
+	if info, err := os.Stat(path); err != nil || info.IsDir() {
+		fail(c, apperror.NotFound("", "File not found"))
+		return
+	}
+	if storagemanager.IsPrivate(key) {
+		c.Header("Cache-Control", "private, no-store")
+		c.FileAttachment(path, filepath.Base(path))
+		return
+	}
+	c.File(path)
+}
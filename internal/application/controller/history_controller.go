@@ -0,0 +1,20 @@
+package controller
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"boilerplate-golang/internal/application/service"
+)
+
+// HistoryController handles entity change history requests
+type HistoryController struct {
+}
+
+// GetHistory handles GET /api/admin/history/:entity/:id
+func (hc *HistoryController) GetHistory(c *gin.Context) {
+	spec, ok := parseQuery(c, c.Request.URL.Query(), service.HistoryQuery)
+	if !ok {
+		return
+	}
+	respond(c, service.IHistoryService.GetHistory(c.Request.Context(), c.Param("entity"), c.Param("id"), spec))
+}
@@ -0,0 +1,63 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"boilerplate-golang/internal/application/apperror"
+	"boilerplate-golang/internal/application/dto"
+	"boilerplate-golang/internal/application/service"
+)
+
+// InvitationController handles invitations and their acceptance
+type InvitationController struct {
+}
+
+// CreateInvitation handles POST /api/admin/invitations
+func (ic *InvitationController) CreateInvitation(c *gin.Context) {
+	var req dto.InvitationCreateRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	respondStatus(c, http.StatusCreated, service.IInvitationService.CreateInvitation(c.Request.Context(),
+		c.GetString("user_id"), req.Email, req.Role, req.OrgID))
+}
+
+// GetInvitations handles GET /api/admin/invitations
+func (ic *InvitationController) GetInvitations(c *gin.Context) {
+	spec, ok := parseQuery(c, c.Request.URL.Query(), service.InvitationQuery)
+	if !ok {
+		return
+	}
+	respond(c, service.IInvitationService.GetInvitations(c.Request.Context(), spec))
+}
+
+// ResendInvitation handles POST /api/admin/invitations/:id/resend
+func (ic *InvitationController) ResendInvitation(c *gin.Context) {
+	respond(c, service.IInvitationService.ResendInvitation(c.Request.Context(), c.Param("id")))
+}
+
+// RevokeInvitation handles POST /api/admin/invitations/:id/revoke
+func (ic *InvitationController) RevokeInvitation(c *gin.Context) {
+	respond(c, service.IInvitationService.RevokeInvitation(c.Request.Context(), c.Param("id")))
+}
+
+// PreviewInvitation handles GET /api/auth/invitation?token=...
+func (ic *InvitationController) PreviewInvitation(c *gin.Context) {
+	token := c.Query("token")
+	if token == "" {
+		fail(c, apperror.InvalidField("token", "required", ""))
+		return
+	}
+	respond(c, service.IInvitationService.PreviewInvitation(c.Request.Context(), token))
+}
+
+// AcceptInvitation handles POST /api/auth/invitation/accept
+func (ic *InvitationController) AcceptInvitation(c *gin.Context) {
+	var req dto.InvitationAcceptRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	respond(c, service.IInvitationService.AcceptInvitation(c.Request.Context(), req.Token, req.Username, req.FullName, req.Password))
+}
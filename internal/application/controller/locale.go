@@ -0,0 +1,80 @@
+package controller
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"boilerplate-golang/internal/application/apperror"
+	"boilerplate-golang/internal/application/i18n"
+	"boilerplate-golang/internal/application/service"
+)
+
+// localeKey caches the locale of the request on the gin context.
+const localeKey = "locale"
+
+// localeOf returns the locale to answer the request in: the language the
+// signed-in user chose in their profile when we support it, otherwise the
+// best match of Accept-Language. The response says which it is.
+func localeOf(c *gin.Context) string {
+	if locale := c.GetString(localeKey); locale != "" {
+		return locale
+	}
+
+	var locale string
+	if userID := c.GetString("user_id"); userID != "" {
+		locale = i18n.Match(service.IUserService.PreferredLocale(c.Request.Context(), userID))
+	}
+	if locale == "" {
+		locale = i18n.Negotiate(c.GetHeader("Accept-Language"))
+	}
+
+	c.Set(localeKey, locale)
+	c.Header("Content-Language", locale)
+	c.Writer.Header().Add("Vary", "Accept-Language")
+	return locale
+}
+
+// translate returns the message key in the request's locale, or message when
+// the locale has no translation.
+func translate(c *gin.Context, key, message string, params ...string) string {
+	if key == "" {
+		return message
+	}
+	if msg, ok := i18n.Text(localeOf(c), key, params...); ok {
+		return msg
+	}
+	return message
+}
+
+// localize returns err with its message and field details in the request's
+// locale. Codes without a translation fall back to the one of their kind, and
+// field errors to the validator's translation of their rule.
+func localize(c *gin.Context, err *apperror.AppError) *apperror.AppError {
+	locale := localeOf(c)
+	out := *err
+
+	if msg, ok := i18n.Text(locale, err.Code, err.Params...); ok {
+		out.Message = msg
+	} else if msg, ok := i18n.Text(locale, string(err.Kind)); ok {
+		out.Message = msg
+	}
+
+	if len(err.Fields) > 0 {
+		out.Fields = make([]apperror.FieldError, len(err.Fields))
+		for i, f := range err.Fields {
+			if msg, ok := f.Translate(i18n.Translator(locale)); ok {
+				f.Message = msg
+			} else if msg, ok := i18n.Text(locale, "field_"+f.Rule, f.Field, f.Param); ok {
+				f.Message = msg
+			}
+			out.Fields[i] = f
+		}
+		if err.Kind == apperror.KindValidation {
+			// The summary is the first field error, as in apperror.Validation
+			out.Message = out.Fields[0].Message
+			if more, ok := i18n.Plural(locale, "validation_more", len(out.Fields)-1); ok && len(out.Fields) > 1 {
+				out.Message += " " + more
+			}
+		}
+	}
+	return &out
+}
@@ -0,0 +1,73 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"boilerplate-golang/internal/application/dto"
+	"boilerplate-golang/internal/application/service"
+)
+
+// PrivacyController handles data export and account erasure requests
+type PrivacyController struct {
+}
+
+// RequestExport handles POST /api/users/me/data-export
+func (pc *PrivacyController) RequestExport(c *gin.Context) {
+	respondStatus(c, http.StatusAccepted, service.IPrivacyService.RequestExport(c.Request.Context(), c.GetString("user_id")))
+}
+
+// GetMyDataRequests handles GET /api/users/me/data-requests
+func (pc *PrivacyController) GetMyDataRequests(c *gin.Context) {
+	respond(c, service.IPrivacyService.GetMyDataRequests(c.Request.Context(), c.GetString("user_id")))
+}
+
+// DownloadExport handles GET /api/users/me/data-requests/:id/download
+func (pc *PrivacyController) DownloadExport(c *gin.Context) {
+	respond(c, service.IPrivacyService.GetExportDownload(c.Request.Context(), c.GetString("user_id"), c.Param("id")))
+}
+
+// RequestErasure handles POST /api/users/me/erasure
+func (pc *PrivacyController) RequestErasure(c *gin.Context) {
+	var req dto.ErasureRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	respondStatus(c, http.StatusAccepted, service.IPrivacyService.RequestErasure(c.Request.Context(), c.GetString("user_id"), req.Password))
+}
+
+// CancelErasure handles DELETE /api/users/me/erasure
+func (pc *PrivacyController) CancelErasure(c *gin.Context) {
+	respond(c, service.IPrivacyService.CancelErasure(c.Request.Context(), c.GetString("user_id")))
+}
+
+// GetDataRequests handles GET /api/admin/privacy/requests
+func (pc *PrivacyController) GetDataRequests(c *gin.Context) {
+	spec, ok := parseQuery(c, c.Request.URL.Query(), service.DataRequestQuery)
+	if !ok {
+		return
+	}
+	respond(c, service.IPrivacyService.GetDataRequests(c.Request.Context(), spec))
+}
+
+// ApproveErasure handles POST /api/admin/privacy/requests/:id/approve
+func (pc *PrivacyController) ApproveErasure(c *gin.Context) {
+	pc.reviewErasure(c, true)
+}
+
+// RejectErasure handles POST /api/admin/privacy/requests/:id/reject
+func (pc *PrivacyController) RejectErasure(c *gin.Context) {
+	pc.reviewErasure(c, false)
+}
+
+func (pc *PrivacyController) reviewErasure(c *gin.Context, approve bool) {
+	var req dto.DataRequestReviewRequest
+	// The body is optional
+	if c.Request.ContentLength != 0 {
+		if !bindJSON(c, &req) {
+			return
+		}
+	}
+	respond(c, service.IPrivacyService.ReviewErasure(c.Request.Context(), c.GetString("user_id"), c.Param("id"), approve, req.Note))
+}
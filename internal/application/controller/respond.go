@@ -0,0 +1,125 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+
+	"boilerplate-golang/internal/application/apiversion"
+	"boilerplate-golang/internal/application/apperror"
+	"boilerplate-golang/internal/application/dto"
+	"boilerplate-golang/internal/application/i18n"
+	"boilerplate-golang/internal/application/queryspec"
+	"boilerplate-golang/internal/infrastructure/config"
+	"boilerplate-golang/internal/infrastructure/logger"
+)
+
+func init() {
+	// Validation errors name fields as clients send them, in their language
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterTagNameFunc(apperror.JSONFieldName)
+		if err := i18n.RegisterValidator(v); err != nil {
+			logger.Fatal("Error registering validator translations: %v", err)
+		}
+	}
+}
+
+// respond writes res with the HTTP status 200 on success, its message in the
+// request's locale. Failures go to the error middleware, which sends them with
+// the status of their code.
+func respond(c *gin.Context, res dto.ResponseDto) {
+	respondStatus(c, http.StatusOK, res)
+}
+
+// respondStatus is respond with the status to use on success, e.g. 201 Created.
+func respondStatus(c *gin.Context, status int, res dto.ResponseDto) {
+	if res.Code != 0 {
+		fail(c, res.AppError())
+		return
+	}
+	res.Msg = translate(c, res.MsgKey, res.Msg, res.MsgParams...)
+	c.JSON(status, res)
+}
+
+// fail ends the request with err, rendered by the error middleware.
+func fail(c *gin.Context, err *apperror.AppError) {
+	_ = c.Error(err)
+	c.Abort()
+}
+
+// bindJSON binds the request body to obj, failing the request with the
+// validation details when it can't.
+func bindJSON(c *gin.Context, obj interface{}) bool {
+	if err := c.ShouldBindJSON(obj); err != nil {
+		fail(c, apperror.FromBinding(err))
+		return false
+	}
+	return true
+}
+
+// parseQuery parses the list query against the whitelist, failing the request
+// when it asks for a field or operator that isn't allowed.
+func parseQuery(c *gin.Context, query url.Values, wl queryspec.Whitelist) (queryspec.Spec, bool) {
+	spec, err := queryspec.Parse(query, wl)
+	if err != nil {
+		fail(c, apperror.Wrap(err, apperror.KindBadRequest, "invalid_query", err.Error()))
+		return queryspec.Spec{}, false
+	}
+	return spec, true
+}
+
+// NotFound is the handler of requests no route matches, which includes the
+// requests for an API version that doesn't exist.
+func NotFound(c *gin.Context) {
+	if version, ok := apiversion.Unsupported(c.Request); ok {
+		supported := strings.Join(apiversion.Names(), ", ")
+		fail(c, apperror.BadRequest("unsupported_api_version",
+			"Unsupported API version "+version+", supported versions: "+supported).WithParams(version, supported))
+		return
+	}
+	fail(c, apperror.NotFound("route_not_found", "No route for "+c.Request.Method+" "+c.Request.URL.Path).
+		WithParams(c.Request.Method, c.Request.URL.Path))
+}
+
+// ErrorMiddleware renders the error a handler failed with as the response
+// envelope, with the HTTP status of its kind, its machine code and field
+// details, translated for the request. Failures of the config middlewares keep
+// their status and code; other errors that aren't AppErrors are internal
+// errors. Causes are logged and never sent.
+func ErrorMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		last := c.Errors.Last()
+		if last == nil {
+			return
+		}
+		err := appError(last.Err)
+		if err.Kind == apperror.KindInternal {
+			logger.Error("%s %s failed (request %s): %v", c.Request.Method, c.Request.URL.Path,
+				logger.RequestID(c.Request.Context()), err)
+		}
+		if c.Writer.Written() {
+			return
+		}
+		c.JSON(err.Status(), dto.FailWith(localize(c, err)))
+	}
+}
+
+// appError returns the AppError to render for err, mapping the failures of
+// the config middlewares by their HTTP status.
+func appError(err error) *apperror.AppError {
+	var httpErr *config.HTTPError
+	if errors.As(err, &httpErr) {
+		if httpErr.Status >= http.StatusInternalServerError {
+			return apperror.Internal(httpErr.Err)
+		}
+		return apperror.Wrap(httpErr.Err, apperror.KindOf(httpErr.Status), httpErr.Code, httpErr.Message)
+	}
+	return apperror.As(err)
+}
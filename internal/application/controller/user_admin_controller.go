@@ -0,0 +1,72 @@
+package controller
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+
+	"boilerplate-golang/internal/application/dto"
+	"boilerplate-golang/internal/application/service"
+)
+
+// SearchUsers handles GET /api/admin/users with the filters, search and sort
+// of GET /api/users
+func (uc *UserController) SearchUsers(c *gin.Context) {
+	spec, ok := parseQuery(c, c.Request.URL.Query(), service.UserQuery)
+	if !ok {
+		return
+	}
+	respond(c, service.IUserService.SearchUsers(c.Request.Context(), spec))
+}
+
+// GetUserDetail handles GET /api/admin/users/:id
+func (uc *UserController) GetUserDetail(c *gin.Context) {
+	respond(c, service.IUserService.GetUserDetail(c.Request.Context(), c.Param("id")))
+}
+
+// SuspendUser handles POST /api/admin/users/:id/suspend
+func (uc *UserController) SuspendUser(c *gin.Context) {
+	uc.adminAction(c, func(ctx context.Context, id, reason string) dto.ResponseDto {
+		return service.IUserService.SuspendUser(ctx, c.GetString("user_id"), id, reason)
+	})
+}
+
+// ReactivateUser handles POST /api/admin/users/:id/reactivate
+func (uc *UserController) ReactivateUser(c *gin.Context) {
+	uc.adminAction(c, service.IUserService.ReactivateUser)
+}
+
+// PromoteUser handles POST /api/admin/users/:id/promote
+func (uc *UserController) PromoteUser(c *gin.Context) {
+	uc.adminAction(c, service.IUserService.PromoteUser)
+}
+
+// DemoteUser handles POST /api/admin/users/:id/demote
+func (uc *UserController) DemoteUser(c *gin.Context) {
+	uc.adminAction(c, func(ctx context.Context, id, reason string) dto.ResponseDto {
+		return service.IUserService.DemoteUser(ctx, c.GetString("user_id"), id, reason)
+	})
+}
+
+// ForceLogout handles POST /api/admin/users/:id/logout
+func (uc *UserController) ForceLogout(c *gin.Context) {
+	uc.adminAction(c, service.IUserService.ForceLogout)
+}
+
+// TriggerPasswordReset handles POST /api/admin/users/:id/password-reset
+func (uc *UserController) TriggerPasswordReset(c *gin.Context) {
+	uc.adminAction(c, service.IUserService.TriggerPasswordReset)
+}
+
+// adminAction binds the optional reason of an admin action on the user in
+// the path and responds with the result of run.
+func (uc *UserController) adminAction(c *gin.Context, run func(ctx context.Context, id, reason string) dto.ResponseDto) {
+	var req dto.AdminActionRequest
+	// The body is optional
+	if c.Request.ContentLength != 0 {
+		if !bindJSON(c, &req) {
+			return
+		}
+	}
+	respond(c, run(c.Request.Context(), c.Param("id"), req.Reason))
+}
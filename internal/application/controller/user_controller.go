@@ -1,7 +1,16 @@
 package controller
 
 import (
+	"io"
+	"net/http"
+	"strconv"
+
 	"github.com/gin-gonic/gin"
+
+	"boilerplate-golang/internal/application/apperror"
+	"boilerplate-golang/internal/application/dto"
+	"boilerplate-golang/internal/application/service"
+	"boilerplate-golang/internal/infrastructure/config"
 )
 
 // UserController handles user-related HTTP requests
@@ -10,25 +19,223 @@ type UserController struct {
 
 // GetUsers handles GET /api/users
 func (uc *UserController) GetUsers(c *gin.Context) {
-
+	spec, ok := parseQuery(c, c.Request.URL.Query(), service.UserQuery)
+	if !ok {
+		return
+	}
+	respond(c, service.IUserService.GetAllUsers(c.Request.Context(), spec))
 }
 
 // GetUser handles GET /api/users/:id
 func (uc *UserController) GetUser(c *gin.Context) {
+	if !canAccessUser(c, c.Param("id")) {
+		return
+	}
+	res := service.IUserService.GetUserByID(c.Request.Context(), c.Param("id"))
+	if user, ok := res.Data.(dto.UserResponse); ok {
+		setETag(c, user.Version)
+	}
+	respond(c, res)
+}
 
+// Register handles POST /api/auth/register, which is closed when the
+// environment is invite-only
+func (uc *UserController) Register(c *gin.Context) {
+	if config.Get().Invitations.InviteOnly {
+		fail(c, apperror.Forbidden("invite_only", "Registration is by invitation only"))
+		return
+	}
+	uc.CreateUser(c)
 }
 
 // CreateUser handles POST /api/users
 func (uc *UserController) CreateUser(c *gin.Context) {
+	var req dto.UserCreateRequest
+	if !bindJSON(c, &req) {
+		return
+	}
 
+	res := service.IUserService.CreateUser(c.Request.Context(), req.Username, req.Email, req.Password, req.FullName)
+	if user, ok := res.Data.(dto.UserResponse); ok {
+		setETag(c, user.Version)
+	}
+	respondStatus(c, http.StatusCreated, res)
 }
 
-// UpdateUser handles PUT /api/users/:id
+// UpdateUser handles PUT /api/users/:id. The expected version comes from the
+// If-Match header (412 on mismatch) or the version field of the body (409).
 func (uc *UserController) UpdateUser(c *gin.Context) {
+	if !canAccessUser(c, c.Param("id")) {
+		return
+	}
+
+	var req dto.UserUpdateRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	version, precondition, err := ifMatchVersion(c)
+	if err != nil {
+		fail(c, apperror.Wrap(err, apperror.KindBadRequest, "invalid_if_match", err.Error()))
+		return
+	}
+	if !precondition && req.Version != nil {
+		version = *req.Version
+	}
+
+	res := service.IUserService.UpdateUser(c.Request.Context(), c.Param("id"), version,
+		deref(req.Username), deref(req.FullName))
+	res = checkPrecondition(res, precondition, "User does not match If-Match, reload and try again")
+	if user, ok := res.Data.(dto.UserResponse); ok {
+		setETag(c, user.Version)
+	}
+	respond(c, res)
+}
 
+// deref returns the value of an optional string field, or "" when it is absent.
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
 }
 
 // DeleteUser handles DELETE /api/users/:id
 func (uc *UserController) DeleteUser(c *gin.Context) {
+	if !canAccessUser(c, c.Param("id")) {
+		return
+	}
+	respond(c, service.IUserService.SoftDeleteUser(c.Request.Context(), c.Param("id")))
+}
+
+// GetMe handles GET /api/users/me
+func (uc *UserController) GetMe(c *gin.Context) {
+	res := service.IUserService.GetUserByID(c.Request.Context(), c.GetString("user_id"))
+	if user, ok := res.Data.(dto.UserResponse); ok {
+		setETag(c, user.Version)
+	}
+	respond(c, res)
+}
+
+// UpdateMe handles PUT /api/users/me. Versions work as in UpdateUser.
+func (uc *UserController) UpdateMe(c *gin.Context) {
+	var req dto.ProfileUpdateRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	version, precondition, err := ifMatchVersion(c)
+	if err != nil {
+		fail(c, apperror.Wrap(err, apperror.KindBadRequest, "invalid_if_match", err.Error()))
+		return
+	}
+	if !precondition && req.Version != nil {
+		version = *req.Version
+	}
+
+	res := service.IUserService.UpdateProfile(c.Request.Context(), c.GetString("user_id"), version,
+		req.FullName, req.Locale, req.Timezone, req.Bio)
+	res = checkPrecondition(res, precondition, "User does not match If-Match, reload and try again")
+	if user, ok := res.Data.(dto.UserResponse); ok {
+		setETag(c, user.Version)
+	}
+	respond(c, res)
+}
+
+// UploadAvatar handles POST /api/users/me/avatar with the image in the
+// multipart field "avatar"
+func (uc *UserController) UploadAvatar(c *gin.Context) {
+	maxSize := config.Get().Storage.MaxAvatarSize
+	if maxSize <= 0 {
+		maxSize = 5 << 20
+	}
+	// Leave room for the multipart framing around the file
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxSize+64<<10)
+
+	header, err := c.FormFile("avatar")
+	if err != nil {
+		fail(c, apperror.InvalidField("avatar", "required", ""))
+		return
+	}
+	if header.Size > maxSize {
+		fail(c, apperror.InvalidField("avatar", "max", formatBytes(maxSize)))
+		return
+	}
+	file, err := header.Open()
+	if err != nil {
+		fail(c, apperror.Wrap(err, apperror.KindBadRequest, "unreadable_file", "avatar file could not be read"))
+		return
+	}
+	defer file.Close()
+	data, err := io.ReadAll(io.LimitReader(file, maxSize))
+	if err != nil {
+		fail(c, apperror.Wrap(err, apperror.KindBadRequest, "unreadable_file", "avatar file could not be read"))
+		return
+	}
+
+	res := service.IUserService.SetAvatar(c.Request.Context(), c.GetString("user_id"), data)
+	if user, ok := res.Data.(dto.UserResponse); ok {
+		setETag(c, user.Version)
+	}
+	respond(c, res)
+}
+
+// DeleteAvatar handles DELETE /api/users/me/avatar
+func (uc *UserController) DeleteAvatar(c *gin.Context) {
+	res := service.IUserService.RemoveAvatar(c.Request.Context(), c.GetString("user_id"))
+	if user, ok := res.Data.(dto.UserResponse); ok {
+		setETag(c, user.Version)
+	}
+	respond(c, res)
+}
+
+// formatBytes renders a size limit for error messages, e.g. "5 MB".
+func formatBytes(n int64) string {
+	if n >= 1<<20 && n%(1<<20) == 0 {
+		return strconv.FormatInt(n>>20, 10) + " MB"
+	}
+	return strconv.FormatInt(n, 10) + " bytes"
+}
+
+// ChangePassword handles POST /api/users/me/password
+func (uc *UserController) ChangePassword(c *gin.Context) {
+	var req dto.ChangePasswordRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	respond(c, service.IAuthService.ChangePassword(c.Request.Context(), c.GetString("user_id"), c.GetString("session_id"),
+		req.CurrentPassword, req.NewPassword))
+}
+
+// RequestEmailChange handles POST /api/users/me/email
+func (uc *UserController) RequestEmailChange(c *gin.Context) {
+	var req dto.EmailChangeRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	respond(c, service.IAuthService.RequestEmailChange(c.Request.Context(), c.GetString("user_id"), req.Email, req.Password))
+}
+
+// canAccessUser lets users act on their own account and admins on any account.
+// Otherwise it responds 403 and returns false.
+func canAccessUser(c *gin.Context, id string) bool {
+	if c.GetString("user_id") == id || config.IsAdminRole(c.GetString("role")) {
+		return true
+	}
+	fail(c, apperror.Forbidden("", "You can only access your own account"))
+	return false
+}
+
+// GetDeletedUsers handles GET /api/admin/users/deleted
+func (uc *UserController) GetDeletedUsers(c *gin.Context) {
+	spec, ok := parseQuery(c, c.Request.URL.Query(), service.UserQuery)
+	if !ok {
+		return
+	}
+	respond(c, service.IUserService.GetDeletedUsers(c.Request.Context(), spec))
+}
 
+// RestoreUser handles POST /api/admin/users/:id/restore
+func (uc *UserController) RestoreUser(c *gin.Context) {
+	respond(c, service.IUserService.RestoreUser(c.Request.Context(), c.Param("id")))
 }
@@ -0,0 +1,144 @@
+package controller
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"boilerplate-golang/internal/application/apperror"
+	"boilerplate-golang/internal/application/dto"
+	"boilerplate-golang/internal/application/service"
+	"boilerplate-golang/internal/application/sheet"
+)
+
+// maxImportSize bounds an uploaded import file.
+const maxImportSize = 10 << 20
+
+// ImportUsers handles POST /api/admin/users/import. The multipart form holds
+// the CSV or XLSX file in "file", an optional JSON "mapping" from user field
+// to column name, and the flags "dry_run" and "send_invites". A dry run
+// responds with the validation report; otherwise the import starts in the
+// background and responds 202 with the job to poll.
+func (uc *UserController) ImportUsers(c *gin.Context) {
+	// Leave room for the multipart framing and the other fields
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImportSize+64<<10)
+
+	header, err := c.FormFile("file")
+	if err != nil {
+		fail(c, apperror.InvalidField("file", "required", ""))
+		return
+	}
+	if header.Size > maxImportSize {
+		fail(c, apperror.InvalidField("file", "max", formatBytes(maxImportSize)))
+		return
+	}
+
+	var mapping map[string]string
+	if raw := c.PostForm("mapping"); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &mapping); err != nil {
+			fail(c, apperror.InvalidField("mapping", "json", ""))
+			return
+		}
+	}
+	dryRun, invalid := formBool(c, "dry_run")
+	if invalid != nil {
+		fail(c, invalid)
+		return
+	}
+	sendInvites, invalid := formBool(c, "send_invites")
+	if invalid != nil {
+		fail(c, invalid)
+		return
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		fail(c, apperror.Wrap(err, apperror.KindBadRequest, "unreadable_file", "file could not be read"))
+		return
+	}
+	defer file.Close()
+	data, err := io.ReadAll(io.LimitReader(file, maxImportSize))
+	if err != nil {
+		fail(c, apperror.Wrap(err, apperror.KindBadRequest, "unreadable_file", "file could not be read"))
+		return
+	}
+
+	if dryRun {
+		respond(c, service.IUserService.PreviewImport(c.Request.Context(), header.Filename, data, mapping))
+		return
+	}
+	respondStatus(c, http.StatusAccepted, service.IUserService.StartImport(c.Request.Context(), c.GetString("user_id"),
+		header.Filename, data, mapping, sendInvites))
+}
+
+// GetImportJob handles GET /api/admin/users/import/:id
+func (uc *UserController) GetImportJob(c *gin.Context) {
+	respond(c, service.IUserService.GetImportJob(c.Request.Context(), c.Param("id")))
+}
+
+// ExportUsers handles GET /api/admin/users/export?format=csv|xlsx|json with
+// the filters, search and sort of GET /api/users, as a file download.
+func (uc *UserController) ExportUsers(c *gin.Context) {
+	format := c.DefaultQuery("format", sheet.FormatCSV)
+	if format != sheet.FormatCSV && format != sheet.FormatXLSX && format != "json" {
+		fail(c, apperror.InvalidField("format", "oneof", "csv xlsx json"))
+		return
+	}
+
+	query := c.Request.URL.Query()
+	query.Del("format")
+	spec, ok := parseQuery(c, query, service.UserQuery)
+	if !ok {
+		return
+	}
+
+	res := service.IUserService.ExportUsers(c.Request.Context(), spec)
+	rows, ok := res.Data.([]dto.UserExportRow)
+	if res.Code != 0 || !ok {
+		respond(c, res)
+		return
+	}
+
+	// Encode before writing headers, so a failure can still be reported as JSON
+	var (
+		buf bytes.Buffer
+		err error
+	)
+	contentType := "application/json"
+	if format == "json" {
+		err = json.NewEncoder(&buf).Encode(rows)
+	} else {
+		records := make([][]string, len(rows))
+		for i, row := range rows {
+			records[i] = row.Record()
+		}
+		contentType = sheet.ContentTypes[format]
+		err = sheet.Write(&buf, format, dto.UserExportColumns, records)
+	}
+	if err != nil {
+		fail(c, apperror.Wrap(err, apperror.KindInternal, "", "Error exporting users"))
+		return
+	}
+
+	filename := "users-" + time.Now().UTC().Format("20060102-150405") + "." + format
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+	c.Data(http.StatusOK, contentType, buf.Bytes())
+}
+
+// formBool parses an optional boolean form field, false when absent.
+func formBool(c *gin.Context, name string) (bool, *apperror.AppError) {
+	raw := c.PostForm(name)
+	if raw == "" {
+		return false, nil
+	}
+	v, err := strconv.ParseBool(raw)
+	if err != nil {
+		return false, apperror.InvalidField(name, "boolean", "")
+	}
+	return v, nil
+}
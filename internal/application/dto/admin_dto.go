@@ -0,0 +1,104 @@
+package dto
+
+import (
+	"encoding/json"
+	"time"
+
+	"boilerplate-golang/internal/application/entity"
+)
+
+// AdminActionRequest represents the optional request body of an admin action
+// on a user, such as a suspension
+type AdminActionRequest struct {
+	// Reason is recorded in the audit log
+	Reason string `json:"reason" binding:"max=500"`
+}
+
+// AdminUserResponse represents a user as seen by admins, with account status
+type AdminUserResponse struct {
+	ID        string     `json:"id"`
+	Username  string     `json:"username"`
+	Email     string     `json:"email"`
+	FullName  string     `json:"full_name"`
+	IsActive  bool       `json:"is_active"`
+	IsAdmin   bool       `json:"is_admin"`
+	Locale    string     `json:"locale,omitempty"`
+	Timezone  string     `json:"timezone,omitempty"`
+	LastLogin *time.Time `json:"last_login"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+	Version   int64      `json:"version"`
+}
+
+func GetAdminUserResponse(entity entity.User) AdminUserResponse {
+	return AdminUserResponse{
+		ID:        entity.ID,
+		Username:  entity.Username,
+		Email:     entity.Email,
+		FullName:  entity.FullName,
+		IsActive:  entity.IsActive,
+		IsAdmin:   entity.IsAdmin,
+		Locale:    entity.Locale,
+		Timezone:  entity.Timezone,
+		LastLogin: entity.LastLogin,
+		CreatedAt: entity.CreatedAt,
+		UpdatedAt: entity.UpdatedAt,
+		Version:   entity.Version,
+	}
+}
+
+// AdminUserDetailResponse represents a user with their active login sessions
+type AdminUserDetailResponse struct {
+	AdminUserResponse
+	Sessions []SessionResponse `json:"sessions"`
+}
+
+// SessionResponse represents a login session
+type SessionResponse struct {
+	ID        string    `json:"id"`
+	UserAgent string    `json:"user_agent"`
+	IP        string    `json:"ip"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+func GetSessionResponse(entity entity.UserSession) SessionResponse {
+	return SessionResponse{
+		ID:        entity.ID,
+		UserAgent: entity.UserAgent,
+		IP:        entity.IP,
+		CreatedAt: entity.CreatedAt,
+		ExpiresAt: entity.ExpiresAt,
+	}
+}
+
+// AuditLogResponse represents one audit log entry
+type AuditLogResponse struct {
+	ID         int64           `json:"id"`
+	ActorID    string          `json:"actor_id,omitempty"`
+	Action     string          `json:"action"`
+	TargetType string          `json:"target_type"`
+	TargetID   string          `json:"target_id"`
+	Details    json.RawMessage `json:"details,omitempty"`
+	IP         string          `json:"ip,omitempty"`
+	RequestID  string          `json:"request_id,omitempty"`
+	CreatedAt  time.Time       `json:"created_at"`
+}
+
+func GetAuditLogResponse(entity entity.AuditLog) AuditLogResponse {
+	var details json.RawMessage
+	if entity.Details != "" && json.Valid([]byte(entity.Details)) {
+		details = json.RawMessage(entity.Details)
+	}
+	return AuditLogResponse{
+		ID:         entity.ID,
+		ActorID:    entity.ActorID,
+		Action:     entity.Action,
+		TargetType: entity.TargetType,
+		TargetID:   entity.TargetID,
+		Details:    details,
+		IP:         entity.IP,
+		RequestID:  entity.RequestID,
+		CreatedAt:  entity.CreatedAt,
+	}
+}
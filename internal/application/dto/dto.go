@@ -1,7 +1,23 @@
 package dto
 
 import (
+    "net/http"
     "reflect"
+
+    "boilerplate-golang/internal/application/apperror"
+)
+
+// Envelope codes of failures; they match the HTTP status the response is sent
+// with. Fail's code 1 is sent as an internal error.
+const (
+    CodeBadRequest         = 400
+    CodeUnauthorized       = 401
+    CodeForbidden          = 403
+    CodeNotFound           = 404
+    CodeConflict           = 409
+    CodePreconditionFailed = 412
+    CodeValidationFailed   = 422
+    CodeInternal           = 500
 )
 
 type ResponseDto struct {
@@ -9,6 +25,15 @@ type ResponseDto struct {
     Msg   string      `json:"msg"` 
     Data  interface{} `json:"data,omitempty"` 
     Count int64       `json:"count"` 
+    Next  string      `json:"next,omitempty"`
+    Prev  string      `json:"prev,omitempty"`
+    // ErrorCode is the stable machine-readable code of a failure
+    ErrorCode string                `json:"error_code,omitempty"`
+    Details   []apperror.FieldError `json:"details,omitempty"`
+    // MsgKey translates Msg, see package i18n; failures use ErrorCode.
+    // MsgParams fill in the translation.
+    MsgKey    string   `json:"-"`
+    MsgParams []string `json:"-"`
 }
 
 type IncentiveResponseDto struct {
@@ -42,6 +67,16 @@ func SuccessMessage(message string, data interface{}) *ResponseDto {
     }
 }
 
+// SuccessMessageKey is SuccessMessage with the key of its translations.
+func SuccessMessageKey(key, message string, data interface{}) *ResponseDto {
+    return &ResponseDto{
+        Code:   0,
+        Msg:    message,
+        Data:   data,
+        MsgKey: key,
+    }
+}
+
 func SuccessCount(data interface{}, count int64) *ResponseDto {
     return &ResponseDto{
         Code:  0,
@@ -51,6 +86,18 @@ func SuccessCount(data interface{}, count int64) *ResponseDto {
     }
 }
 
+// SuccessCursor returns a keyset-paginated page with the cursors of the
+// neighbouring pages; an empty cursor means there is no such page.
+func SuccessCursor(data interface{}, next, prev string) *ResponseDto {
+    return &ResponseDto{
+        Code: 0,
+        Msg:  "SUCCESS",
+        Data: data,
+        Next: next,
+        Prev: prev,
+    }
+}
+
 func SuccessIncentiveCount(data interface{}, count int64, totalPrice float64) *IncentiveResponseDto {
     return &IncentiveResponseDto{
         Code:       0,
@@ -75,11 +122,29 @@ func Fail(msg string) *ResponseDto {
     }
 }
 
+// FailCode reports a failure with the HTTP status code and its standard text.
 func FailCode(code int) *ResponseDto {
+    return FailWith(apperror.New(apperror.KindOf(code), "", http.StatusText(code)))
+}
+
+// FailWith reports err with its machine code and field details. The cause is
+// left out, it is not for clients.
+func FailWith(err *apperror.AppError) *ResponseDto {
     return &ResponseDto{
-        Code: code,
-        Msg:  "No Permission",
+        Code:      err.Status(),
+        Msg:       err.Message,
+        ErrorCode: err.Code,
+        Details:   err.Fields,
+        MsgParams: err.Params,
     }
 }
 
+// AppError returns the failure res reports as an AppError, for the error
+// middleware to render. Failures without a machine code get their kind's; Fail
+// is an internal error, with its message since it is written for clients.
+func (res ResponseDto) AppError() *apperror.AppError {
+    return apperror.New(apperror.KindOf(res.Code), res.ErrorCode, res.Msg).WithFields(res.Details...).
+        WithParams(res.MsgParams...)
+}
+
 type NullDto struct{}
\ No newline at end of file
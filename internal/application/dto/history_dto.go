@@ -0,0 +1,37 @@
+package dto
+
+import (
+	"encoding/json"
+	"time"
+
+	"boilerplate-golang/internal/application/entity"
+)
+
+// HistoryResponse represents one recorded change of an entity
+type HistoryResponse struct {
+	ID        int64           `json:"id"`
+	Entity    string          `json:"entity"`
+	EntityID  string          `json:"entity_id"`
+	Action    string          `json:"action"`
+	Changes   json.RawMessage `json:"changes"`
+	ActorID   string          `json:"actor_id,omitempty"`
+	RequestID string          `json:"request_id,omitempty"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+func GetHistoryResponse(entity entity.EntityHistory) HistoryResponse {
+	changes := json.RawMessage(entity.Changes)
+	if !json.Valid(changes) {
+		changes = json.RawMessage("{}")
+	}
+	return HistoryResponse{
+		ID:        entity.ID,
+		Entity:    entity.Entity,
+		EntityID:  entity.EntityID,
+		Action:    entity.Action,
+		Changes:   changes,
+		ActorID:   entity.ActorID,
+		RequestID: entity.RequestID,
+		CreatedAt: entity.CreatedAt,
+	}
+}
@@ -0,0 +1,71 @@
+package dto
+
+import (
+	"time"
+
+	"boilerplate-golang/internal/application/entity"
+)
+
+// InvitationCreateRequest represents the request body for inviting someone
+type InvitationCreateRequest struct {
+	Email string `json:"email" binding:"required,email,max=254"`
+	Role  string `json:"role" binding:"omitempty,oneof=user admin"`
+	// OrgID is rejected until users belong to organizations
+	OrgID string `json:"org_id" binding:"max=255"`
+}
+
+// InvitationAcceptRequest represents the request body for accepting an
+// invitation. For a new account, Username, FullName and Password set it up;
+// when the invited email already has an account, Password must be that
+// account's password and the other fields are ignored.
+type InvitationAcceptRequest struct {
+	Token    string `json:"token" binding:"required"`
+	Username string `json:"username" binding:"omitempty,min=3,max=50"`
+	FullName string `json:"full_name" binding:"omitempty,min=2,max=100"`
+	Password string `json:"password" binding:"required,min=8,max=72"`
+}
+
+// InvitationResponse represents an invitation
+type InvitationResponse struct {
+	ID         string     `json:"id"`
+	Email      string     `json:"email"`
+	Role       string     `json:"role"`
+	OrgID      string     `json:"org_id,omitempty"`
+	InvitedBy  string     `json:"invited_by"`
+	Status     string     `json:"status"`
+	SentCount  int        `json:"sent_count"`
+	AcceptedBy string     `json:"accepted_by,omitempty"`
+	AcceptedAt *time.Time `json:"accepted_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+func GetInvitationResponse(entity entity.Invitation) InvitationResponse {
+	return InvitationResponse{
+		ID:         entity.ID,
+		Email:      entity.Email,
+		Role:       entity.Role,
+		OrgID:      entity.OrgID,
+		InvitedBy:  entity.InvitedBy,
+		Status:     entity.CurrentStatus(time.Now().UTC()),
+		SentCount:  entity.SentCount,
+		AcceptedBy: entity.AcceptedBy,
+		AcceptedAt: entity.AcceptedAt,
+		RevokedAt:  entity.RevokedAt,
+		ExpiresAt:  entity.ExpiresAt,
+		CreatedAt:  entity.CreatedAt,
+	}
+}
+
+// InvitationPreview represents what the invitee sees before accepting
+type InvitationPreview struct {
+	Email     string `json:"email"`
+	Role      string `json:"role"`
+	OrgID     string `json:"org_id,omitempty"`
+	InvitedBy string `json:"invited_by"`
+	// HasAccount tells the client to ask for the existing account's password
+	// instead of setting up a new account
+	HasAccount bool      `json:"has_account"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
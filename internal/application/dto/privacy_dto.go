@@ -0,0 +1,53 @@
+package dto
+
+import (
+	"time"
+
+	"boilerplate-golang/internal/application/entity"
+)
+
+// ErasureRequest represents the request body for asking to erase one's account
+type ErasureRequest struct {
+	Password string `json:"password" binding:"required"`
+}
+
+// DataRequestReviewRequest represents the request body for approving or
+// rejecting an erasure request
+type DataRequestReviewRequest struct {
+	Note string `json:"note" binding:"max=500"`
+}
+
+// DataRequestResponse represents a data export or erasure request
+type DataRequestResponse struct {
+	ID           string     `json:"id"`
+	UserID       string     `json:"user_id"`
+	Kind         string     `json:"kind"`
+	Status       string     `json:"status"`
+	Note         string     `json:"note,omitempty"`
+	ReviewedAt   *time.Time `json:"reviewed_at,omitempty"`
+	ScheduledFor *time.Time `json:"scheduled_for,omitempty"`
+	ExpiresAt    *time.Time `json:"expires_at,omitempty"`
+	CompletedAt  *time.Time `json:"completed_at,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+func GetDataRequestResponse(entity entity.DataRequest) DataRequestResponse {
+	return DataRequestResponse{
+		ID:           entity.ID,
+		UserID:       entity.UserID,
+		Kind:         entity.Kind,
+		Status:       entity.Status,
+		Note:         entity.Note,
+		ReviewedAt:   entity.ReviewedAt,
+		ScheduledFor: entity.ScheduledFor,
+		ExpiresAt:    entity.ExpiresAt,
+		CompletedAt:  entity.CompletedAt,
+		CreatedAt:    entity.CreatedAt,
+	}
+}
+
+// DownloadResponse represents a temporary download link
+type DownloadResponse struct {
+	URL       string    `json:"url"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
@@ -2,23 +2,27 @@ package dto
 
 import (
 	"boilerplate-golang/internal/application/entity"
+	"boilerplate-golang/internal/infrastructure/storagemanager"
 	"time"
 )
 
-// // UserCreateRequest represents the request body for creating a new user
-// type UserCreateRequest struct {
-// 	Username string `json:"username" binding:"required,min=3,max=50"`
-// 	Email    string `json:"email" binding:"required,email"`
-// 	Password string `json:"password" binding:"required,min=8"`
-// 	FullName string `json:"full_name" binding:"required,min=2,max=100"`
-// }
-
-// // UserUpdateRequest represents the request body for updating a user
-// type UserUpdateRequest struct {
-// 	Username *string `json:"username,omitempty" binding:"omitempty,min=3,max=50"`
-// 	Email    *string `json:"email,omitempty" binding:"omitempty,email"`
-// 	FullName *string `json:"full_name,omitempty" binding:"omitempty,min=2,max=100"`
-// }
+// UserCreateRequest represents the request body for creating a new user
+type UserCreateRequest struct {
+	Username string `json:"username" binding:"required,min=3,max=50"`
+	Email    string `json:"email" binding:"required,email"`
+	Password string `json:"password" binding:"required,min=8,max=72"`
+	FullName string `json:"full_name" binding:"required,min=2,max=100"`
+}
+
+// UserUpdateRequest represents the request body for updating a user.
+// Email and password have their own endpoints, which verify the change.
+type UserUpdateRequest struct {
+	Username *string `json:"username,omitempty" binding:"omitempty,min=3,max=50"`
+	FullName *string `json:"full_name,omitempty" binding:"omitempty,min=2,max=100"`
+	// Version is the version the client last read; the update fails with a
+	// conflict if the user has changed since. The If-Match header takes precedence.
+	Version *int64 `json:"version,omitempty"`
+}
 
 // UserResponse represents the user data sent in the response
 type UserResponse struct {
@@ -26,8 +30,14 @@ type UserResponse struct {
 	Username  string    `json:"username"`
 	Email     string    `json:"email"`
 	FullName  string    `json:"full_name"`
+	Locale    string    `json:"locale,omitempty"`
+	Timezone  string    `json:"timezone,omitempty"`
+	Bio       string    `json:"bio,omitempty"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
+	Version   int64     `json:"version"`
+	// Avatar maps each avatar variant (small, medium, large) to its URL
+	Avatar map[string]string `json:"avatar,omitempty"`
 }
 
 func GetUserResponse(entity entity.User) UserResponse {
@@ -36,21 +46,87 @@ func GetUserResponse(entity entity.User) UserResponse {
 		Username:  entity.Username,
 		Email:     entity.Email,
 		FullName:  entity.FullName,
+		Locale:    entity.Locale,
+		Timezone:  entity.Timezone,
+		Bio:       entity.Bio,
+		Avatar:    avatarURLs(entity),
 		CreatedAt: entity.CreatedAt,
 		UpdatedAt: entity.UpdatedAt,
+		Version:   entity.Version,
+	}
+}
+
+// avatarURLs returns the URLs of the user's avatar variants, or nil without an avatar.
+func avatarURLs(user entity.User) map[string]string {
+	if user.AvatarKey == "" {
+		return nil
 	}
+	urls := make(map[string]string, len(entity.AvatarVariants))
+	for variant := range entity.AvatarVariants {
+		urls[variant] = storagemanager.URL(user.AvatarVariantKey(variant))
+	}
+	return urls
+}
+
+// ProfileUpdateRequest represents the request body for updating one's own
+// profile; omitted fields are left unchanged and "" clears locale, timezone or bio.
+type ProfileUpdateRequest struct {
+	FullName *string `json:"full_name,omitempty" binding:"omitempty,min=2,max=100"`
+	Locale   *string `json:"locale,omitempty" binding:"omitempty,bcp47_language_tag,max=35"`
+	Timezone *string `json:"timezone,omitempty" binding:"omitempty,timezone,max=64"`
+	Bio      *string `json:"bio,omitempty" binding:"omitempty,max=500"`
+	// Version is the version the client last read, as in UserUpdateRequest
+	Version *int64 `json:"version,omitempty"`
+}
+
+// ChangePasswordRequest represents the request body for changing one's password
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required,min=8,max=72"`
+}
+
+// EmailChangeRequest represents the request body for changing one's email;
+// the new address is only used once confirmed through the emailed link.
+type EmailChangeRequest struct {
+	Email    string `json:"email" binding:"required,email"`
+	Password string `json:"password" binding:"required"`
+}
+
+// EmailChangeConfirmRequest represents the request body for confirming an
+// email change with the token from the emailed link
+type EmailChangeConfirmRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// EmailChangePreview represents what the user sees before confirming an
+// email change
+type EmailChangePreview struct {
+	Email     string    `json:"email"`
+	ExpiresAt time.Time `json:"expires_at"`
 }
 
-// // LoginRequest represents the login request payload
-// type LoginRequest struct {
-// 	Email    string `json:"email" binding:"required,email"`
-// 	Password string `json:"password" binding:"required"`
-// }
+// ForgotPasswordRequest represents the request body for requesting a password reset link
+type ForgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// ResetPasswordRequest represents the request body for setting a new password
+// with the token from a reset or invite link
+type ResetPasswordRequest struct {
+	Token    string `json:"token" binding:"required"`
+	Password string `json:"password" binding:"required,min=8,max=72"`
+}
 
-// // TokenResponse represents the authentication token response
-// type TokenResponse struct {
-// 	AccessToken  string `json:"access_token"`
-// 	RefreshToken string `json:"refresh_token,omitempty"`
-// 	ExpiresIn    int64  `json:"expires_in"`
-// 	TokenType    string `json:"token_type"`
-// }
+// LoginRequest represents the login request payload
+type LoginRequest struct {
+	Email    string `json:"email" binding:"required,email"`
+	Password string `json:"password" binding:"required"`
+}
+
+// TokenResponse represents the authentication token response
+type TokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	ExpiresIn    int64  `json:"expires_in"`
+	TokenType    string `json:"token_type"`
+}
@@ -0,0 +1,115 @@
+package dto
+
+import (
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"boilerplate-golang/internal/application/entity"
+)
+
+// ImportRowError describes why a row of an import file was rejected. Row is
+// the line in the file, counting the header as row 1.
+type ImportRowError struct {
+	Row     int    `json:"row"`
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+// ImportReport is the result of validating an import file without importing it
+type ImportReport struct {
+	// Columns maps each user field to the file column it is read from
+	Columns map[string]string `json:"columns"`
+	Total   int               `json:"total"`
+	Valid   int               `json:"valid"`
+	Invalid int               `json:"invalid"`
+	Errors  []ImportRowError  `json:"errors"`
+}
+
+// ImportJobResponse represents the progress of a background user import
+type ImportJobResponse struct {
+	ID          string           `json:"id"`
+	Filename    string           `json:"filename"`
+	Status      string           `json:"status"`
+	SendInvites bool             `json:"send_invites"`
+	Total       int              `json:"total"`
+	Processed   int              `json:"processed"`
+	Created     int              `json:"created"`
+	Skipped     int              `json:"skipped"`
+	Failed      int              `json:"failed"`
+	Errors      []ImportRowError `json:"errors"`
+	CreatedAt   time.Time        `json:"created_at"`
+	StartedAt   *time.Time       `json:"started_at"`
+	FinishedAt  *time.Time       `json:"finished_at"`
+}
+
+func GetImportJobResponse(job entity.UserImportJob) ImportJobResponse {
+	errs := []ImportRowError{}
+	if job.Errors != "" {
+		_ = json.Unmarshal([]byte(job.Errors), &errs)
+	}
+	return ImportJobResponse{
+		ID:          job.ID,
+		Filename:    job.Filename,
+		Status:      job.Status,
+		SendInvites: job.SendInvites,
+		Total:       job.Total,
+		Processed:   job.Processed,
+		Created:     job.Created,
+		Skipped:     job.Skipped,
+		Failed:      job.Failed,
+		Errors:      errs,
+		CreatedAt:   job.CreatedAt,
+		StartedAt:   job.StartedAt,
+		FinishedAt:  job.FinishedAt,
+	}
+}
+
+// UserExportColumns is the header row of CSV and XLSX user exports, in the
+// order of UserExportRow.Record.
+var UserExportColumns = []string{
+	"id", "username", "email", "full_name", "is_active", "is_admin",
+	"locale", "timezone", "created_at", "last_login",
+}
+
+// UserExportRow represents a user in an admin export
+type UserExportRow struct {
+	ID        string     `json:"id"`
+	Username  string     `json:"username"`
+	Email     string     `json:"email"`
+	FullName  string     `json:"full_name"`
+	IsActive  bool       `json:"is_active"`
+	IsAdmin   bool       `json:"is_admin"`
+	Locale    string     `json:"locale"`
+	Timezone  string     `json:"timezone"`
+	CreatedAt time.Time  `json:"created_at"`
+	LastLogin *time.Time `json:"last_login"`
+}
+
+func GetUserExportRow(entity entity.User) UserExportRow {
+	return UserExportRow{
+		ID:        entity.ID,
+		Username:  entity.Username,
+		Email:     entity.Email,
+		FullName:  entity.FullName,
+		IsActive:  entity.IsActive,
+		IsAdmin:   entity.IsAdmin,
+		Locale:    entity.Locale,
+		Timezone:  entity.Timezone,
+		CreatedAt: entity.CreatedAt,
+		LastLogin: entity.LastLogin,
+	}
+}
+
+// Record returns the row as spreadsheet cells, matching UserExportColumns.
+func (r UserExportRow) Record() []string {
+	lastLogin := ""
+	if r.LastLogin != nil {
+		lastLogin = r.LastLogin.UTC().Format(time.RFC3339)
+	}
+	return []string{
+		r.ID, r.Username, r.Email, r.FullName,
+		strconv.FormatBool(r.IsActive), strconv.FormatBool(r.IsAdmin),
+		r.Locale, r.Timezone, r.CreatedAt.UTC().Format(time.RFC3339), lastLogin,
+	}
+}
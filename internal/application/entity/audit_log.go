@@ -0,0 +1,25 @@
+package entity
+
+import (
+	"time"
+)
+
+// AuditLog records an administrative action, such as suspending a user: who
+// did it, to what, and from which request. Details holds action specific JSON
+// and is encrypted, since it may contain personal data.
+type AuditLog struct {
+	ID         int64     `json:"id" gorm:"column:id;primaryKey;autoIncrement;comment:'Primary Key'"`
+	ActorID    string    `json:"actor_id" gorm:"column:actor_id;type:varchar(255);index:idx_audit_logs_actor_id;comment:'user who performed the action'"`
+	Action     string    `json:"action" gorm:"column:action;type:varchar(100);not null;comment:'what was done, e.g. user.suspend'"`
+	TargetType string    `json:"target_type" gorm:"column:target_type;type:varchar(100);not null;index:idx_audit_logs_target,priority:1;comment:'kind of record acted on, e.g. user'"`
+	TargetID   string    `json:"target_id" gorm:"column:target_id;type:varchar(255);not null;index:idx_audit_logs_target,priority:2;comment:'primary key of the record acted on'"`
+	Details    string    `json:"details" gorm:"column:details;type:text;serializer:encrypted;comment:'action details, encrypted'"`
+	IP         string    `json:"ip" gorm:"column:ip;type:varchar(64);comment:'client IP of the request'"`
+	RequestID  string    `json:"request_id" gorm:"column:request_id;type:varchar(128);comment:'request the action was made in'"`
+	CreatedAt  time.Time `json:"created_at" gorm:"column:created_at;type:timestamp;comment:'created at'"`
+}
+
+// TableName specifies the table name for the AuditLog model
+func (AuditLog) TableName() string {
+	return "audit_logs"
+}
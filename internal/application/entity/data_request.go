@@ -0,0 +1,49 @@
+package entity
+
+import (
+	"time"
+)
+
+// Data request kinds.
+const (
+	DataRequestExport  = "export"
+	DataRequestErasure = "erasure"
+)
+
+// Data request statuses. An export goes pending → running → ready → expired;
+// an erasure goes pending → approved → running → completed, unless it is
+// rejected by an admin or cancelled by the user first.
+const (
+	DataRequestPending   = "pending"
+	DataRequestRunning   = "running"
+	DataRequestReady     = "ready"
+	DataRequestExpired   = "expired"
+	DataRequestApproved  = "approved"
+	DataRequestRejected  = "rejected"
+	DataRequestCancelled = "cancelled"
+	DataRequestCompleted = "completed"
+	DataRequestFailed    = "failed"
+)
+
+// DataRequest is a data subject request of a user: an export of their data or
+// the erasure of their account.
+type DataRequest struct {
+	ID           string     `json:"id" gorm:"column:id;primaryKey;type:varchar(255);comment:'Primary Key'"`
+	UserID       string     `json:"user_id" gorm:"column:user_id;type:varchar(255);not null;index:idx_data_requests_user_id;comment:'user the data belongs to'"`
+	Kind         string     `json:"kind" gorm:"column:kind;type:varchar(20);not null;comment:'export or erasure'"`
+	Status       string     `json:"status" gorm:"column:status;type:varchar(20);not null;index:idx_data_requests_status;comment:'request status'"`
+	FileKey      string     `json:"-" gorm:"column:file_key;type:varchar(255);comment:'storage key of the export archive'"`
+	Note         string     `json:"note" gorm:"column:note;type:varchar(500);comment:'reason given when rejected'"`
+	ReviewedBy   string     `json:"reviewed_by" gorm:"column:reviewed_by;type:varchar(255);comment:'admin who approved or rejected the erasure'"`
+	ReviewedAt   *time.Time `json:"reviewed_at" gorm:"column:reviewed_at;type:timestamp;comment:'approved or rejected at'"`
+	ScheduledFor *time.Time `json:"scheduled_for" gorm:"column:scheduled_for;type:timestamp;comment:'end of the erasure grace period'"`
+	ExpiresAt    *time.Time `json:"expires_at" gorm:"column:expires_at;type:timestamp;comment:'export archive deleted at'"`
+	CompletedAt  *time.Time `json:"completed_at" gorm:"column:completed_at;type:timestamp;comment:'export built or erasure carried out at'"`
+	CreatedAt    time.Time  `json:"created_at" gorm:"column:created_at;type:timestamp;comment:'created at'"`
+	UpdatedAt    time.Time  `json:"updated_at" gorm:"column:updated_at;type:timestamp;comment:'updated at'"`
+}
+
+// TableName specifies the table name for the DataRequest model
+func (DataRequest) TableName() string {
+	return "data_requests"
+}
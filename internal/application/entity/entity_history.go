@@ -0,0 +1,31 @@
+package entity
+
+import (
+	"time"
+)
+
+// History actions.
+const (
+	HistoryCreate = "create"
+	HistoryUpdate = "update"
+	HistoryDelete = "delete"
+)
+
+// EntityHistory records one change to a row of an entity that opts into
+// history tracking. Changes holds the JSON of the changed fields with their
+// old and new values and is encrypted, since it may contain personal data.
+type EntityHistory struct {
+	ID        int64     `json:"id" gorm:"column:id;primaryKey;autoIncrement;index:idx_entity_histories_entity,priority:3;comment:'Primary Key'"`
+	Entity    string    `json:"entity" gorm:"column:entity;type:varchar(100);not null;index:idx_entity_histories_entity,priority:1;comment:'entity name, e.g. user'"`
+	EntityID  string    `json:"entity_id" gorm:"column:entity_id;type:varchar(255);not null;index:idx_entity_histories_entity,priority:2;comment:'primary key of the changed row'"`
+	Action    string    `json:"action" gorm:"column:action;type:varchar(20);not null;comment:'create, update or delete'"`
+	Changes   string    `json:"changes" gorm:"column:changes;type:text;serializer:encrypted;comment:'changed fields with old and new values, encrypted'"`
+	ActorID   string    `json:"actor_id" gorm:"column:actor_id;type:varchar(255);comment:'user who made the change'"`
+	RequestID string    `json:"request_id" gorm:"column:request_id;type:varchar(128);comment:'request the change was made in'"`
+	CreatedAt time.Time `json:"created_at" gorm:"column:created_at;type:timestamp;comment:'created at'"`
+}
+
+// TableName specifies the table name for the EntityHistory model
+func (EntityHistory) TableName() string {
+	return "entity_histories"
+}
@@ -0,0 +1,59 @@
+package entity
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+
+	"boilerplate-golang/internal/infrastructure/cryptomanager"
+)
+
+// Invitation statuses. A pending invitation past ExpiresAt can no longer be
+// accepted, but can still be resent or revoked.
+const (
+	InvitationPending  = "pending"
+	InvitationAccepted = "accepted"
+	InvitationRevoked  = "revoked"
+	InvitationExpired  = "expired"
+)
+
+// Invitation lets someone join by following a link mailed to Email. Accepting
+// creates an account for the email, or grants Role to the account already
+// using it. Only a hash of the link secret is stored.
+type Invitation struct {
+	ID         string     `json:"id" gorm:"column:id;primaryKey;type:varchar(255);comment:'Primary Key'"`
+	Email      string     `json:"email" gorm:"column:email;type:varchar(512);serializer:encrypted;comment:'invited email, encrypted'"`
+	EmailBidx  string     `json:"-" gorm:"column:email_bidx;type:varchar(64);index:idx_invitations_email_bidx;comment:'blind index of email'"`
+	Role       string     `json:"role" gorm:"column:role;type:varchar(20);not null;comment:'role granted on accept, user or admin'"`
+	OrgID      string     `json:"org_id" gorm:"column:org_id;type:varchar(255);comment:'organization invited to, if any'"`
+	InvitedBy  string     `json:"invited_by" gorm:"column:invited_by;type:varchar(255);not null;comment:'user who sent the invitation'"`
+	Status     string     `json:"status" gorm:"column:status;type:varchar(20);not null;index:idx_invitations_status;comment:'pending, accepted or revoked'"`
+	TokenHash  string     `json:"-" gorm:"column:token_hash;type:varchar(64);not null;uniqueIndex:idx_invitations_token_hash;comment:'SHA-256 of the link secret'"`
+	SentCount  int        `json:"sent_count" gorm:"column:sent_count;not null;default:1;comment:'times the invitation was mailed'"`
+	AcceptedBy string     `json:"accepted_by" gorm:"column:accepted_by;type:varchar(255);comment:'user created or linked on accept'"`
+	AcceptedAt *time.Time `json:"accepted_at" gorm:"column:accepted_at;type:timestamp;comment:'accepted at'"`
+	RevokedAt  *time.Time `json:"revoked_at" gorm:"column:revoked_at;type:timestamp;comment:'revoked at'"`
+	ExpiresAt  time.Time  `json:"expires_at" gorm:"column:expires_at;type:timestamp;comment:'link end'"`
+	CreatedAt  time.Time  `json:"created_at" gorm:"column:created_at;type:timestamp;comment:'created at'"`
+	UpdatedAt  time.Time  `json:"updated_at" gorm:"column:updated_at;type:timestamp;comment:'updated at'"`
+}
+
+// TableName specifies the table name for the Invitation model
+func (Invitation) TableName() string {
+	return "invitations"
+}
+
+// CurrentStatus returns the status, reporting pending invitations past their
+// expiry as expired.
+func (i Invitation) CurrentStatus(now time.Time) string {
+	if i.Status == InvitationPending && !now.Before(i.ExpiresAt) {
+		return InvitationExpired
+	}
+	return i.Status
+}
+
+// BeforeSave keeps the email blind index in sync with the email.
+func (i *Invitation) BeforeSave(tx *gorm.DB) (err error) {
+	i.EmailBidx, err = cryptomanager.BlindIndex(i.Email)
+	return err
+}
@@ -0,0 +1,34 @@
+package entity
+
+import (
+	"time"
+)
+
+// Outbox message statuses.
+const (
+	OutboxPending   = "pending"
+	OutboxPublished = "published"
+	OutboxDead      = "dead"
+)
+
+// OutboxMessage is a domain event written in the same transaction as the
+// change that produced it and published afterwards by the outbox relay.
+type OutboxMessage struct {
+	ID            int64      `json:"id" gorm:"column:id;primaryKey;autoIncrement;index:idx_outbox_messages_status,priority:2;comment:'Primary Key, defines publish order'"`
+	EventID       string     `json:"event_id" gorm:"column:event_id;type:varchar(36);not null;uniqueIndex:idx_outbox_messages_event_id;comment:'event id for consumer deduplication'"`
+	AggregateType string     `json:"aggregate_type" gorm:"column:aggregate_type;type:varchar(100);not null;index:idx_outbox_messages_aggregate;comment:'aggregate type'"`
+	AggregateID   string     `json:"aggregate_id" gorm:"column:aggregate_id;type:varchar(255);not null;index:idx_outbox_messages_aggregate;comment:'aggregate id'"`
+	EventType     string     `json:"event_type" gorm:"column:event_type;type:varchar(100);not null;comment:'event type'"`
+	Payload       string     `json:"payload" gorm:"column:payload;type:text;comment:'event payload as JSON'"`
+	Status        string     `json:"status" gorm:"column:status;type:varchar(20);not null;index:idx_outbox_messages_status,priority:1;comment:'pending, published or dead'"`
+	Attempts      int        `json:"attempts" gorm:"column:attempts;not null;default:0;comment:'publish attempts'"`
+	LastError     string     `json:"last_error" gorm:"column:last_error;type:text;comment:'last publish error'"`
+	AvailableAt   time.Time  `json:"available_at" gorm:"column:available_at;type:timestamp;comment:'next publish attempt'"`
+	CreatedAt     time.Time  `json:"created_at" gorm:"column:created_at;type:timestamp;comment:'created at'"`
+	PublishedAt   *time.Time `json:"published_at" gorm:"column:published_at;type:timestamp;comment:'published at'"`
+}
+
+// TableName specifies the table name for the OutboxMessage model
+func (OutboxMessage) TableName() string {
+	return "outbox_messages"
+}
@@ -4,21 +4,42 @@ import (
 	"time"
 
 	"gorm.io/gorm"
+
+	"boilerplate-golang/internal/infrastructure/cryptomanager"
 )
 
 // User represents a user record in the database.
 type User struct {
 	ID        string         `json:"id" gorm:"column:id;primaryKey;type:varchar(255);comment:'Primary Key'"`
 	Username  string         `json:"username" gorm:"column:username;type:varchar(50);comment:'username to login'"`
-	Email     string         `json:"email" gorm:"column:email;type:varchar(100);comment:'email to login'"`
-	Password  string         `json:"password" gorm:"column:password;type:varchar(255);comment:'password to login'"`
-	FullName  string         `json:"full_name" gorm:"column:full_name;type:varchar(100);comment:'full name'"`
+	Email     string         `json:"email" gorm:"column:email;type:varchar(512);serializer:encrypted;comment:'email to login, encrypted'"`
+	EmailBidx string         `json:"-" gorm:"column:email_bidx;type:varchar(64);history:skip;index:idx_users_email_bidx;comment:'blind index of email'"`
+	Password  string         `json:"password" gorm:"column:password;type:varchar(255);history:mask;comment:'password to login'"`
+	FullName  string         `json:"full_name" gorm:"column:full_name;type:varchar(512);serializer:encrypted;comment:'full name, encrypted'"`
+	Locale    string         `json:"locale" gorm:"column:locale;type:varchar(35);comment:'preferred language, BCP 47 tag'"`
+	Timezone  string         `json:"timezone" gorm:"column:timezone;type:varchar(64);comment:'IANA time zone'"`
+	Bio       string         `json:"bio" gorm:"column:bio;type:varchar(500);comment:'profile text'"`
+	AvatarKey string         `json:"avatar_key" gorm:"column:avatar_key;type:varchar(255);comment:'storage key prefix of the avatar variants'"`
 	IsActive  bool           `json:"is_active" gorm:"column:is_active;type:boolean;comment:'is active'"`
 	IsAdmin   bool           `json:"is_admin" gorm:"column:is_admin;type:boolean;comment:'is admin'"`
-	LastLogin *time.Time     `json:"last_login" gorm:"column:last_login;type:timestamp;comment:'last login'"`
+	LastLogin *time.Time     `json:"last_login" gorm:"column:last_login;type:timestamp;history:skip;comment:'last login'"`
 	CreatedAt time.Time      `json:"created_at" gorm:"column:created_at;type:timestamp;comment:'created at'"`
-	UpdatedAt time.Time      `json:"updated_at" gorm:"column:updated_at;type:timestamp;comment:'updated at'"`
+	UpdatedAt time.Time      `json:"updated_at" gorm:"column:updated_at;type:timestamp;history:skip;comment:'updated at'"`
 	DeletedAt gorm.DeletedAt `json:"deleted_at" gorm:"column:deleted_at;type:timestamp;comment:'deleted at'"`
+	Version   int64          `json:"version" gorm:"column:version;not null;history:skip;default:1;comment:'optimistic lock version'"`
+}
+
+// User roles, as carried in access tokens.
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
+// AvatarVariants maps the avatar variants to their square size in pixels.
+var AvatarVariants = map[string]int{
+	"small":  64,
+	"medium": 256,
+	"large":  512,
 }
 
 // TableName specifies the table name for the User model
@@ -26,6 +47,26 @@ func (User) TableName() string {
 	return "users"
 }
 
+// HistoryName opts users into change history (see dbmanager.Tracked).
+func (User) HistoryName() string {
+	return "user"
+}
+
+// AvatarVariantKey returns the storage key of an avatar variant, or "" when
+// the user has no avatar.
+func (u User) AvatarVariantKey(variant string) string {
+	if u.AvatarKey == "" {
+		return ""
+	}
+	return u.AvatarKey + "/" + variant + ".jpg"
+}
+
+// BeforeSave keeps the email blind index in sync with the email.
+func (u *User) BeforeSave(tx *gorm.DB) (err error) {
+	u.EmailBidx, err = cryptomanager.BlindIndex(u.Email)
+	return err
+}
+
 // BeforeCreate sets timestamps and performs any pre-creation logic.
 func (u *User) BeforeCreate(tx *gorm.DB) (err error) {
 	now := time.Now().UTC()
@@ -0,0 +1,39 @@
+package entity
+
+import (
+	"time"
+)
+
+// User import job statuses.
+const (
+	ImportPending   = "pending"
+	ImportRunning   = "running"
+	ImportCompleted = "completed"
+	ImportFailed    = "failed"
+)
+
+// UserImportJob tracks a bulk user import running in the background. Rows
+// rejected by validation are counted as skipped, rows whose account could not
+// be created as failed; Errors lists both per row as JSON.
+type UserImportJob struct {
+	ID          string     `json:"id" gorm:"column:id;primaryKey;type:varchar(255);comment:'Primary Key'"`
+	CreatedBy   string     `json:"created_by" gorm:"column:created_by;type:varchar(255);index:idx_user_import_jobs_created_by;comment:'admin who started the import'"`
+	Filename    string     `json:"filename" gorm:"column:filename;type:varchar(255);comment:'uploaded file name'"`
+	Status      string     `json:"status" gorm:"column:status;type:varchar(20);not null;comment:'pending, running, completed or failed'"`
+	SendInvites bool       `json:"send_invites" gorm:"column:send_invites;comment:'mail created users a link to set their password'"`
+	Total       int        `json:"total" gorm:"column:total;not null;default:0;comment:'data rows in the file'"`
+	Processed   int        `json:"processed" gorm:"column:processed;not null;default:0;comment:'rows handled so far'"`
+	Created     int        `json:"created" gorm:"column:created;not null;default:0;comment:'users created'"`
+	Skipped     int        `json:"skipped" gorm:"column:skipped;not null;default:0;comment:'rows rejected by validation'"`
+	Failed      int        `json:"failed" gorm:"column:failed;not null;default:0;comment:'rows whose user could not be created'"`
+	Errors      string     `json:"errors" gorm:"column:errors;type:text;comment:'row errors as JSON'"`
+	CreatedAt   time.Time  `json:"created_at" gorm:"column:created_at;type:timestamp;comment:'created at'"`
+	UpdatedAt   time.Time  `json:"updated_at" gorm:"column:updated_at;type:timestamp;comment:'last progress update'"`
+	StartedAt   *time.Time `json:"started_at" gorm:"column:started_at;type:timestamp;comment:'processing start'"`
+	FinishedAt  *time.Time `json:"finished_at" gorm:"column:finished_at;type:timestamp;comment:'processing end'"`
+}
+
+// TableName specifies the table name for the UserImportJob model
+func (UserImportJob) TableName() string {
+	return "user_import_jobs"
+}
@@ -0,0 +1,27 @@
+package entity
+
+import (
+	"time"
+)
+
+// UserSession is a login session. Tokens issued at login carry its ID, and
+// revoking the session (logout, password change) invalidates them.
+type UserSession struct {
+	ID        string     `json:"id" gorm:"column:id;primaryKey;type:varchar(255);comment:'Primary Key'"`
+	UserID    string     `json:"user_id" gorm:"column:user_id;type:varchar(255);not null;index:idx_user_sessions_user_id;comment:'owner of the session'"`
+	UserAgent string     `json:"user_agent" gorm:"column:user_agent;type:varchar(255);comment:'client the session was created from'"`
+	IP        string     `json:"ip" gorm:"column:ip;type:varchar(64);comment:'client IP at login'"`
+	CreatedAt time.Time  `json:"created_at" gorm:"column:created_at;type:timestamp;comment:'created at'"`
+	ExpiresAt time.Time  `json:"expires_at" gorm:"column:expires_at;type:timestamp;comment:'session end'"`
+	RevokedAt *time.Time `json:"revoked_at" gorm:"column:revoked_at;type:timestamp;comment:'set when the session was ended early'"`
+}
+
+// TableName specifies the table name for the UserSession model
+func (UserSession) TableName() string {
+	return "user_sessions"
+}
+
+// Active reports whether the session can still be used at now.
+func (s UserSession) Active(now time.Time) bool {
+	return s.RevokedAt == nil && now.Before(s.ExpiresAt)
+}
@@ -0,0 +1,30 @@
+package entity
+
+import (
+	"time"
+)
+
+// User token purposes.
+const (
+	TokenEmailChange   = "email_change"
+	TokenPasswordReset = "password_reset"
+)
+
+// UserToken is a single-use secret sent to a user by email, e.g. to confirm a
+// new email address. Only a hash of the secret is stored; Payload holds the
+// data the token applies (such as the new address) and is encrypted.
+type UserToken struct {
+	ID        string     `json:"id" gorm:"column:id;primaryKey;type:varchar(255);comment:'Primary Key'"`
+	UserID    string     `json:"user_id" gorm:"column:user_id;type:varchar(255);not null;index:idx_user_tokens_user_id;comment:'user the token was issued to'"`
+	Purpose   string     `json:"purpose" gorm:"column:purpose;type:varchar(30);not null;comment:'what the token confirms, e.g. email_change'"`
+	TokenHash string     `json:"-" gorm:"column:token_hash;type:varchar(64);not null;uniqueIndex:idx_user_tokens_token_hash;comment:'SHA-256 of the secret'"`
+	Payload   string     `json:"-" gorm:"column:payload;type:text;serializer:encrypted;comment:'data applied on use, encrypted'"`
+	CreatedAt time.Time  `json:"created_at" gorm:"column:created_at;type:timestamp;comment:'created at'"`
+	ExpiresAt time.Time  `json:"expires_at" gorm:"column:expires_at;type:timestamp;comment:'token end'"`
+	UsedAt    *time.Time `json:"used_at" gorm:"column:used_at;type:timestamp;comment:'set when the token was used or superseded'"`
+}
+
+// TableName specifies the table name for the UserToken model
+func (UserToken) TableName() string {
+	return "user_tokens"
+}
@@ -0,0 +1,13 @@
+package i18n
+
+// enCatalog only holds plural forms: English messages are written where the
+// error is reported.
+var enCatalog = Catalog{
+	Plurals: map[string]Forms{
+		"body_too_large":       {One: "Request body must be at most {0} byte", Other: "Request body must be at most {0} bytes"},
+		"import_too_many_rows": {One: "Import file must have at most {0} row", Other: "Import file must have at most {0} rows"},
+		"export_too_many_rows": {One: "Export is limited to {0} user, narrow the filters", Other: "Export is limited to {0} users, narrow the filters"},
+		"avatar_too_large":     {One: "Avatar must be at most {0} pixel", Other: "Avatar must be at most {0} pixels"},
+		"validation_more":      {One: "(and {0} more error)", Other: "(and {0} more errors)"},
+	},
+}
@@ -0,0 +1,119 @@
+package i18n
+
+// esCatalog is the Spanish catalog.
+var esCatalog = Catalog{
+	Messages: map[string]string{
+		// Fallbacks by error kind, for codes without their own message
+		"bad_request":         "Solicitud no válida",
+		"unauthorized":        "Autenticación requerida",
+		"forbidden":           "Acceso denegado",
+		"not_found":           "Recurso no encontrado",
+		"conflict":            "La solicitud entra en conflicto con el estado actual del recurso",
+		"precondition_failed": "El recurso no coincide con If-Match, recárguelo e inténtelo de nuevo",
+		"validation_failed":   "La solicitud contiene campos no válidos",
+		"internal":            "Error interno del servidor",
+
+		// Requests
+		"missing_token":               "El encabezado Authorization es obligatorio",
+		"invalid_token":               "Token no válido o caducado",
+		"session_revoked":             "La sesión ha caducado o ha sido revocada",
+		"admin_required":              "Se requiere acceso de administrador",
+		"client_certificate_required": "Se requiere un certificado de cliente",
+		"invalid_body":                "El cuerpo de la solicitud debe ser JSON válido",
+		"missing_body":                "El cuerpo de la solicitud es obligatorio",
+		"invalid_query":               "Parámetros de consulta no válidos",
+		"invalid_cursor":              "El cursor de paginación no es válido para esta lista",
+		"invalid_if_match":            "If-Match debe ser un ETag devuelto por esta API",
+		"unreadable_file":             "No se pudo leer el archivo",
+		"unsupported_api_version":     "Versión de la API no admitida: {0}, versiones disponibles: {1}",
+		"route_not_found":             "No hay ruta para {0} {1}",
+		"version_conflict":            "Otra persona modificó el recurso, recárguelo e inténtelo de nuevo",
+		"unknown_entity":              "Entidad desconocida",
+
+		// Accounts
+		"invalid_credentials": "Correo electrónico o contraseña incorrectos",
+		"account_disabled":    "La cuenta está desactivada",
+		"incorrect_password":  "La contraseña es incorrecta",
+		"invalid_link":        "El enlace no es válido o ha caducado",
+		"invite_only":         "El registro es solo por invitación",
+		"org_unsupported":     "Las invitaciones a una organización aún no están disponibles",
+		"session_unbound":     "El token no está vinculado a ninguna sesión",
+		"email_unchanged":     "Este ya es su correo electrónico",
+		"password_unchanged":  "La nueva contraseña debe ser distinta de la actual",
+		"password_changed":    "Contraseña cambiada",
+		"email_changed":       "Correo electrónico cambiado",
+
+		// Users
+		"user_not_found":         "Usuario no encontrado",
+		"deleted_user_not_found": "Usuario eliminado no encontrado",
+		"email_taken":            "El correo electrónico ya está en uso",
+		"username_taken":         "El nombre de usuario ya existe",
+		"user_exists":            "Otro usuario ya usa el nombre de usuario o el correo electrónico",
+		"invalid_email":          "Formato de correo electrónico no válido",
+		"fields_required":        "Todos los campos son obligatorios",
+		"username_has_spaces":    "El nombre de usuario no debe contener espacios",
+		"user_updated":           "Usuario actualizado",
+		"profile_updated":        "Perfil actualizado",
+		"avatar_unsupported":     "El avatar debe ser una imagen JPEG, PNG o GIF",
+		"avatar_unreadable":      "No se pudo leer la imagen del avatar",
+		"avatar_updated":         "Avatar actualizado",
+		"avatar_removed":         "Avatar eliminado",
+
+		// User administration
+		"user_inactive":          "El usuario está suspendido",
+		"user_not_admin":         "El usuario no es administrador",
+		"user_already_suspended": "El usuario ya está suspendido",
+		"user_already_admin":     "El usuario ya es administrador",
+		"user_already_active":    "El usuario ya está activo",
+		"cannot_suspend_self":    "No puede suspender su propia cuenta",
+		"cannot_demote_self":     "No puede degradar su propia cuenta",
+		"last_admin":             "El usuario es el último administrador activo",
+		"user_suspended":         "Usuario suspendido",
+		"user_reactivated":       "Usuario reactivado",
+		"user_promoted":          "Usuario ascendido a administrador, a partir de su próximo inicio de sesión",
+		"user_demoted":           "Usuario degradado",
+		"user_deleted":           "Usuario eliminado",
+		"user_logged_out":        "Usuario desconectado de todas sus sesiones",
+		"password_reset_sent":    "Enlace de restablecimiento de contraseña enviado",
+
+		// Imports
+		"import_unsupported":   "El archivo de importación debe ser un archivo .csv o .xlsx",
+		"import_empty":         "El archivo de importación no tiene filas de datos",
+		"invalid_import_file":  "El archivo de importación no es válido",
+		"import_job_not_found": "Tarea de importación no encontrada",
+		"import_started":       "Importación iniciada",
+
+		// Invitations
+		"invitation_pending":       "Este correo electrónico ya tiene una invitación pendiente, reenvíela en su lugar",
+		"user_has_access":          "Un usuario con este correo electrónico ya tiene este acceso",
+		"account_details_required": "username y full_name son obligatorios para crear una cuenta",
+		"invitation_not_found":     "Invitación no encontrada",
+		"invitation_not_pending":   "La invitación está en estado {0}, solo se pueden cambiar las invitaciones pendientes",
+
+		// Privacy
+		"export_in_progress":  "Ya se está preparando una exportación de datos",
+		"export_not_found":    "Exportación de datos no encontrada",
+		"export_not_ready":    "La exportación de datos está en estado {0}, no está lista para descargar",
+		"erasure_requested":   "Ya se solicitó la eliminación de la cuenta",
+		"no_open_erasure":     "No hay ninguna solicitud de eliminación abierta",
+		"erasure_not_found":   "Solicitud de eliminación no encontrada",
+		"erasure_not_pending": "La solicitud de eliminación está en estado {0}, solo se pueden revisar las solicitudes pendientes",
+
+		// Fields, by the rule they break
+		"field_required":           "{0} es un campo requerido",
+		"field_boolean":            "{0} debe ser true o false",
+		"field_json":               "{0} debe ser un objeto JSON que asocie campos a nombres de columna",
+		"field_oneof":              "{0} debe ser uno de [{1}]",
+		"field_max":                "{0} debe ser como máximo {1}",
+		"field_type":               "{0} debe ser de tipo {1}",
+		"field_bcp47_language_tag": "{0} debe ser una etiqueta de idioma como es-ES",
+		"field_timezone":           "{0} debe ser una zona horaria IANA como Europe/Madrid",
+	},
+	Plurals: map[string]Forms{
+		"body_too_large":       {One: "El cuerpo de la solicitud debe tener como máximo {0} byte", Other: "El cuerpo de la solicitud debe tener como máximo {0} bytes"},
+		"import_too_many_rows": {One: "El archivo de importación debe tener como máximo {0} fila", Other: "El archivo de importación debe tener como máximo {0} filas"},
+		"export_too_many_rows": {One: "La exportación está limitada a {0} usuario, acote los filtros", Other: "La exportación está limitada a {0} usuarios, acote los filtros"},
+		"avatar_too_large":     {One: "El avatar debe tener como máximo {0} píxel", Other: "El avatar debe tener como máximo {0} píxeles"},
+		"validation_more":      {One: "(y {0} error más)", Other: "(y {0} errores más)"},
+	},
+}
@@ -0,0 +1,119 @@
+package i18n
+
+// frCatalog is the French catalog.
+var frCatalog = Catalog{
+	Messages: map[string]string{
+		// Fallbacks by error kind, for codes without their own message
+		"bad_request":         "Requête invalide",
+		"unauthorized":        "Authentification requise",
+		"forbidden":           "Accès refusé",
+		"not_found":           "Ressource introuvable",
+		"conflict":            "La requête est en conflit avec l'état actuel de la ressource",
+		"precondition_failed": "La ressource ne correspond pas à If-Match, rechargez-la et réessayez",
+		"validation_failed":   "La requête contient des champs invalides",
+		"internal":            "Erreur interne du serveur",
+
+		// Requests
+		"missing_token":               "L'en-tête Authorization est obligatoire",
+		"invalid_token":               "Jeton invalide ou expiré",
+		"session_revoked":             "La session a expiré ou a été révoquée",
+		"admin_required":              "Accès administrateur requis",
+		"client_certificate_required": "Certificat client requis",
+		"invalid_body":                "Le corps de la requête doit être du JSON valide",
+		"missing_body":                "Le corps de la requête est obligatoire",
+		"invalid_query":               "Paramètres de liste invalides",
+		"invalid_cursor":              "Le curseur de pagination n'est pas valide pour cette liste",
+		"invalid_if_match":            "If-Match doit être un ETag renvoyé par cette API",
+		"unreadable_file":             "Le fichier n'a pas pu être lu",
+		"unsupported_api_version":     "Version d'API non prise en charge : {0}, versions disponibles : {1}",
+		"route_not_found":             "Aucune route pour {0} {1}",
+		"version_conflict":            "La ressource a été modifiée par quelqu'un d'autre, rechargez-la et réessayez",
+		"unknown_entity":              "Entité inconnue",
+
+		// Accounts
+		"invalid_credentials": "E-mail ou mot de passe incorrect",
+		"account_disabled":    "Le compte est désactivé",
+		"incorrect_password":  "Le mot de passe est incorrect",
+		"invalid_link":        "Le lien est invalide ou a expiré",
+		"invite_only":         "L'inscription se fait uniquement sur invitation",
+		"org_unsupported":     "Les invitations à une organisation ne sont pas encore prises en charge",
+		"session_unbound":     "Le jeton n'est lié à aucune session",
+		"email_unchanged":     "C'est déjà votre adresse e-mail",
+		"password_unchanged":  "Le nouveau mot de passe doit être différent de l'actuel",
+		"password_changed":    "Mot de passe modifié",
+		"email_changed":       "Adresse e-mail modifiée",
+
+		// Users
+		"user_not_found":         "Utilisateur introuvable",
+		"deleted_user_not_found": "Utilisateur supprimé introuvable",
+		"email_taken":            "Cette adresse e-mail est déjà utilisée",
+		"username_taken":         "Ce nom d'utilisateur existe déjà",
+		"user_exists":            "Le nom d'utilisateur ou l'adresse e-mail est déjà utilisé par un autre utilisateur",
+		"invalid_email":          "Format d'adresse e-mail invalide",
+		"fields_required":        "Tous les champs sont obligatoires",
+		"username_has_spaces":    "Le nom d'utilisateur ne doit pas contenir d'espaces",
+		"user_updated":           "Utilisateur mis à jour",
+		"profile_updated":        "Profil mis à jour",
+		"avatar_unsupported":     "L'avatar doit être une image JPEG, PNG ou GIF",
+		"avatar_unreadable":      "L'image de l'avatar n'a pas pu être lue",
+		"avatar_updated":         "Avatar mis à jour",
+		"avatar_removed":         "Avatar supprimé",
+
+		// User administration
+		"user_inactive":          "L'utilisateur est suspendu",
+		"user_not_admin":         "L'utilisateur n'est pas administrateur",
+		"user_already_suspended": "L'utilisateur est déjà suspendu",
+		"user_already_admin":     "L'utilisateur est déjà administrateur",
+		"user_already_active":    "L'utilisateur est déjà actif",
+		"cannot_suspend_self":    "Vous ne pouvez pas suspendre votre propre compte",
+		"cannot_demote_self":     "Vous ne pouvez pas rétrograder votre propre compte",
+		"last_admin":             "L'utilisateur est le dernier administrateur actif",
+		"user_suspended":         "Utilisateur suspendu",
+		"user_reactivated":       "Utilisateur réactivé",
+		"user_promoted":          "Utilisateur promu administrateur, à partir de sa prochaine connexion",
+		"user_demoted":           "Utilisateur rétrogradé",
+		"user_deleted":           "Utilisateur supprimé",
+		"user_logged_out":        "Utilisateur déconnecté de toutes ses sessions",
+		"password_reset_sent":    "Lien de réinitialisation du mot de passe envoyé",
+
+		// Imports
+		"import_unsupported":   "Le fichier d'import doit être un fichier .csv ou .xlsx",
+		"import_empty":         "Le fichier d'import ne contient aucune ligne de données",
+		"invalid_import_file":  "Le fichier d'import est invalide",
+		"import_job_not_found": "Tâche d'import introuvable",
+		"import_started":       "Import lancé",
+
+		// Invitations
+		"invitation_pending":       "Cette adresse e-mail a déjà une invitation en attente, renvoyez-la plutôt",
+		"user_has_access":          "Un utilisateur avec cette adresse e-mail a déjà cet accès",
+		"account_details_required": "username et full_name sont obligatoires pour créer un compte",
+		"invitation_not_found":     "Invitation introuvable",
+		"invitation_not_pending":   "L'invitation est au statut {0}, seules les invitations en attente peuvent être modifiées",
+
+		// Privacy
+		"export_in_progress":  "Un export de données est déjà en préparation",
+		"export_not_found":    "Export de données introuvable",
+		"export_not_ready":    "L'export de données est au statut {0}, il n'est pas prêt à être téléchargé",
+		"erasure_requested":   "La suppression du compte a déjà été demandée",
+		"no_open_erasure":     "Aucune demande de suppression en cours",
+		"erasure_not_found":   "Demande de suppression introuvable",
+		"erasure_not_pending": "La demande de suppression est au statut {0}, seules les demandes en attente peuvent être examinées",
+
+		// Fields, by the rule they break
+		"field_required":           "{0} est un champ obligatoire",
+		"field_boolean":            "{0} doit valoir true ou false",
+		"field_json":               "{0} doit être un objet JSON associant les champs aux noms de colonnes",
+		"field_oneof":              "{0} doit être l'une des valeurs [{1}]",
+		"field_max":                "{0} doit faire au plus {1}",
+		"field_type":               "{0} doit être de type {1}",
+		"field_bcp47_language_tag": "{0} doit être une balise de langue comme fr-FR",
+		"field_timezone":           "{0} doit être un fuseau horaire IANA comme Europe/Paris",
+	},
+	Plurals: map[string]Forms{
+		"body_too_large":       {One: "Le corps de la requête ne doit pas dépasser {0} octet", Other: "Le corps de la requête ne doit pas dépasser {0} octets"},
+		"import_too_many_rows": {One: "Le fichier d'import doit contenir au plus {0} ligne", Other: "Le fichier d'import doit contenir au plus {0} lignes"},
+		"export_too_many_rows": {One: "L'export est limité à {0} utilisateur, affinez les filtres", Other: "L'export est limité à {0} utilisateurs, affinez les filtres"},
+		"avatar_too_large":     {One: "L'avatar doit faire au plus {0} pixel", Other: "L'avatar doit faire au plus {0} pixels"},
+		"validation_more":      {One: "(et {0} autre erreur)", Other: "(et {0} autres erreurs)"},
+	},
+}
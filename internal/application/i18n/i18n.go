@@ -0,0 +1,173 @@
+// Package i18n translates API messages. Messages are keyed by the error code
+// or message key the application reports; the English text where it is
+// reported is the source, so the English catalog only holds plural forms.
+// Catalogs are loaded into a universal translator, which also carries the
+// validator's translations of binding errors.
+package i18n
+
+import (
+	"log"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-playground/locales"
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/es"
+	"github.com/go-playground/locales/fr"
+	ut "github.com/go-playground/universal-translator"
+)
+
+// Default is the locale of requests that ask for none we support.
+const Default = "en"
+
+// Catalog holds the messages of one language.
+type Catalog struct {
+	// Messages are texts with {0}, {1}... replaced by the params in order
+	Messages map[string]string
+	// Plurals are texts chosen by the count given as the first param, which
+	// replaces {0}
+	Plurals map[string]Forms
+}
+
+// Forms holds the CLDR plural forms a message needs in its language.
+type Forms struct {
+	One   string
+	Other string
+}
+
+var (
+	catalogs = map[string]Catalog{"en": enCatalog, "fr": frCatalog, "es": esCatalog}
+	uni      = ut.New(en.New(), en.New(), fr.New(), es.New())
+
+	placeholderRe = regexp.MustCompile(`\{(\d+)\}`)
+)
+
+func init() {
+	for locale, catalog := range catalogs {
+		trans, _ := uni.GetTranslator(locale)
+		for key, text := range catalog.Messages {
+			must(trans.Add(key, text, false))
+		}
+		for key, p := range catalog.Plurals {
+			must(trans.AddCardinal(key, p.One, locales.PluralRuleOne, false))
+			must(trans.AddCardinal(key, p.Other, locales.PluralRuleOther, false))
+		}
+	}
+	must(uni.VerifyTranslations())
+}
+
+func must(err error) {
+	if err != nil {
+		log.Fatalf("i18n: invalid catalog: %v", err)
+	}
+}
+
+// Supported lists the locales with a catalog.
+func Supported() []string {
+	list := make([]string, 0, len(catalogs))
+	for locale := range catalogs {
+		list = append(list, locale)
+	}
+	sort.Strings(list)
+	return list
+}
+
+// Translator returns the translator of a supported locale, for the validator.
+func Translator(locale string) ut.Translator {
+	trans, _ := uni.GetTranslator(locale)
+	return trans
+}
+
+// Match returns the supported locale of a BCP 47 tag such as "fr-CA", "" when
+// there is none.
+func Match(tag string) string {
+	tag = strings.ToLower(strings.ReplaceAll(strings.TrimSpace(tag), "_", "-"))
+	if _, ok := catalogs[tag]; ok {
+		return tag
+	}
+	base, _, _ := strings.Cut(tag, "-")
+	if _, ok := catalogs[base]; ok {
+		return base
+	}
+	return ""
+}
+
+// Negotiate picks the supported locale the Accept-Language header prefers,
+// Default when it names none of them.
+func Negotiate(acceptLanguage string) string {
+	type weighted struct {
+		tag string
+		q   float64
+	}
+	var tags []weighted
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if tag == "" || tag == "*" {
+			continue
+		}
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if f, err := strconv.ParseFloat(v, 64); err == nil {
+				q = f
+			}
+		}
+		if q > 0 {
+			tags = append(tags, weighted{tag, q})
+		}
+	}
+	sort.SliceStable(tags, func(i, j int) bool { return tags[i].q > tags[j].q })
+
+	for _, t := range tags {
+		if locale := Match(t.tag); locale != "" {
+			return locale
+		}
+	}
+	return Default
+}
+
+// Text returns the message key in locale with params substituted, and false
+// when the catalog of locale lacks it. Plural messages take the count as the
+// first param and format it for the locale.
+func Text(locale, key string, params ...string) (string, bool) {
+	catalog, ok := catalogs[locale]
+	if !ok {
+		return "", false
+	}
+	trans := Translator(locale)
+
+	if text, ok := catalog.Messages[key]; ok {
+		// Missing params are left empty rather than failing the message
+		if n := arity(text); len(params) < n {
+			params = append(params, make([]string, n-len(params))...)
+		}
+		msg, err := trans.T(key, params...)
+		return msg, err == nil
+	}
+	if _, ok := catalog.Plurals[key]; ok && len(params) > 0 {
+		n, err := strconv.ParseFloat(params[0], 64)
+		if err != nil {
+			return "", false
+		}
+		msg, err := trans.C(key, n, 0, trans.FmtNumber(n, 0))
+		return msg, err == nil
+	}
+	return "", false
+}
+
+// Plural returns the plural message key in locale for count.
+func Plural(locale, key string, count int) (string, bool) {
+	return Text(locale, key, strconv.Itoa(count))
+}
+
+// arity is the number of params text takes.
+func arity(text string) int {
+	n := 0
+	for _, m := range placeholderRe.FindAllStringSubmatch(text, -1) {
+		if i, _ := strconv.Atoi(m[1]); i+1 > n {
+			n = i + 1
+		}
+	}
+	return n
+}
@@ -0,0 +1,25 @@
+package i18n
+
+import (
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	en_translations "github.com/go-playground/validator/v10/translations/en"
+	es_translations "github.com/go-playground/validator/v10/translations/es"
+	fr_translations "github.com/go-playground/validator/v10/translations/fr"
+)
+
+// RegisterValidator adds the validator's own translations of its rules for
+// every supported locale, so binding errors read as the rest of the response.
+func RegisterValidator(v *validator.Validate) error {
+	register := map[string]func(*validator.Validate, ut.Translator) error{
+		"en": en_translations.RegisterDefaultTranslations,
+		"fr": fr_translations.RegisterDefaultTranslations,
+		"es": es_translations.RegisterDefaultTranslations,
+	}
+	for locale, fn := range register {
+		if err := fn(v, Translator(locale)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,509 @@
+// Package openapi builds the OpenAPI 3 document of the API from the routes
+// registered on the engine and a table of operations describing them: the
+// request and response DTOs, whose schemas come from their json and binding
+// tags, the ResponseDto envelope they are sent in and who may call them.
+package openapi
+
+import (
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"boilerplate-golang/internal/application/dto"
+	"boilerplate-golang/internal/application/queryspec"
+)
+
+// Version is the OpenAPI version of the documents Build returns.
+const Version = "3.0.3"
+
+// Access says who may call an operation.
+type Access int
+
+const (
+	// User operations need a bearer token
+	User Access = iota
+	// Public operations are whitelisted from authentication
+	Public
+	// Admin operations need the token of an admin
+	Admin
+)
+
+// Operation documents one route.
+type Operation struct {
+	// Method and Path are the route as registered with gin, Path relative to
+	// the API base, e.g. "/users/:id"
+	Method string
+	Path   string
+
+	Tag         string
+	Summary     string
+	Description string
+	Access      Access
+
+	// Query, Headers and Form list the parameters read from the query, the
+	// headers and a multipart form; form fields of Type "file" are uploads
+	Query   []Param
+	Headers []Param
+	Form    []Param
+	// List adds the filter, sort, search and page parameters of a queryspec
+	// listing and its count and cursors to the response
+	List *queryspec.Whitelist
+	// Body is a value of the JSON request body, e.g. dto.LoginRequest{};
+	// OptionalBody when it may be left out
+	Body         interface{}
+	OptionalBody bool
+
+	// Response is a value of the data of the envelope, nil when there is
+	// none. Plain sends it as is, without the envelope.
+	Response interface{}
+	Plain    bool
+	// Files lists the content types of a file download, sent instead of JSON
+	Files []string
+	// Status is the status of success, 200 when zero
+	Status int
+	// ETag is set when the response carries the version of the resource
+	ETag bool
+	// Deprecated marks operations on their way out, which answer with the
+	// Deprecation and Sunset headers
+	Deprecated bool
+	// Errors lists error statuses beyond the ones every operation of its kind
+	// can fail with
+	Errors []int
+}
+
+// Param is a query, header or form parameter.
+type Param struct {
+	Name        string
+	Description string
+	// Type is a JSON schema type, "string" when empty, or "file"
+	Type     string
+	Format   string
+	Enum     []string
+	Required bool
+}
+
+// Document is an OpenAPI document, with the parts of the specification the
+// API uses.
+type Document struct {
+	OpenAPI    string                `json:"openapi"`
+	Info       Info                  `json:"info"`
+	Servers    []Server              `json:"servers,omitempty"`
+	Security   []map[string][]string `json:"security,omitempty"`
+	Tags       []Tag                 `json:"tags,omitempty"`
+	Paths      map[string]PathItem   `json:"paths"`
+	Components Components            `json:"components"`
+}
+
+type Info struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+type Server struct {
+	URL         string `json:"url"`
+	Description string `json:"description,omitempty"`
+}
+
+type Tag struct {
+	Name string `json:"name"`
+}
+
+// PathItem maps the lowercase methods of a path to their operation.
+type PathItem map[string]*OperationObject
+
+type OperationObject struct {
+	Tags        []string             `json:"tags,omitempty"`
+	Summary     string               `json:"summary,omitempty"`
+	Description string               `json:"description,omitempty"`
+	OperationID string               `json:"operationId"`
+	Parameters  []*ParameterObject   `json:"parameters,omitempty"`
+	RequestBody *RequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*Response `json:"responses"`
+	// Security is empty, not absent, on public operations
+	Security   *[]map[string][]string `json:"security,omitempty"`
+	Deprecated bool                   `json:"deprecated,omitempty"`
+}
+
+type ParameterObject struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+type Response struct {
+	Ref         string               `json:"$ref,omitempty"`
+	Description string               `json:"description,omitempty"`
+	Headers     map[string]Header    `json:"headers,omitempty"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+type Header struct {
+	Description string  `json:"description,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+type Components struct {
+	Schemas         map[string]*Schema        `json:"schemas"`
+	Responses       map[string]*Response      `json:"responses"`
+	SecuritySchemes map[string]SecurityScheme `json:"securitySchemes"`
+}
+
+type SecurityScheme struct {
+	Type         string `json:"type"`
+	Scheme       string `json:"scheme"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+	Description  string `json:"description,omitempty"`
+}
+
+// bearerAuth is the security scheme of the API's JWTs.
+const bearerAuth = "bearerAuth"
+
+// Errors every operation can fail with, by what it takes. Each status is a
+// response component named after its text.
+var (
+	authErrors  = []int{http.StatusUnauthorized}
+	adminErrors = []int{http.StatusUnauthorized, http.StatusForbidden}
+	bodyErrors  = []int{http.StatusBadRequest, http.StatusUnprocessableEntity}
+	listErrors  = []int{http.StatusBadRequest}
+	pathErrors  = []int{http.StatusNotFound}
+	allErrors   = []int{http.StatusInternalServerError}
+)
+
+// pathParamRe matches the :name and *name parameters of gin paths.
+var pathParamRe = regexp.MustCompile(`[:*](\w+)`)
+
+// Build returns the document of the routes registered under base that ops
+// documents. Routes without an operation are left out, see Check.
+func Build(info Info, servers []Server, base string, routes gin.RoutesInfo, ops []Operation) *Document {
+	b := newSchemas()
+	doc := &Document{
+		OpenAPI:  Version,
+		Info:     info,
+		Servers:  servers,
+		Security: []map[string][]string{{bearerAuth: {}}},
+		Paths:    map[string]PathItem{},
+		Components: Components{
+			Schemas:   b.components,
+			Responses: map[string]*Response{},
+			SecuritySchemes: map[string]SecurityScheme{
+				bearerAuth: {Type: "http", Scheme: "bearer", BearerFormat: "JWT",
+					Description: "Access token returned by POST /auth/login"},
+			},
+		},
+	}
+	envelope := b.schemaOf(dto.ResponseDto{})
+
+	registered := map[string]bool{}
+	for _, r := range routes {
+		if path, ok := strings.CutPrefix(r.Path, base); ok {
+			registered[r.Method+" "+path] = true
+		}
+	}
+
+	tags := map[string]bool{}
+	for _, op := range ops {
+		if !registered[op.Method+" "+op.Path] {
+			continue
+		}
+		path := pathParamRe.ReplaceAllString(op.Path, "{$1}")
+		if doc.Paths[path] == nil {
+			doc.Paths[path] = PathItem{}
+		}
+		doc.Paths[path][strings.ToLower(op.Method)] = b.operation(op, envelope, doc.Components.Responses)
+		if op.Tag != "" && !tags[op.Tag] {
+			tags[op.Tag] = true
+			doc.Tags = append(doc.Tags, Tag{Name: op.Tag})
+		}
+	}
+	sort.Slice(doc.Tags, func(i, j int) bool { return doc.Tags[i].Name < doc.Tags[j].Name })
+	return doc
+}
+
+// Check compares the routes registered under base with ops. Undocumented
+// lists the routes without an operation and stale the operations without a
+// route, both as "METHOD path".
+func Check(base string, routes gin.RoutesInfo, ops []Operation) (undocumented, stale []string) {
+	documented := map[string]bool{}
+	for _, op := range ops {
+		documented[op.Method+" "+op.Path] = true
+	}
+	registered := map[string]bool{}
+	for _, r := range routes {
+		path, ok := strings.CutPrefix(r.Path, base)
+		if !ok {
+			continue
+		}
+		key := r.Method + " " + path
+		registered[key] = true
+		if !documented[key] {
+			undocumented = append(undocumented, key)
+		}
+	}
+	for _, op := range ops {
+		if key := op.Method + " " + op.Path; !registered[key] {
+			stale = append(stale, key)
+		}
+	}
+	sort.Strings(undocumented)
+	sort.Strings(stale)
+	return undocumented, stale
+}
+
+// operation builds the object of op, adding the error responses it uses to
+// responses.
+func (b *schemas) operation(op Operation, envelope *Schema, responses map[string]*Response) *OperationObject {
+	obj := &OperationObject{
+		Summary:     op.Summary,
+		Description: op.Description,
+		OperationID: operationID(op),
+		Responses:   map[string]*Response{},
+		Deprecated:  op.Deprecated,
+	}
+	if op.Tag != "" {
+		obj.Tags = []string{op.Tag}
+	}
+
+	errs := append([]int{}, allErrors...)
+	switch op.Access {
+	case Public:
+		obj.Security = &[]map[string][]string{}
+	case Admin:
+		errs = append(errs, adminErrors...)
+	default:
+		errs = append(errs, authErrors...)
+	}
+
+	for _, m := range pathParamRe.FindAllStringSubmatch(op.Path, -1) {
+		p := &ParameterObject{Name: m[1], In: "path", Required: true, Schema: &Schema{Type: "string"}}
+		if strings.HasPrefix(m[0], "*") {
+			p.Description = "Rest of the path, slashes included"
+		}
+		obj.Parameters = append(obj.Parameters, p)
+		errs = append(errs, pathErrors...)
+	}
+	for _, p := range op.Query {
+		obj.Parameters = append(obj.Parameters, parameter(p, "query"))
+	}
+	if op.List != nil {
+		obj.Parameters = append(obj.Parameters, listParameters(*op.List)...)
+		errs = append(errs, listErrors...)
+	}
+	for _, p := range op.Headers {
+		obj.Parameters = append(obj.Parameters, parameter(p, "header"))
+	}
+
+	if op.Body != nil {
+		obj.RequestBody = &RequestBody{Required: !op.OptionalBody, Content: map[string]MediaType{
+			"application/json": {Schema: b.schemaOf(op.Body)},
+		}}
+		errs = append(errs, bodyErrors...)
+	}
+	if len(op.Form) > 0 {
+		form := &Schema{Type: "object", Properties: map[string]*Schema{}}
+		for _, p := range op.Form {
+			form.Properties[p.Name] = paramSchema(p)
+			if p.Required {
+				form.Required = append(form.Required, p.Name)
+			}
+		}
+		obj.RequestBody = &RequestBody{Required: true, Content: map[string]MediaType{
+			"multipart/form-data": {Schema: form},
+		}}
+		errs = append(errs, bodyErrors...)
+	}
+	errs = append(errs, op.Errors...)
+
+	status := op.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	obj.Responses[strconv.Itoa(status)] = b.success(op, envelope)
+
+	for _, code := range errs {
+		name := strings.ReplaceAll(http.StatusText(code), " ", "")
+		if responses[name] == nil {
+			responses[name] = &Response{
+				Description: http.StatusText(code),
+				Content:     map[string]MediaType{"application/json": {Schema: envelope}},
+			}
+		}
+		obj.Responses[strconv.Itoa(code)] = &Response{Ref: "#/components/responses/" + name}
+	}
+	return obj
+}
+
+// success returns the response of op when it succeeds.
+func (b *schemas) success(op Operation, envelope *Schema) *Response {
+	res := &Response{Description: "Success", Headers: map[string]Header{}}
+	if op.ETag {
+		res.Headers["ETag"] = Header{
+			Description: "Version of the resource, for If-Match",
+			Schema:      &Schema{Type: "string"},
+		}
+	}
+	if op.Deprecated {
+		res.Headers["Deprecation"] = Header{
+			Description: "When the operation was deprecated, as @ and Unix seconds (RFC 9745)",
+			Schema:      &Schema{Type: "string"},
+		}
+		res.Headers["Sunset"] = Header{
+			Description: "When the operation stops being served, as an HTTP date (RFC 8594)",
+			Schema:      &Schema{Type: "string"},
+		}
+	}
+
+	switch {
+	case len(op.Files) > 0:
+		res.Content = map[string]MediaType{}
+		for _, contentType := range op.Files {
+			res.Content[contentType] = MediaType{Schema: &Schema{Type: "string", Format: "binary"}}
+		}
+	case op.Plain:
+		res.Content = map[string]MediaType{"application/json": {Schema: b.schemaOf(op.Response)}}
+	default:
+		data := &Schema{Description: "Absent when the operation returns no data"}
+		if op.Response != nil {
+			data = b.schemaOf(op.Response)
+		}
+		if op.List != nil {
+			data = &Schema{Type: "array", Items: data}
+		}
+		schema := &Schema{AllOf: []*Schema{envelope, {
+			Type:       "object",
+			Properties: map[string]*Schema{"data": data},
+		}}}
+		res.Content = map[string]MediaType{"application/json": {Schema: schema}}
+	}
+	return res
+}
+
+// operationID names op after its method and path, e.g. get_users_id.
+func operationID(op Operation) string {
+	id := strings.ToLower(op.Method)
+	for _, part := range strings.Split(op.Path, "/") {
+		part = strings.TrimLeft(part, ":*")
+		part = strings.ReplaceAll(part, "-", "_")
+		if part != "" {
+			id += "_" + part
+		}
+	}
+	return id
+}
+
+// parameter returns p as a parameter found in in.
+func parameter(p Param, in string) *ParameterObject {
+	schema := paramSchema(p)
+	schema.Description = ""
+	return &ParameterObject{
+		Name:        p.Name,
+		In:          in,
+		Description: p.Description,
+		Required:    p.Required,
+		Schema:      schema,
+	}
+}
+
+func paramSchema(p Param) *Schema {
+	s := &Schema{Type: p.Type, Format: p.Format, Enum: p.Enum, Description: p.Description}
+	switch p.Type {
+	case "":
+		s.Type = "string"
+	case "file":
+		s.Type, s.Format = "string", "binary"
+	}
+	return s
+}
+
+// filterTypes are the schemas of the queryspec value types.
+var filterTypes = map[int]*Schema{
+	queryspec.TypeString: {Type: "string"},
+	queryspec.TypeBool:   {Type: "boolean"},
+	queryspec.TypeInt:    {Type: "integer"},
+	queryspec.TypeTime:   {Type: "string", Format: "date-time"},
+}
+
+// opWords describe the filter operators.
+var opWords = map[string]string{
+	queryspec.OpNe:   "differs from",
+	queryspec.OpLike: "contains",
+	queryspec.OpGt:   "is greater than",
+	queryspec.OpGte:  "is at least",
+	queryspec.OpLt:   "is less than",
+	queryspec.OpLte:  "is at most",
+}
+
+// listParameters returns the query parameters of a listing whitelisted by wl.
+func listParameters(wl queryspec.Whitelist) []*ParameterObject {
+	var params []*ParameterObject
+
+	fields := make([]string, 0, len(wl.Filters))
+	for name := range wl.Filters {
+		fields = append(fields, name)
+	}
+	sort.Strings(fields)
+	for _, name := range fields {
+		field := wl.Filters[name]
+		for _, op := range field.Ops {
+			p := &ParameterObject{In: "query", Schema: filterTypes[field.Type]}
+			switch op {
+			case queryspec.OpEq:
+				p.Name = "filter[" + name + "]"
+				p.Description = name + " equals the value"
+			case queryspec.OpIn:
+				p.Name = "filter[" + name + "][in]"
+				p.Description = name + " is one of the comma-separated values"
+				p.Schema = &Schema{Type: "string"}
+			default:
+				p.Name = "filter[" + name + "][" + op + "]"
+				p.Description = name + " " + opWords[op] + " the value"
+			}
+			params = append(params, p)
+		}
+	}
+
+	if len(wl.Sorts) > 0 {
+		sorts := make([]string, 0, len(wl.Sorts))
+		for name := range wl.Sorts {
+			sorts = append(sorts, name)
+		}
+		sort.Strings(sorts)
+		desc := "Comma-separated fields to sort by, - first for descending: " + strings.Join(sorts, ", ")
+		if wl.DefaultSort != "" {
+			desc += " (default " + wl.DefaultSort + ")"
+		}
+		params = append(params, &ParameterObject{Name: "sort", In: "query", Description: desc, Schema: &Schema{Type: "string"}})
+	}
+	if len(wl.Search) > 0 {
+		params = append(params, &ParameterObject{Name: "q", In: "query",
+			Description: "Free-text search on " + strings.Join(wl.Search, ", "), Schema: &Schema{Type: "string"}})
+	}
+
+	minimum, maximum := 1.0, float64(wl.PageSizeLimit())
+	params = append(params,
+		&ParameterObject{Name: "page[number]", In: "query", Description: "Page of an offset listing, which has a count",
+			Schema: &Schema{Type: "integer", Minimum: &minimum}},
+		&ParameterObject{Name: "page[size]", In: "query", Description: "Rows per page",
+			Schema: &Schema{Type: "integer", Minimum: &minimum, Maximum: &maximum}},
+		&ParameterObject{Name: "page[after]", In: "query",
+			Description: "Cursor of a keyset listing, from next; empty for the first page", Schema: &Schema{Type: "string"}},
+		&ParameterObject{Name: "page[before]", In: "query",
+			Description: "Cursor of a keyset listing, from prev", Schema: &Schema{Type: "string"}},
+	)
+	return params
+}
@@ -0,0 +1,9 @@
+package openapi
+
+import _ "embed"
+
+// DocsPage is a self-contained page that renders the document served at
+// openapi.json next to it, or at openapi/<version>.json with ?version=.
+//
+//go:embed docs.html
+var DocsPage []byte
@@ -0,0 +1,259 @@
+package openapi
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schema is a JSON schema, as OpenAPI 3.0 extends it.
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	AllOf                []*Schema          `json:"allOf,omitempty"`
+}
+
+var (
+	timeType = reflect.TypeOf(time.Time{})
+	rawType  = reflect.TypeOf(json.RawMessage{})
+)
+
+// ruleFormats are the string formats of validator rules.
+var ruleFormats = map[string]string{
+	"email": "email",
+	"url":   "uri",
+	"uri":   "uri",
+	"uuid":  "uuid",
+}
+
+// ruleDescriptions describe validator rules without a format.
+var ruleDescriptions = map[string]string{
+	"bcp47_language_tag": "BCP 47 language tag, e.g. fr-FR",
+	"timezone":           "IANA time zone, e.g. Europe/Paris",
+}
+
+// schemas builds the schemas of Go types. Named structs become components,
+// referenced wherever they are used.
+type schemas struct {
+	components map[string]*Schema
+	// names holds the component name of each struct type
+	names map[reflect.Type]string
+}
+
+func newSchemas() *schemas {
+	return &schemas{components: map[string]*Schema{}, names: map[reflect.Type]string{}}
+}
+
+// schemaOf returns the schema of the type of v.
+func (b *schemas) schemaOf(v interface{}) *Schema {
+	return b.schema(reflect.TypeOf(v))
+}
+
+func (b *schemas) schema(t reflect.Type) *Schema {
+	if t == nil {
+		return &Schema{}
+	}
+	switch t {
+	case timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case rawType:
+		return &Schema{Description: "Any JSON value"}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		s := b.schema(t.Elem())
+		if s.Ref != "" {
+			// Siblings of $ref are ignored, so nullable needs a wrapper
+			return &Schema{AllOf: []*Schema{s}, Nullable: true}
+		}
+		s.Nullable = true
+		return s
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: b.schema(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: b.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return b.object(t)
+		}
+		return b.ref(t)
+	}
+	// Interfaces hold any value
+	return &Schema{}
+}
+
+// ref returns a reference to the component of the named struct t, building it
+// the first time.
+func (b *schemas) ref(t reflect.Type) *Schema {
+	name, ok := b.names[t]
+	if !ok {
+		name = t.Name()
+		if _, taken := b.components[name]; taken {
+			// Another package has a type of that name
+			name = pkgName(t) + name
+		}
+		b.names[t] = name
+		// Registered before it is built, for types that refer to themselves
+		b.components[name] = &Schema{}
+		*b.components[name] = *b.object(t)
+	}
+	return &Schema{Ref: "#/components/schemas/" + name}
+}
+
+// object returns the schema of the fields of struct t, as encoding/json
+// encodes them.
+func (b *schemas) object(t reflect.Type) *Schema {
+	s := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	b.fields(t, s)
+	return s
+}
+
+func (b *schemas) fields(t reflect.Type, s *Schema) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if f.Anonymous && name == "" {
+			// Fields of embedded structs are promoted
+			et := f.Type
+			if et.Kind() == reflect.Ptr {
+				et = et.Elem()
+			}
+			if et.Kind() == reflect.Struct {
+				b.fields(et, s)
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		prop := b.schema(f.Type)
+		binding := f.Tag.Get("binding")
+		applyRules(prop, f.Type, binding)
+		s.Properties[name] = prop
+		if required(f, binding, opts) {
+			s.Required = append(s.Required, name)
+		}
+	}
+}
+
+// required reports whether field f is always present: request fields when
+// their binding requires them, others unless they may be omitted.
+func required(f reflect.StructField, binding, opts string) bool {
+	if binding != "" {
+		for _, rule := range strings.Split(binding, ",") {
+			if rule == "required" {
+				return true
+			}
+		}
+		return false
+	}
+	return !strings.Contains(opts, "omitempty") && f.Type.Kind() != reflect.Ptr
+}
+
+// applyRules adds the constraints of the validator rules of a binding tag to
+// the schema s of a field of type t.
+func applyRules(s *Schema, t reflect.Type, binding string) {
+	if binding == "" {
+		return
+	}
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	for _, rule := range strings.Split(binding, ",") {
+		name, param, _ := strings.Cut(rule, "=")
+		switch name {
+		case "min", "max", "len":
+			n, err := strconv.Atoi(param)
+			if err != nil {
+				continue
+			}
+			setBound(s, t, name, n)
+		case "oneof":
+			s.Enum = strings.Fields(param)
+		default:
+			if format, ok := ruleFormats[name]; ok {
+				s.Format = format
+			} else if desc, ok := ruleDescriptions[name]; ok {
+				s.Description = desc
+			}
+		}
+	}
+}
+
+// setBound applies a min, max or len rule, which bounds the length of strings,
+// the size of slices and the value of numbers.
+func setBound(s *Schema, t reflect.Type, rule string, n int) {
+	lower, upper := rule != "max", rule != "min"
+	switch t.Kind() {
+	case reflect.String:
+		if lower {
+			s.MinLength = &n
+		}
+		if upper {
+			s.MaxLength = &n
+		}
+	case reflect.Slice, reflect.Array:
+		if lower {
+			s.MinItems = &n
+		}
+		if upper {
+			s.MaxItems = &n
+		}
+	default:
+		f := float64(n)
+		if lower {
+			s.Minimum = &f
+		}
+		if upper {
+			s.Maximum = &f
+		}
+	}
+}
+
+// pkgName returns the name of the package of t, capitalized.
+func pkgName(t reflect.Type) string {
+	path := t.PkgPath()
+	name := path[strings.LastIndex(path, "/")+1:]
+	if name == "" {
+		return ""
+	}
+	return strings.ToUpper(name[:1]) + name[1:]
+}
@@ -0,0 +1,50 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"boilerplate-golang/internal/application/entity"
+	"boilerplate-golang/internal/application/tools"
+	"boilerplate-golang/internal/infrastructure/dbmanager"
+)
+
+// wake nudges the relay so committed events go out without waiting for the next poll.
+var wake = make(chan struct{}, 1)
+
+// Enqueue records a domain event for the aggregate. Call it with the context
+// of the transaction that changes the aggregate (see dbmanager.WithTx) so the
+// event is stored if and only if the change commits.
+func Enqueue(ctx context.Context, aggregateType, aggregateID, eventType string, payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s payload: %w", eventType, err)
+	}
+
+	now := time.Now().UTC()
+	msg := entity.OutboxMessage{
+		EventID:       tools.NewUuid(),
+		AggregateType: aggregateType,
+		AggregateID:   aggregateID,
+		EventType:     eventType,
+		Payload:       string(data),
+		Status:        entity.OutboxPending,
+		AvailableAt:   now,
+		CreatedAt:     now,
+	}
+	if err := dbmanager.DB(ctx).Create(&msg).Error; err != nil {
+		return fmt.Errorf("failed to enqueue %s: %w", eventType, err)
+	}
+
+	dbmanager.AfterCommit(ctx, notify)
+	return nil
+}
+
+func notify() {
+	select {
+	case wake <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,245 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"gorm.io/gorm/clause"
+
+	"boilerplate-golang/internal/application/entity"
+	"boilerplate-golang/internal/infrastructure/config"
+	"boilerplate-golang/internal/infrastructure/dbmanager"
+	"boilerplate-golang/internal/infrastructure/eventbus"
+	"boilerplate-golang/internal/infrastructure/logger"
+	"boilerplate-golang/internal/infrastructure/redismanager"
+)
+
+const (
+	defaultPollInterval = time.Second
+	defaultBatchSize    = 100
+	defaultMaxAttempts  = 10
+	defaultRetention    = 7 * 24 * time.Hour
+	defaultRedisChannel = "events."
+	maxBackoff          = 5 * time.Minute
+	cleanupInterval     = time.Hour
+)
+
+// Publisher delivers outbox events to their consumers.
+type Publisher interface {
+	Publish(ctx context.Context, e eventbus.Event) error
+}
+
+// BusPublisher delivers events to in-process subscribers.
+type BusPublisher struct{}
+
+// Publish implements Publisher.
+func (BusPublisher) Publish(ctx context.Context, e eventbus.Event) error {
+	return eventbus.Publish(ctx, e)
+}
+
+// RedisPublisher publishes events as JSON on the Redis channel Prefix+type.
+type RedisPublisher struct {
+	Prefix string
+}
+
+// Publish implements Publisher.
+func (p RedisPublisher) Publish(ctx context.Context, e eventbus.Event) error {
+	if redismanager.Redis == nil {
+		return errors.New("redis is not configured")
+	}
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	return redismanager.Redis.Publish(ctx, p.Prefix+e.Type, data).Err()
+}
+
+// Start runs the relay in the background until ctx is done. It publishes
+// pending outbox rows in id order, retrying failures with exponential backoff,
+// and periodically deletes delivered rows past the retention period.
+func Start(ctx context.Context) {
+	cfg := config.Get().Outbox
+	if !cfg.Enabled {
+		logger.Info("outbox: relay disabled")
+		return
+	}
+	if dbmanager.GetDB() == nil {
+		logger.Warn("outbox: database not connected, relay not started")
+		return
+	}
+
+	var pub Publisher = BusPublisher{}
+	if cfg.Publisher == "redis" {
+		prefix := cfg.RedisChannel
+		if prefix == "" {
+			prefix = defaultRedisChannel
+		}
+		pub = RedisPublisher{Prefix: prefix}
+	}
+
+	go run(ctx, pub)
+	logger.Info("outbox: relay started (publisher: %T)", pub)
+}
+
+func run(ctx context.Context, pub Publisher) {
+	cfg := config.Get().Outbox
+	interval := cfg.PollInterval
+	if interval <= 0 {
+		interval = defaultPollInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var lastCleanup time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-wake:
+		}
+
+		// Keep draining while full batches are attempted
+		for {
+			n, err := RelayOnce(ctx, pub)
+			if err != nil {
+				logger.Error("outbox: relay failed: %v", err)
+				break
+			}
+			if n < batchSize() {
+				break
+			}
+		}
+
+		if time.Since(lastCleanup) >= cleanupInterval {
+			if n, err := Cleanup(ctx); err != nil {
+				logger.Error("outbox: cleanup failed: %v", err)
+			} else if n > 0 {
+				logger.Info("outbox: deleted %d delivered message(s)", n)
+			}
+			lastCleanup = time.Now()
+		}
+	}
+}
+
+// RelayOnce publishes one batch of pending messages and returns how many it
+// attempted to publish. Messages of one aggregate are published strictly in
+// order: once one fails or is waiting for a retry, the later ones wait too, and
+// waiting messages are left out of the batch so they don't crowd out others.
+// Rows are locked for the batch so concurrent relays on other replicas don't
+// publish them twice.
+func RelayOnce(ctx context.Context, pub Publisher) (int, error) {
+	cfg := config.Get().Outbox
+	maxAttempts := cfg.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = defaultMaxAttempts
+	}
+
+	var attempted int
+	process := func(txCtx context.Context) error {
+		db := dbmanager.DB(txCtx)
+		attempted = 0
+
+		now := time.Now().UTC()
+		query := db.Where("status = ? AND available_at <= ?", entity.OutboxPending, now).
+			Where("NOT EXISTS (?)", db.Table("outbox_messages AS earlier").Select("1").
+				Where("earlier.status = ? AND earlier.available_at > ?", entity.OutboxPending, now).
+				Where("earlier.aggregate_type = outbox_messages.aggregate_type AND earlier.aggregate_id = outbox_messages.aggregate_id").
+				Where("earlier.id < outbox_messages.id")).
+			Order("id").Limit(batchSize())
+		if dbmanager.Dialect() != dbmanager.DriverSQLite {
+			query = query.Clauses(clause.Locking{Strength: "UPDATE"})
+		}
+		var msgs []entity.OutboxMessage
+		if err := query.Find(&msgs).Error; err != nil {
+			return err
+		}
+
+		blocked := make(map[string]bool)
+		for _, m := range msgs {
+			key := m.AggregateType + "/" + m.AggregateID
+			if blocked[key] {
+				continue
+			}
+			attempted++
+
+			// Handlers get the caller's context, not the relay transaction
+			if err := pub.Publish(ctx, toEvent(m)); err != nil {
+				blocked[key] = true
+				updates := map[string]interface{}{
+					"attempts":     m.Attempts + 1,
+					"last_error":   err.Error(),
+					"available_at": now.Add(backoff(m.Attempts + 1)),
+				}
+				if m.Attempts+1 >= maxAttempts {
+					updates["status"] = entity.OutboxDead
+					logger.Error("outbox: giving up on %s %s after %d attempts: %v", m.EventType, m.EventID, m.Attempts+1, err)
+				} else {
+					logger.Warn("outbox: publishing %s %s failed (attempt %d): %v", m.EventType, m.EventID, m.Attempts+1, err)
+				}
+				if err := db.Model(&entity.OutboxMessage{}).Where("id = ?", m.ID).Updates(updates).Error; err != nil {
+					return err
+				}
+				continue
+			}
+
+			if err := db.Model(&entity.OutboxMessage{}).Where("id = ?", m.ID).Updates(map[string]interface{}{
+				"status":       entity.OutboxPublished,
+				"published_at": now,
+			}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	// SQLite has a single connection and a single relay, so no row locks are needed
+	// and holding a transaction would starve handlers that touch the database
+	if dbmanager.Dialect() == dbmanager.DriverSQLite {
+		return attempted, process(ctx)
+	}
+	err := dbmanager.WithTx(ctx, process)
+	return attempted, err
+}
+
+// Cleanup deletes published messages older than outbox.retention.
+// Dead messages are kept for inspection.
+func Cleanup(ctx context.Context) (int64, error) {
+	retention := config.Get().Outbox.Retention
+	if retention <= 0 {
+		retention = defaultRetention
+	}
+	res := dbmanager.DB(ctx).
+		Where("status = ? AND published_at < ?", entity.OutboxPublished, time.Now().UTC().Add(-retention)).
+		Delete(&entity.OutboxMessage{})
+	return res.RowsAffected, res.Error
+}
+
+func toEvent(m entity.OutboxMessage) eventbus.Event {
+	return eventbus.Event{
+		ID:            m.EventID,
+		Type:          m.EventType,
+		AggregateType: m.AggregateType,
+		AggregateID:   m.AggregateID,
+		Payload:       json.RawMessage(m.Payload),
+		OccurredAt:    m.CreatedAt,
+	}
+}
+
+// backoff returns the delay before retry n: 2s, 4s, 8s... capped at maxBackoff.
+func backoff(n int) time.Duration {
+	d := time.Second << uint(n)
+	if d <= 0 || d > maxBackoff {
+		return maxBackoff
+	}
+	return d
+}
+
+func batchSize() int {
+	if n := config.Get().Outbox.BatchSize; n > 0 {
+		return n
+	}
+	return defaultBatchSize
+}
@@ -0,0 +1,199 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"boilerplate-golang/internal/application/entity"
+	"boilerplate-golang/internal/infrastructure/config"
+	"boilerplate-golang/internal/infrastructure/dbmanager"
+	"boilerplate-golang/internal/infrastructure/dbmanager/dbtest"
+	"boilerplate-golang/internal/infrastructure/eventbus"
+)
+
+// fakePublisher records the events it publishes and fails those of the
+// aggregates in failing.
+type fakePublisher struct {
+	failing   map[string]bool
+	published []string
+}
+
+func (p *fakePublisher) Publish(_ context.Context, e eventbus.Event) error {
+	if p.failing[e.AggregateID] {
+		return errors.New("broker down")
+	}
+	p.published = append(p.published, e.AggregateID+":"+e.Type)
+	return nil
+}
+
+func setup(t *testing.T, batchSize, maxAttempts int) {
+	t.Helper()
+	dbtest.Open(t)
+	cfg := config.Get()
+	cfg.Outbox.BatchSize = batchSize
+	cfg.Outbox.MaxAttempts = maxAttempts
+	config.Set(cfg)
+}
+
+func enqueue(t *testing.T, aggregateID, eventType string) {
+	t.Helper()
+	if err := Enqueue(context.Background(), "user", aggregateID, eventType, nil); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func messages(t *testing.T) []entity.OutboxMessage {
+	t.Helper()
+	var msgs []entity.OutboxMessage
+	if err := dbmanager.GetDB().Order("id").Find(&msgs).Error; err != nil {
+		t.Fatal(err)
+	}
+	return msgs
+}
+
+func TestRelayOnceBlocksLaterEventsOfFailedAggregate(t *testing.T) {
+	setup(t, 10, 5)
+	enqueue(t, "a", "first")
+	enqueue(t, "b", "first")
+	enqueue(t, "a", "second")
+
+	pub := &fakePublisher{failing: map[string]bool{"a": true}}
+	n, err := RelayOnce(context.Background(), pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 {
+		t.Errorf("attempted = %d, want 2 (a:first and b:first)", n)
+	}
+	if len(pub.published) != 1 || pub.published[0] != "b:first" {
+		t.Errorf("published = %v, want [b:first]", pub.published)
+	}
+
+	msgs := messages(t)
+	if msgs[0].Attempts != 1 || msgs[0].LastError != "broker down" || !msgs[0].AvailableAt.After(time.Now()) {
+		t.Errorf("failed message = %+v, want 1 attempt in backoff", msgs[0])
+	}
+	if msgs[2].Attempts != 0 || msgs[2].Status != entity.OutboxPending {
+		t.Errorf("a:second = %+v, want untouched", msgs[2])
+	}
+}
+
+func TestRelayOnceSkipsAggregatesInBackoff(t *testing.T) {
+	setup(t, 2, 5)
+	enqueue(t, "a", "first")
+	enqueue(t, "a", "second")
+	enqueue(t, "a", "third")
+
+	pub := &fakePublisher{failing: map[string]bool{"a": true}}
+	if _, err := RelayOnce(context.Background(), pub); err != nil {
+		t.Fatal(err)
+	}
+	enqueue(t, "b", "first")
+	pub.failing = nil
+
+	// The waiting rows of a fill more than a batch, b must still go out
+	n, err := RelayOnce(context.Background(), pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 || len(pub.published) != 1 || pub.published[0] != "b:first" {
+		t.Errorf("attempted %d, published %v, want 1 and [b:first]", n, pub.published)
+	}
+
+	// Nothing is available until the backoff ends, which stops the drain loop
+	n, err = RelayOnce(context.Background(), pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 0 {
+		t.Errorf("attempted = %d while a is in backoff, want 0", n)
+	}
+
+	// Once available again, a goes out in order
+	if err := dbmanager.GetDB().Model(&entity.OutboxMessage{}).Where("aggregate_id = ?", "a").
+		Update("available_at", time.Now().UTC().Add(-time.Second)).Error; err != nil {
+		t.Fatal(err)
+	}
+	for {
+		if n, err = RelayOnce(context.Background(), pub); err != nil {
+			t.Fatal(err)
+		}
+		if n == 0 {
+			break
+		}
+	}
+	want := []string{"b:first", "a:first", "a:second", "a:third"}
+	if len(pub.published) != len(want) {
+		t.Fatalf("published = %v, want %v", pub.published, want)
+	}
+	for i := range want {
+		if pub.published[i] != want[i] {
+			t.Fatalf("published = %v, want %v", pub.published, want)
+		}
+	}
+}
+
+func TestRelayOnceWaitsForEarlierEventInBackoff(t *testing.T) {
+	setup(t, 10, 5)
+	enqueue(t, "a", "first")
+	enqueue(t, "a", "second")
+
+	// The first event is waiting for a retry, the second is available
+	if err := dbmanager.GetDB().Model(&entity.OutboxMessage{}).Where("event_type = ?", "first").
+		Update("available_at", time.Now().UTC().Add(time.Minute)).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	pub := &fakePublisher{}
+	n, err := RelayOnce(context.Background(), pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 0 || len(pub.published) != 0 {
+		t.Errorf("attempted %d, published %v, want nothing before a:first", n, pub.published)
+	}
+}
+
+func TestRelayOnceGivesUpAfterMaxAttempts(t *testing.T) {
+	setup(t, 10, 2)
+	enqueue(t, "a", "first")
+	pub := &fakePublisher{failing: map[string]bool{"a": true}}
+
+	for attempt := 1; attempt <= 2; attempt++ {
+		if _, err := RelayOnce(context.Background(), pub); err != nil {
+			t.Fatal(err)
+		}
+		if err := dbmanager.GetDB().Model(&entity.OutboxMessage{}).Where("1 = 1").
+			Update("available_at", time.Now().UTC().Add(-time.Second)).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	msgs := messages(t)
+	if msgs[0].Status != entity.OutboxDead || msgs[0].Attempts != 2 {
+		t.Errorf("message = %s after %d attempts, want dead after 2", msgs[0].Status, msgs[0].Attempts)
+	}
+	if n, _ := RelayOnce(context.Background(), pub); n != 0 {
+		t.Errorf("attempted = %d, dead messages must not be retried", n)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{1, 2 * time.Second},
+		{2, 4 * time.Second},
+		{5, 32 * time.Second},
+		{9, maxBackoff},
+		{80, maxBackoff},
+	}
+	for _, tt := range tests {
+		if got := backoff(tt.attempt); got != tt.want {
+			t.Errorf("backoff(%d) = %v, want %v", tt.attempt, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package privacy
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"time"
+
+	"boilerplate-golang/internal/infrastructure/dbmanager"
+)
+
+// File is a file added to a data export archive.
+type File struct {
+	Name string
+	Data []byte
+}
+
+// ExportFunc returns the files a section contributes to the export archive of
+// a user, e.g. the user's orders as orders.json. It returns no files when the
+// section holds no data for the user.
+type ExportFunc func(ctx context.Context, userID string) ([]File, error)
+
+// EraseFunc removes or anonymizes the personal data a section holds for a
+// user. It runs in the erasure transaction; data that must be kept, such as
+// financial records, is anonymized rather than deleted.
+type EraseFunc func(ctx context.Context, userID string) error
+
+type exporter struct {
+	name string
+	fn   ExportFunc
+}
+
+type eraser struct {
+	name string
+	fn   EraseFunc
+}
+
+var (
+	exporters []exporter
+	erasers   []eraser
+)
+
+// OnExport adds a section to data export archives; its files are stored under
+// the section name. Packages owning personal data register in init.
+func OnExport(name string, fn ExportFunc) {
+	exporters = append(exporters, exporter{name: name, fn: fn})
+}
+
+// OnErase adds a step to account erasure. Steps run in registration order.
+func OnErase(name string, fn EraseFunc) {
+	erasers = append(erasers, eraser{name: name, fn: fn})
+}
+
+// JSONFile encodes v as an indented JSON file for an export section.
+func JSONFile(name string, v interface{}) (File, error) {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return File{}, fmt.Errorf("encoding %s: %w", name, err)
+	}
+	return File{Name: name, Data: data}, nil
+}
+
+// BuildArchive collects the data of every export section for the user into a
+// zip archive, with a manifest.json listing what it contains.
+func BuildArchive(ctx context.Context, userID string) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+
+	manifest := struct {
+		UserID      string              `json:"user_id"`
+		GeneratedAt time.Time           `json:"generated_at"`
+		Sections    map[string][]string `json:"sections"`
+	}{UserID: userID, GeneratedAt: time.Now().UTC(), Sections: map[string][]string{}}
+
+	for _, e := range exporters {
+		files, err := e.fn(ctx, userID)
+		if err != nil {
+			return nil, fmt.Errorf("exporting %s: %w", e.name, err)
+		}
+		for _, f := range files {
+			name := path.Join(e.name, path.Clean("/" + f.Name)[1:])
+			w, err := zw.Create(name)
+			if err != nil {
+				return nil, err
+			}
+			if _, err := w.Write(f.Data); err != nil {
+				return nil, err
+			}
+			manifest.Sections[e.name] = append(manifest.Sections[e.name], name)
+		}
+	}
+
+	m, err := JSONFile("manifest.json", manifest)
+	if err != nil {
+		return nil, err
+	}
+	w, err := zw.Create(m.Name)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(m.Data); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Erase runs every erase step for the user in one transaction, so the account
+// is either fully erased or left untouched.
+func Erase(ctx context.Context, userID string) error {
+	return dbmanager.WithTx(ctx, func(ctx context.Context) error {
+		for _, e := range erasers {
+			if err := e.fn(ctx, userID); err != nil {
+				return fmt.Errorf("erasing %s: %w", e.name, err)
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,44 @@
+package purge
+
+import (
+	"context"
+
+	"boilerplate-golang/internal/application/entity"
+	"boilerplate-golang/internal/infrastructure/dbmanager"
+)
+
+func init() {
+	Register(Policy{
+		Table:   "users",
+		Model:   &entity.User{},
+		Cascade: []CascadeFunc{purgeAggregateEvents("user"), purgeHistory("user"), purgeUserAuth},
+	})
+}
+
+// purgeAggregateEvents deletes outbox messages of the purged aggregates, whose
+// payloads may still carry their personal data.
+func purgeAggregateEvents(aggregateType string) CascadeFunc {
+	return func(ctx context.Context, ids []string) error {
+		return dbmanager.DB(ctx).
+			Where("aggregate_type = ? AND aggregate_id IN ?", aggregateType, ids).
+			Delete(&entity.OutboxMessage{}).Error
+	}
+}
+
+// purgeHistory deletes the change history of the purged rows.
+func purgeHistory(name string) CascadeFunc {
+	return func(ctx context.Context, ids []string) error {
+		return dbmanager.DB(ctx).
+			Where("entity = ? AND entity_id IN ?", name, ids).
+			Delete(&entity.EntityHistory{}).Error
+	}
+}
+
+// purgeUserAuth deletes the login sessions and email tokens of purged users.
+func purgeUserAuth(ctx context.Context, ids []string) error {
+	db := dbmanager.DB(ctx)
+	if err := db.Where("user_id IN ?", ids).Delete(&entity.UserSession{}).Error; err != nil {
+		return err
+	}
+	return db.Where("user_id IN ?", ids).Delete(&entity.UserToken{}).Error
+}
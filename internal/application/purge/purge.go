@@ -0,0 +1,114 @@
+package purge
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"boilerplate-golang/internal/infrastructure/config"
+	"boilerplate-golang/internal/infrastructure/dbmanager"
+	"boilerplate-golang/internal/infrastructure/logger"
+	"boilerplate-golang/internal/infrastructure/storagemanager"
+)
+
+// batchSize bounds how many rows are purged per transaction.
+const batchSize = 500
+
+// CascadeFunc removes data that depends on rows about to be purged. It runs in
+// the purge transaction, so database changes commit or roll back with the purge.
+type CascadeFunc func(ctx context.Context, ids []string) error
+
+// Policy describes a soft-deletable entity that can be hard-deleted once its
+// rows have been deleted for longer than the retention configured under
+// [retention] for Table.
+type Policy struct {
+	Table string
+	// Model is a pointer to the entity, e.g. &entity.User{}
+	Model   interface{}
+	Cascade []CascadeFunc
+}
+
+var policies []*Policy
+
+// Register adds a purge policy.
+func Register(p Policy) {
+	policies = append(policies, &p)
+}
+
+// OnPurge adds a cascade to the policy of table, for dependent data owned by
+// other packages (e.g. uploaded files).
+func OnPurge(table string, fn CascadeFunc) {
+	for _, p := range policies {
+		if p.Table == table {
+			p.Cascade = append(p.Cascade, fn)
+			return
+		}
+	}
+	panic(fmt.Sprintf("purge: no policy registered for %s", table))
+}
+
+// DeleteFilesAfterCommit removes stored files once the purge transaction has
+// committed, so files never disappear for rows that survive a rollback.
+// Failures are logged; the files are orphaned rather than blocking the purge.
+func DeleteFilesAfterCommit(ctx context.Context, keys []string) {
+	dbmanager.AfterCommit(ctx, func() {
+		for _, key := range keys {
+			if err := storagemanager.Delete(context.Background(), key); err != nil {
+				logger.Error("purge: failed to delete file %s: %v", key, err)
+			}
+		}
+	})
+}
+
+// Run hard-deletes soft-deleted rows past retention for every policy. Tables
+// without a retention (or with zero) are kept forever.
+func Run(ctx context.Context) error {
+	retention := config.Get().Retention
+	for _, p := range policies {
+		keep := retention[p.Table]
+		if keep <= 0 {
+			continue
+		}
+		n, err := purgeTable(ctx, p, time.Now().UTC().Add(-keep))
+		if err != nil {
+			return fmt.Errorf("purging %s: %w", p.Table, err)
+		}
+		if n > 0 {
+			logger.Info("purge: removed %d %s row(s) past the %s retention", n, p.Table, keep)
+		}
+	}
+	return nil
+}
+
+// purgeTable deletes rows of p soft-deleted before cutoff in batches.
+func purgeTable(ctx context.Context, p *Policy, cutoff time.Time) (int, error) {
+	total := 0
+	for {
+		var ids []string
+		err := dbmanager.DB(ctx).Model(p.Model).Unscoped().
+			Where("deleted_at IS NOT NULL AND deleted_at < ?", cutoff).
+			Order("deleted_at").Limit(batchSize).Pluck("id", &ids).Error
+		if err != nil {
+			return total, err
+		}
+		if len(ids) == 0 {
+			return total, nil
+		}
+
+		err = dbmanager.WithTx(ctx, func(ctx context.Context) error {
+			for _, cascade := range p.Cascade {
+				if err := cascade(ctx, ids); err != nil {
+					return err
+				}
+			}
+			return dbmanager.DB(ctx).Unscoped().Where("id IN ?", ids).Delete(p.Model).Error
+		})
+		if err != nil {
+			return total, err
+		}
+		total += len(ids)
+		if len(ids) < batchSize {
+			return total, nil
+		}
+	}
+}
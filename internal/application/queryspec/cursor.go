@@ -0,0 +1,293 @@
+package queryspec
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+
+	"boilerplate-golang/internal/application/apperror"
+	"boilerplate-golang/internal/application/dto"
+	"boilerplate-golang/internal/infrastructure/config"
+	"boilerplate-golang/internal/infrastructure/logger"
+)
+
+var (
+	cursorKey     []byte
+	cursorKeyOnce sync.Once
+)
+
+// Cursor is the decoded position of a row in a keyset-paginated listing: the
+// values of the sort keys plus the primary key, bound to the table and sort order.
+type Cursor struct {
+	Table  string            `json:"t"`
+	Sort   string            `json:"s"`
+	Values []json.RawMessage `json:"v"`
+	ID     json.RawMessage   `json:"id"`
+}
+
+// EncodeCursor serializes and signs a cursor into an opaque token.
+func EncodeCursor(c Cursor) (string, error) {
+	payload, err := json.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(payload) + "." + base64.RawURLEncoding.EncodeToString(sign(payload)), nil
+}
+
+// DecodeCursor verifies and decodes a token produced by EncodeCursor.
+func DecodeCursor(token string) (*Cursor, error) {
+	parts := strings.SplitN(token, ".", 2)
+	if len(parts) != 2 {
+		return nil, errors.New("malformed cursor")
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, errors.New("malformed cursor")
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil || !hmac.Equal(sig, sign(payload)) {
+		return nil, errors.New("invalid cursor signature")
+	}
+
+	var c Cursor
+	if err := json.Unmarshal(payload, &c); err != nil {
+		return nil, errors.New("malformed cursor")
+	}
+	return &c, nil
+}
+
+// sign computes the HMAC of a cursor payload with the configured secret.
+func sign(payload []byte) []byte {
+	cursorKeyOnce.Do(func() {
+		cursorKey = []byte(config.Get().Pagination.CursorSecret)
+		if len(cursorKey) == 0 {
+			// Cursors then only survive until restart and aren't valid across replicas
+			logger.Warn("pagination.cursor_secret is not set, using a random key")
+			cursorKey = make([]byte, 32)
+			_, _ = rand.Read(cursorKey)
+		}
+	})
+
+	mac := hmac.New(sha256.New, cursorKey)
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
+
+// sortKey returns the canonical form of the spec's sort, e.g. "-created_at,username".
+func (s Spec) sortKey() string {
+	keys := make([]string, len(s.Sorts))
+	for i, sort := range s.Sorts {
+		keys[i] = sort.Field
+		if sort.Desc {
+			keys[i] = "-" + sort.Field
+		}
+	}
+	return strings.Join(keys, ",")
+}
+
+// ListCursor runs the spec against model T using keyset pagination and returns
+// the page with next/prev cursor tokens instead of a total count. It reads
+// page[after] or page[before] from the spec; without either it returns the first page.
+// NULLs of nullable (pointer) sort fields come after every value, whatever the
+// database's default.
+func ListCursor[T any, R any](db *gorm.DB, spec Spec, wl Whitelist, toDto func(T) R) dto.ResponseDto {
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(new(T)); err != nil {
+		logger.Error("Error parsing schema for cursor pagination: %v", err)
+		return *dto.Fail("Error fetching records")
+	}
+	sch := stmt.Schema
+	pk := sch.PrioritizedPrimaryField
+
+	fields := make([]*schema.Field, len(spec.Sorts))
+	for i, sort := range spec.Sorts {
+		fields[i] = sch.LookUpField(wl.Sorts[sort.Field])
+		if fields[i] == nil {
+			logger.Error("Sort column %s not found on %s", wl.Sorts[sort.Field], sch.Table)
+			return *dto.Fail("Error fetching records")
+		}
+	}
+
+	cur, backward := spec.After, false
+	if spec.Before != nil {
+		cur, backward = spec.Before, true
+	}
+
+	query := spec.Where(db.Model(new(T)), wl)
+	if cur != nil {
+		cond, err := keysetCondition(cur, sch.Table, spec, fields, pk, backward)
+		if err != nil {
+			return *dto.FailWith(apperror.Wrap(err, apperror.KindBadRequest, "invalid_cursor", err.Error()))
+		}
+		query = query.Where(cond)
+	}
+
+	// Walking backwards reverses the order; the page is flipped back below
+	for i, sort := range spec.Sorts {
+		if nullable(fields[i]) {
+			isNull := clause.Column{Name: query.Statement.Quote(fields[i].DBName) + " IS NULL", Raw: true}
+			query = query.Order(clause.OrderByColumn{Column: isNull, Desc: sort.Desc != backward})
+		}
+		query = query.Order(clause.OrderByColumn{Column: clause.Column{Name: fields[i].DBName}, Desc: sort.Desc != backward})
+	}
+	query = query.Order(clause.OrderByColumn{Column: clause.Column{Name: pk.DBName}, Desc: backward})
+
+	var rows []T
+	if err := query.Limit(spec.PageSize + 1).Find(&rows).Error; err != nil {
+		logger.Error("Error fetching %T rows: %v", rows, err)
+		return *dto.Fail("Error fetching records")
+	}
+
+	hasMore := len(rows) > spec.PageSize
+	if hasMore {
+		rows = rows[:spec.PageSize]
+	}
+	if backward {
+		for i, j := 0, len(rows)-1; i < j; i, j = i+1, j-1 {
+			rows[i], rows[j] = rows[j], rows[i]
+		}
+	}
+
+	var next, prev string
+	if len(rows) > 0 {
+		var err error
+		if backward || hasMore {
+			if next, err = cursorFor(rows[len(rows)-1], sch.Table, spec, fields, pk); err != nil {
+				logger.Error("Error encoding cursor: %v", err)
+				return *dto.Fail("Error fetching records")
+			}
+		}
+		if (backward && hasMore) || (!backward && cur != nil) {
+			if prev, err = cursorFor(rows[0], sch.Table, spec, fields, pk); err != nil {
+				logger.Error("Error encoding cursor: %v", err)
+				return *dto.Fail("Error fetching records")
+			}
+		}
+	}
+
+	result := make([]R, len(rows))
+	for i, row := range rows {
+		result[i] = toDto(row)
+	}
+	return *dto.SuccessCursor(result, next, prev)
+}
+
+// cursorFor builds the token pointing at row.
+func cursorFor(row interface{}, table string, spec Spec, fields []*schema.Field, pk *schema.Field) (string, error) {
+	rv := reflect.ValueOf(row)
+	c := Cursor{Table: table, Sort: spec.sortKey(), Values: make([]json.RawMessage, len(fields))}
+
+	for i, f := range fields {
+		v, _ := f.ValueOf(context.Background(), rv)
+		raw, err := json.Marshal(v)
+		if err != nil {
+			return "", err
+		}
+		c.Values[i] = raw
+	}
+	id, _ := pk.ValueOf(context.Background(), rv)
+	raw, err := json.Marshal(id)
+	if err != nil {
+		return "", err
+	}
+	c.ID = raw
+
+	return EncodeCursor(c)
+}
+
+// nullable reports whether the field can hold NULL, which sorts after every
+// value in cursor listings.
+func nullable(f *schema.Field) bool {
+	return f.FieldType.Kind() == reflect.Ptr
+}
+
+// keysetCondition builds "rows after (or before) the cursor" for the sort keys,
+// e.g. (a > ?) OR (a = ? AND b < ?) OR (a = ? AND b = ? AND id > ?).
+func keysetCondition(cur *Cursor, table string, spec Spec, fields []*schema.Field, pk *schema.Field, backward bool) (clause.Expression, error) {
+	if cur.Table != table || cur.Sort != spec.sortKey() || len(cur.Values) != len(fields) {
+		return nil, errors.New("cursor does not match this listing; restart from the first page")
+	}
+
+	cols := make([]clause.Column, 0, len(fields)+1)
+	descs := make([]bool, 0, len(fields)+1)
+	nulls := make([]bool, 0, len(fields)+1)
+	vals := make([]interface{}, 0, len(fields)+1)
+	for i, f := range fields {
+		v, err := decodeValue(f, cur.Values[i])
+		if err != nil {
+			return nil, err
+		}
+		cols = append(cols, clause.Column{Name: f.DBName})
+		descs = append(descs, spec.Sorts[i].Desc)
+		nulls = append(nulls, nullable(f))
+		vals = append(vals, v)
+	}
+	id, err := decodeValue(pk, cur.ID)
+	if err != nil || id == nil {
+		return nil, errors.New("malformed cursor")
+	}
+	cols = append(cols, clause.Column{Name: pk.DBName})
+	descs = append(descs, false)
+	nulls = append(nulls, false)
+	vals = append(vals, id)
+
+	ors := make([]clause.Expression, 0, len(cols))
+	for i := range cols {
+		// A nil value is NULL to clause.Eq
+		ands := make([]clause.Expression, 0, i+1)
+		for j := 0; j < i; j++ {
+			ands = append(ands, clause.Eq{Column: cols[j], Value: vals[j]})
+		}
+		beyond, ok := beyondValue(cols[i], vals[i], nulls[i], descs[i] == backward)
+		if !ok {
+			continue
+		}
+		ors = append(ors, clause.And(append(ands, beyond)...))
+	}
+	return clause.Or(ors...), nil
+}
+
+// beyondValue builds "col comes after v" in the walking direction, greater or
+// smaller, with NULL after every value. It returns false when nothing can:
+// walking up from NULL.
+func beyondValue(col clause.Column, v interface{}, nullable, greater bool) (clause.Expression, bool) {
+	switch {
+	case v == nil && greater:
+		return nil, false
+	case v == nil:
+		return clause.Neq{Column: col, Value: nil}, true
+	case greater && nullable:
+		return clause.Or(clause.Gt{Column: col, Value: v}, clause.Eq{Column: col, Value: nil}), true
+	case greater:
+		return clause.Gt{Column: col, Value: v}, true
+	default:
+		return clause.Lt{Column: col, Value: v}, true
+	}
+}
+
+// decodeValue converts a JSON cursor value back into the Go type of the field.
+func decodeValue(f *schema.Field, raw json.RawMessage) (interface{}, error) {
+	ptr := reflect.New(f.FieldType)
+	if err := json.Unmarshal(raw, ptr.Interface()); err != nil {
+		return nil, errors.New("malformed cursor")
+	}
+	v := ptr.Elem()
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil, nil
+		}
+		v = v.Elem()
+	}
+	return v.Interface(), nil
+}
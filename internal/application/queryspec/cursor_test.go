@@ -0,0 +1,210 @@
+package queryspec
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"boilerplate-golang/internal/application/dto"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	c := Cursor{Table: "users", Sort: "-created_at", Values: []json.RawMessage{json.RawMessage(`"2024-01-01T00:00:00Z"`)}, ID: json.RawMessage(`"u1"`)}
+	token, err := EncodeCursor(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := DecodeCursor(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(*got, c) {
+		t.Errorf("DecodeCursor = %+v, want %+v", *got, c)
+	}
+}
+
+func TestDecodeCursorRejectsTampering(t *testing.T) {
+	token, err := EncodeCursor(Cursor{Table: "users", Sort: "username", Values: []json.RawMessage{json.RawMessage(`"bob"`)}, ID: json.RawMessage(`"u1"`)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload, sig, _ := strings.Cut(token, ".")
+
+	forged, _ := json.Marshal(Cursor{Table: "users", Sort: "username", Values: []json.RawMessage{json.RawMessage(`"alice"`)}, ID: json.RawMessage(`"u1"`)})
+	flipped := []byte(sig)
+	flipped[0] ^= 1
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr string
+	}{
+		{"no signature", payload, "malformed cursor"},
+		{"empty", "", "malformed cursor"},
+		{"payload not base64", "!!!." + sig, "malformed cursor"},
+		{"forged payload", base64.RawURLEncoding.EncodeToString(forged) + "." + sig, "invalid cursor signature"},
+		{"altered signature", payload + "." + string(flipped), "invalid cursor signature"},
+		{"truncated signature", payload + "." + sig[:10], "invalid cursor signature"},
+		{"signed garbage", base64.RawURLEncoding.EncodeToString([]byte("not json")) + "." +
+			base64.RawURLEncoding.EncodeToString(sign([]byte("not json"))), "malformed cursor"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := DecodeCursor(tt.token)
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("DecodeCursor error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+type cursorItem struct {
+	ID    int64 `gorm:"primaryKey"`
+	Name  string
+	Score int
+	Seen  *int
+}
+
+var cursorWhitelist = Whitelist{
+	Sorts:       map[string]string{"score": "score", "name": "name", "seen": "seen"},
+	DefaultSort: "-score",
+}
+
+func openItems(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "items.db")), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&cursorItem{}); err != nil {
+		t.Fatal(err)
+	}
+	// Scores repeat so the primary key has to break ties; some items were never seen
+	seen := map[int]int{1: 3, 3: 1, 5: 3, 6: 2}
+	for i := 1; i <= 7; i++ {
+		item := cursorItem{ID: int64(i), Name: fmt.Sprintf("item%d", i), Score: i / 2}
+		if s, ok := seen[i]; ok {
+			item.Seen = &s
+		}
+		if err := db.Create(&item).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	return db
+}
+
+func listPage(t *testing.T, db *gorm.DB, query string) (ids []int64, next, prev string) {
+	t.Helper()
+	values := make(map[string][]string)
+	for _, kv := range strings.Split(query, "&") {
+		k, v, _ := strings.Cut(kv, "=")
+		values[k] = append(values[k], v)
+	}
+	spec, err := Parse(values, cursorWhitelist)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res := ListCursor(db, spec, cursorWhitelist, func(i cursorItem) int64 { return i.ID })
+	if res.Code != 0 {
+		t.Fatalf("ListCursor(%s) = %d %s", query, res.Code, res.Msg)
+	}
+	return res.Data.([]int64), res.Next, res.Prev
+}
+
+func TestListCursorWalksBothWays(t *testing.T) {
+	db := openItems(t)
+
+	// -score then id: 6,7 (3), 4,5 (2), 2,3 (1), 1 (0)
+	var pages [][]int64
+	var prevs []string
+	query := "page[size]=3&page[after]="
+	for {
+		ids, next, prev := listPage(t, db, query)
+		pages = append(pages, ids)
+		prevs = append(prevs, prev)
+		if next == "" {
+			break
+		}
+		query = "page[size]=3&page[after]=" + next
+	}
+	want := [][]int64{{6, 7, 4}, {5, 2, 3}, {1}}
+	if !reflect.DeepEqual(pages, want) {
+		t.Fatalf("pages = %v, want %v", pages, want)
+	}
+	if prevs[0] != "" {
+		t.Error("first page has a previous cursor")
+	}
+
+	// Going back from the last page returns the middle one
+	ids, _, _ := listPage(t, db, "page[size]=3&page[before]="+prevs[2])
+	if !reflect.DeepEqual(ids, want[1]) {
+		t.Errorf("page before the last = %v, want %v", ids, want[1])
+	}
+}
+
+func TestListCursorWalksPastNulls(t *testing.T) {
+	db := openItems(t)
+
+	tests := []struct {
+		sort string
+		want []int64
+	}{
+		// NULLs come after every value: 3 (1), 6 (2), 1,5 (3), then 2,4,7
+		{"seen", []int64{3, 6, 1, 5, 2, 4, 7}},
+		{"-seen", []int64{2, 4, 7, 1, 5, 6, 3}},
+		{"-seen,name", []int64{2, 4, 7, 1, 5, 6, 3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.sort, func(t *testing.T) {
+			var got, last []int64
+			var prev string
+			after := ""
+			for {
+				ids, next, p := listPage(t, db, "sort="+tt.sort+"&page[size]=2&page[after]="+after)
+				got = append(got, ids...)
+				last, prev = ids, p
+				if next == "" {
+					break
+				}
+				after = next
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("walked %v, want %v", got, tt.want)
+			}
+
+			// Walking back from the last page crosses the NULLs the other way
+			var back []int64
+			before := prev
+			for before != "" {
+				ids, _, prev := listPage(t, db, "sort="+tt.sort+"&page[size]=2&page[before]="+before)
+				back = append(ids, back...)
+				before = prev
+			}
+			if want := tt.want[:len(tt.want)-len(last)]; !reflect.DeepEqual(back, want) {
+				t.Errorf("walked back %v, want %v", back, want)
+			}
+		})
+	}
+}
+
+func TestListCursorRejectsCursorOfAnotherSort(t *testing.T) {
+	db := openItems(t)
+	_, next, _ := listPage(t, db, "page[size]=2&page[after]=")
+
+	spec, err := Parse(map[string][]string{"sort": {"name"}, "page[after]": {next}}, cursorWhitelist)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res := ListCursor(db, spec, cursorWhitelist, func(i cursorItem) int64 { return i.ID })
+	if res.Code != dto.CodeBadRequest {
+		t.Errorf("cursor of -score used with sort=name: code %d, want %d", res.Code, dto.CodeBadRequest)
+	}
+}
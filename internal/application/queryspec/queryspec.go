@@ -0,0 +1,320 @@
+package queryspec
+
+import (
+	"fmt"
+	"math"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"boilerplate-golang/internal/application/dto"
+	"boilerplate-golang/internal/infrastructure/dbmanager"
+	"boilerplate-golang/internal/infrastructure/logger"
+)
+
+// Filter operators accepted in filter[field][op]=value.
+const (
+	OpEq   = "eq"
+	OpNe   = "ne"
+	OpLike = "like"
+	OpGt   = "gt"
+	OpGte  = "gte"
+	OpLt   = "lt"
+	OpLte  = "lte"
+	OpIn   = "in"
+)
+
+// Value types used to convert filter values before they are bound.
+const (
+	TypeString = iota
+	TypeBool
+	TypeInt
+	TypeTime
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+// filterKeyRe matches filter[field] and filter[field][op].
+var filterKeyRe = regexp.MustCompile(`^filter\[(\w+)\](?:\[(\w+)\])?$`)
+
+// Field maps a public field name to its column and the operators allowed on it.
+type Field struct {
+	Column string
+	Type   int
+	Ops    []string
+	// Index, when set, maps string values before they are bound, e.g. to the
+	// blind index of an encrypted column
+	Index func(string) (string, error)
+}
+
+// Whitelist declares what callers may filter, sort and search on for one entity.
+// Anything not listed is rejected, so column names never come from the request.
+type Whitelist struct {
+	Filters map[string]Field
+	// Sorts maps public sort names to columns
+	Sorts map[string]string
+	// Search lists the columns matched by the free-text q parameter
+	Search []string
+	// DefaultSort is used when no sort is given, e.g. "-created_at"
+	DefaultSort string
+	// MaxPageSize caps page[size]; zero means maxPageSize
+	MaxPageSize int
+}
+
+// PageSizeLimit returns the largest page[size] of the listing.
+func (wl Whitelist) PageSizeLimit() int {
+	if wl.MaxPageSize > 0 {
+		return wl.MaxPageSize
+	}
+	return maxPageSize
+}
+
+// Filter is a single parsed filter condition.
+type Filter struct {
+	Field  string
+	Op     string
+	Values []interface{}
+}
+
+// Sort is a single parsed sort key.
+type Sort struct {
+	Field string
+	Desc  bool
+}
+
+// Spec is a validated list query: filters, sorting, search and pagination.
+type Spec struct {
+	Filters  []Filter
+	Sorts    []Sort
+	Search   string
+	Page     int
+	PageSize int
+	// Cursor is set when the caller asked for keyset pagination with
+	// page[after] or page[before]; an empty value requests the first page
+	Cursor bool
+	After  *Cursor
+	Before *Cursor
+}
+
+// Parse reads a list query such as
+//
+//	?filter[email][like]=acme&filter[is_active]=true&sort=-created_at&page[number]=2&page[size]=20&q=jane
+//
+// and validates every field and operator against wl. Keyset pagination uses
+// page[after]=<token> or page[before]=<token> instead of page[number].
+func Parse(values url.Values, wl Whitelist) (Spec, error) {
+	spec := Spec{Page: 1, PageSize: defaultPageSize}
+
+	for key, vals := range values {
+		m := filterKeyRe.FindStringSubmatch(key)
+		if m == nil {
+			continue
+		}
+		field, ok := wl.Filters[m[1]]
+		if !ok {
+			return Spec{}, fmt.Errorf("filtering by %q is not allowed", m[1])
+		}
+		op := m[2]
+		if op == "" {
+			op = OpEq
+		}
+		if !allowed(field.Ops, op) {
+			return Spec{}, fmt.Errorf("operator %q is not allowed on %q", op, m[1])
+		}
+
+		raw := vals[len(vals)-1]
+		parts := []string{raw}
+		if op == OpIn {
+			parts = strings.Split(raw, ",")
+		}
+		converted := make([]interface{}, 0, len(parts))
+		for _, p := range parts {
+			p = strings.TrimSpace(p)
+			if field.Index != nil {
+				indexed, err := field.Index(p)
+				if err != nil {
+					return Spec{}, fmt.Errorf("invalid value for %q: %v", m[1], err)
+				}
+				p = indexed
+			}
+			v, err := convert(p, field.Type)
+			if err != nil {
+				return Spec{}, fmt.Errorf("invalid value for %q: %v", m[1], err)
+			}
+			converted = append(converted, v)
+		}
+		spec.Filters = append(spec.Filters, Filter{Field: m[1], Op: op, Values: converted})
+	}
+
+	sortParam := values.Get("sort")
+	if sortParam == "" {
+		sortParam = wl.DefaultSort
+	}
+	for _, key := range strings.Split(sortParam, ",") {
+		key = strings.TrimSpace(key)
+		if key == "" {
+			continue
+		}
+		s := Sort{Field: strings.TrimPrefix(key, "-"), Desc: strings.HasPrefix(key, "-")}
+		if _, ok := wl.Sorts[s.Field]; !ok {
+			return Spec{}, fmt.Errorf("sorting by %q is not allowed", s.Field)
+		}
+		spec.Sorts = append(spec.Sorts, s)
+	}
+
+	spec.Search = strings.TrimSpace(values.Get("q"))
+
+	if v := values.Get("page[number]"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return Spec{}, fmt.Errorf("page[number] must be a positive integer")
+		}
+		spec.Page = n
+	}
+	if v := values.Get("page[size]"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return Spec{}, fmt.Errorf("page[size] must be a positive integer")
+		}
+		spec.PageSize = n
+	}
+	for _, key := range []string{"page[after]", "page[before]"} {
+		if !values.Has(key) {
+			continue
+		}
+		spec.Cursor = true
+		token := values.Get(key)
+		if token == "" {
+			continue
+		}
+		c, err := DecodeCursor(token)
+		if err != nil {
+			return Spec{}, fmt.Errorf("%s: %v", key, err)
+		}
+		if key == "page[after]" {
+			spec.After = c
+		} else {
+			spec.Before = c
+		}
+	}
+	if spec.After != nil && spec.Before != nil {
+		return Spec{}, fmt.Errorf("page[after] and page[before] cannot be combined")
+	}
+
+	if limit := wl.PageSizeLimit(); spec.PageSize > limit {
+		spec.PageSize = limit
+	}
+	// Keep page*size, and so the OFFSET, within 32 bits
+	if maxPage := math.MaxInt32 / spec.PageSize; spec.Page > maxPage {
+		return Spec{}, fmt.Errorf("page[number] must be at most %d", maxPage)
+	}
+
+	return spec, nil
+}
+
+// Where applies the filters and search of the spec to db.
+func (s Spec) Where(db *gorm.DB, wl Whitelist) *gorm.DB {
+	for _, f := range s.Filters {
+		col := clause.Column{Name: wl.Filters[f.Field].Column}
+		switch f.Op {
+		case OpEq:
+			db = db.Where(clause.Eq{Column: col, Value: f.Values[0]})
+		case OpNe:
+			db = db.Where(clause.Neq{Column: col, Value: f.Values[0]})
+		case OpGt:
+			db = db.Where(clause.Gt{Column: col, Value: f.Values[0]})
+		case OpGte:
+			db = db.Where(clause.Gte{Column: col, Value: f.Values[0]})
+		case OpLt:
+			db = db.Where(clause.Lt{Column: col, Value: f.Values[0]})
+		case OpLte:
+			db = db.Where(clause.Lte{Column: col, Value: f.Values[0]})
+		case OpIn:
+			db = db.Where(clause.IN{Column: col, Values: f.Values})
+		case OpLike:
+			db = db.Where(dbmanager.CaseInsensitiveLike(col.Name), "%"+dbmanager.EscapeLike(fmt.Sprint(f.Values[0]))+"%")
+		}
+	}
+
+	if s.Search != "" && len(wl.Search) > 0 {
+		term := "%" + dbmanager.EscapeLike(s.Search) + "%"
+		conds := make([]string, len(wl.Search))
+		args := make([]interface{}, len(wl.Search))
+		for i, col := range wl.Search {
+			conds[i] = dbmanager.CaseInsensitiveLike(col)
+			args[i] = term
+		}
+		db = db.Where(strings.Join(conds, " OR "), args...)
+	}
+	return db
+}
+
+// Order applies the sort keys of the spec to db, with the primary key as a
+// final tiebreaker so pages are stable.
+func (s Spec) Order(db *gorm.DB, wl Whitelist) *gorm.DB {
+	for _, sort := range s.Sorts {
+		db = db.Order(clause.OrderByColumn{Column: clause.Column{Name: wl.Sorts[sort.Field]}, Desc: sort.Desc})
+	}
+	return db.Order(clause.OrderByColumn{Column: clause.Column{Name: "id"}})
+}
+
+// List runs the spec against model T and returns the requested page converted
+// with toDto, together with the total number of matching rows.
+func List[T any, R any](db *gorm.DB, spec Spec, wl Whitelist, toDto func(T) R) dto.ResponseDto {
+	var (
+		rows  []T
+		total int64
+	)
+
+	query := spec.Where(db.Model(new(T)), wl).Session(&gorm.Session{})
+	if err := query.Count(&total).Error; err != nil {
+		logger.Error("Error counting %T rows: %v", rows, err)
+		return *dto.Fail("Error fetching records")
+	}
+
+	offset := (spec.Page - 1) * spec.PageSize
+	if err := spec.Order(query, wl).Limit(spec.PageSize).Offset(offset).Find(&rows).Error; err != nil {
+		logger.Error("Error fetching %T rows: %v", rows, err)
+		return *dto.Fail("Error fetching records")
+	}
+
+	result := make([]R, len(rows))
+	for i, row := range rows {
+		result[i] = toDto(row)
+	}
+	return *dto.SuccessCount(result, total)
+}
+
+func allowed(ops []string, op string) bool {
+	for _, o := range ops {
+		if o == op {
+			return true
+		}
+	}
+	return false
+}
+
+// convert parses a raw query value into the Go type of the column.
+func convert(raw string, typ int) (interface{}, error) {
+	switch typ {
+	case TypeBool:
+		return strconv.ParseBool(raw)
+	case TypeInt:
+		return strconv.ParseInt(raw, 10, 64)
+	case TypeTime:
+		if t, err := time.Parse(time.RFC3339, raw); err == nil {
+			return t, nil
+		}
+		return time.Parse("2006-01-02", raw)
+	default:
+		return raw, nil
+	}
+}
@@ -0,0 +1,166 @@
+package queryspec
+
+import (
+	"errors"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testWhitelist = Whitelist{
+	Filters: map[string]Field{
+		"email":      {Column: "email", Ops: []string{OpEq, OpLike}},
+		"is_active":  {Column: "is_active", Type: TypeBool, Ops: []string{OpEq}},
+		"age":        {Column: "age", Type: TypeInt, Ops: []string{OpGt, OpLte, OpIn}},
+		"created_at": {Column: "created_at", Type: TypeTime, Ops: []string{OpGte, OpLt}},
+		"code": {Column: "code_idx", Ops: []string{OpEq}, Index: func(s string) (string, error) {
+			if s == "" {
+				return "", errors.New("empty")
+			}
+			return "idx:" + strings.ToLower(s), nil
+		}},
+	},
+	Sorts:       map[string]string{"created_at": "created_at", "username": "username"},
+	Search:      []string{"username", "email"},
+	DefaultSort: "-created_at",
+	MaxPageSize: 50,
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  Spec
+	}{
+		{
+			name:  "defaults",
+			query: "",
+			want:  Spec{Sorts: []Sort{{Field: "created_at", Desc: true}}, Page: 1, PageSize: defaultPageSize},
+		},
+		{
+			name:  "filter without operator is eq",
+			query: "filter[is_active]=true",
+			want: Spec{
+				Filters:  []Filter{{Field: "is_active", Op: OpEq, Values: []interface{}{true}}},
+				Sorts:    []Sort{{Field: "created_at", Desc: true}},
+				Page:     1,
+				PageSize: defaultPageSize,
+			},
+		},
+		{
+			name:  "in splits and converts values",
+			query: "filter[age][in]=18, 21,30",
+			want: Spec{
+				Filters:  []Filter{{Field: "age", Op: OpIn, Values: []interface{}{int64(18), int64(21), int64(30)}}},
+				Sorts:    []Sort{{Field: "created_at", Desc: true}},
+				Page:     1,
+				PageSize: defaultPageSize,
+			},
+		},
+		{
+			name:  "date filter",
+			query: "filter[created_at][gte]=2024-03-01",
+			want: Spec{
+				Filters:  []Filter{{Field: "created_at", Op: OpGte, Values: []interface{}{time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)}}},
+				Sorts:    []Sort{{Field: "created_at", Desc: true}},
+				Page:     1,
+				PageSize: defaultPageSize,
+			},
+		},
+		{
+			name:  "index maps the value",
+			query: "filter[code]=ABC",
+			want: Spec{
+				Filters:  []Filter{{Field: "code", Op: OpEq, Values: []interface{}{"idx:abc"}}},
+				Sorts:    []Sort{{Field: "created_at", Desc: true}},
+				Page:     1,
+				PageSize: defaultPageSize,
+			},
+		},
+		{
+			name:  "sort, search and page",
+			query: "sort=username,-created_at&q=%20jane%20&page[number]=3&page[size]=10",
+			want: Spec{
+				Sorts:    []Sort{{Field: "username"}, {Field: "created_at", Desc: true}},
+				Search:   "jane",
+				Page:     3,
+				PageSize: 10,
+			},
+		},
+		{
+			name:  "page size is capped",
+			query: "page[size]=500",
+			want:  Spec{Sorts: []Sort{{Field: "created_at", Desc: true}}, Page: 1, PageSize: 50},
+		},
+		{
+			name:  "empty page[after] asks for the first cursor page",
+			query: "page[after]=",
+			want:  Spec{Sorts: []Sort{{Field: "created_at", Desc: true}}, Page: 1, PageSize: defaultPageSize, Cursor: true},
+		},
+		{
+			name:  "other parameters are ignored",
+			query: "include=roles&filters[email]=x",
+			want:  Spec{Sorts: []Sort{{Field: "created_at", Desc: true}}, Page: 1, PageSize: defaultPageSize},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, err := url.ParseQuery(tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := Parse(values, testWhitelist)
+			if err != nil {
+				t.Fatalf("Parse(%q) failed: %v", tt.query, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse(%q) =\n%+v\nwant\n%+v", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseRejects(t *testing.T) {
+	tests := []struct {
+		query   string
+		wantErr string
+	}{
+		{"filter[password]=x", `filtering by "password" is not allowed`},
+		{"filter[email][gt]=x", `operator "gt" is not allowed on "email"`},
+		{"filter[is_active]=maybe", `invalid value for "is_active"`},
+		{"filter[age][in]=1,two", `invalid value for "age"`},
+		{"filter[created_at][lt]=yesterday", `invalid value for "created_at"`},
+		{"filter[code]=", `invalid value for "code"`},
+		{"sort=password", `sorting by "password" is not allowed`},
+		{"sort=-password", `sorting by "password" is not allowed`},
+		{"page[number]=0", "page[number] must be a positive integer"},
+		{"page[number]=42949673&page[size]=50", "page[number] must be at most 42949672"},
+		{"page[number]=9223372036854775807", "page[number] must be at most 107374182"},
+		{"page[size]=-5", "page[size] must be a positive integer"},
+		{"page[size]=ten", "page[size] must be a positive integer"},
+		{"page[after]=garbage", "page[after]: malformed cursor"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			values, err := url.ParseQuery(tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = Parse(values, testWhitelist)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Parse(%q) error = %v, want %q", tt.query, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPageSizeLimit(t *testing.T) {
+	if got := (Whitelist{}).PageSizeLimit(); got != maxPageSize {
+		t.Errorf("default limit = %d, want %d", got, maxPageSize)
+	}
+	if got := (Whitelist{MaxPageSize: 25}).PageSizeLimit(); got != 25 {
+		t.Errorf("limit = %d, want 25", got)
+	}
+}
@@ -0,0 +1,333 @@
+package router
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+
+	"boilerplate-golang/internal/application/apiversion"
+	"boilerplate-golang/internal/application/apperror"
+	"boilerplate-golang/internal/application/controller"
+	"boilerplate-golang/internal/application/dto"
+	"boilerplate-golang/internal/application/openapi"
+	"boilerplate-golang/internal/application/service"
+	"boilerplate-golang/internal/application/sheet"
+	"boilerplate-golang/internal/infrastructure/config"
+	"boilerplate-golang/internal/infrastructure/dbmanager"
+)
+
+// Parameters and responses shared by several operations
+var (
+	tokenQuery = openapi.Param{Name: "token", Required: true, Description: "Token of the emailed link"}
+	ifMatch    = openapi.Param{Name: "If-Match", Description: "ETag of the version being updated; 412 when it is stale"}
+
+	// message is the data of operations that only confirm what they did
+	message = ""
+
+	notImplemented = struct {
+		Message string `json:"message"`
+	}{}
+)
+
+// apiDocs documents the API routes for the OpenAPI spec, with paths relative
+// to the group of a version; the specs of versions hold the entries of the
+// routes they register. Every route needs an entry: `openapi --check` fails
+// on the ones that lack it.
+var apiDocs = []openapi.Operation{
+	// System
+	{Method: http.MethodGet, Path: "/health", Tag: "System", Summary: "Health check", Access: openapi.Public,
+		Response: struct {
+			Status string `json:"status"`
+		}{}, Plain: true},
+
+	// Auth
+	{Method: http.MethodPost, Path: "/auth/register", Tag: "Auth", Summary: "Register an account",
+		Description: "Closed with 403 invite_only when registration is by invitation only.",
+		Access:      openapi.Public, Body: dto.UserCreateRequest{}, Response: dto.UserResponse{},
+		Status: http.StatusCreated, ETag: true, Errors: []int{http.StatusForbidden, http.StatusConflict}},
+	{Method: http.MethodPost, Path: "/auth/login", Tag: "Auth", Summary: "Log in",
+		Description: "Opens a session and returns its access token.",
+		Access:      openapi.Public, Body: dto.LoginRequest{}, Response: dto.TokenResponse{},
+		Errors: []int{http.StatusUnauthorized, http.StatusForbidden}},
+	{Method: http.MethodPost, Path: "/auth/logout", Tag: "Auth", Summary: "Log out",
+		Description: "Revokes the session of the token.",
+		Response:    message, Errors: []int{http.StatusBadRequest}},
+	{Method: http.MethodGet, Path: "/auth/confirm-email", Tag: "Auth", Summary: "Preview an email change",
+		Description: "Returns the new address of a confirmation link without changing anything.",
+		Access:      openapi.Public, Query: []openapi.Param{tokenQuery}, Response: dto.EmailChangePreview{},
+		Errors: []int{http.StatusBadRequest}},
+	{Method: http.MethodPost, Path: "/auth/confirm-email", Tag: "Auth", Summary: "Confirm an email change",
+		Description: "Applies the change with the token of the confirmation link and notifies the previous address.",
+		Access:      openapi.Public, Body: dto.EmailChangeConfirmRequest{}, Response: dto.UserResponse{},
+		Errors: []int{http.StatusBadRequest, http.StatusConflict}},
+	{Method: http.MethodPost, Path: "/auth/forgot-password", Tag: "Auth", Summary: "Request a password reset link",
+		Description: "Succeeds whether or not an account has the email.",
+		Access:      openapi.Public, Body: dto.ForgotPasswordRequest{}, Response: message},
+	{Method: http.MethodPost, Path: "/auth/reset-password", Tag: "Auth", Summary: "Reset a password",
+		Description: "Sets the password with the token of a reset link and revokes every session.",
+		Access:      openapi.Public, Body: dto.ResetPasswordRequest{}, Response: message,
+		Errors: []int{http.StatusBadRequest, http.StatusForbidden}},
+	{Method: http.MethodGet, Path: "/auth/invitation", Tag: "Auth", Summary: "Preview an invitation",
+		Access: openapi.Public, Query: []openapi.Param{tokenQuery}, Response: dto.InvitationPreview{},
+		Errors: []int{http.StatusBadRequest}},
+	{Method: http.MethodPost, Path: "/auth/invitation/accept", Tag: "Auth", Summary: "Accept an invitation",
+		Description: "Creates the account of the invitation, or activates the existing one of its email.",
+		Access:      openapi.Public, Body: dto.InvitationAcceptRequest{}, Response: dto.UserResponse{},
+		Errors: []int{http.StatusBadRequest, http.StatusForbidden, http.StatusConflict}},
+
+	// Account
+	{Method: http.MethodGet, Path: "/users/me", Tag: "Account", Summary: "Get my account",
+		Response: dto.UserResponse{}, ETag: true},
+	{Method: http.MethodPut, Path: "/users/me", Tag: "Account", Summary: "Update my profile",
+		Description: "The expected version comes from If-Match (412 when stale) or the version field (409).",
+		Headers:     []openapi.Param{ifMatch}, Body: dto.ProfileUpdateRequest{}, Response: dto.UserResponse{},
+		ETag: true, Errors: []int{http.StatusConflict, http.StatusPreconditionFailed}},
+	{Method: http.MethodPost, Path: "/users/me/avatar", Tag: "Account", Summary: "Upload my avatar",
+		Description: "Takes a JPEG, PNG or GIF image and stores its resized variants.",
+		Form:        []openapi.Param{{Name: "avatar", Type: "file", Required: true, Description: "Image file"}},
+		Response:    dto.UserResponse{}, ETag: true},
+	{Method: http.MethodDelete, Path: "/users/me/avatar", Tag: "Account", Summary: "Remove my avatar",
+		Response: dto.UserResponse{}, ETag: true},
+	{Method: http.MethodPost, Path: "/users/me/password", Tag: "Account", Summary: "Change my password",
+		Description: "Revokes every other session.",
+		Body:        dto.ChangePasswordRequest{}, Response: struct {
+			RevokedSessions int `json:"revoked_sessions"`
+		}{}, Errors: []int{http.StatusBadRequest, http.StatusForbidden}},
+	{Method: http.MethodPost, Path: "/users/me/email", Tag: "Account", Summary: "Change my email",
+		Description: "Sends a confirmation link to the new address; the email changes once it is opened.",
+		Body:        dto.EmailChangeRequest{}, Response: message,
+		Errors: []int{http.StatusBadRequest, http.StatusForbidden, http.StatusConflict}},
+
+	// Privacy
+	{Method: http.MethodPost, Path: "/users/me/data-export", Tag: "Privacy", Summary: "Request an export of my data",
+		Description: "The export is prepared in the background and its download link emailed.",
+		Response:    dto.DataRequestResponse{}, Status: http.StatusAccepted, Errors: []int{http.StatusConflict}},
+	{Method: http.MethodGet, Path: "/users/me/data-requests", Tag: "Privacy", Summary: "List my data requests",
+		Response: []dto.DataRequestResponse{}},
+	{Method: http.MethodGet, Path: "/users/me/data-requests/:id/download", Tag: "Privacy",
+		Summary: "Get the download link of a data export", Response: dto.DownloadResponse{},
+		Errors: []int{http.StatusConflict}},
+	{Method: http.MethodPost, Path: "/users/me/erasure", Tag: "Privacy", Summary: "Request the erasure of my account",
+		Description: "An admin reviews the request; the account is erased after the grace period.",
+		Body:        dto.ErasureRequest{}, Response: dto.DataRequestResponse{}, Status: http.StatusAccepted,
+		Errors: []int{http.StatusForbidden, http.StatusConflict}},
+	{Method: http.MethodDelete, Path: "/users/me/erasure", Tag: "Privacy", Summary: "Cancel my erasure request",
+		Response: dto.DataRequestResponse{}, Errors: []int{http.StatusNotFound}},
+
+	// Users
+	{Method: http.MethodGet, Path: "/users", Tag: "Users", Summary: "List users", Access: openapi.Admin,
+		List: &service.UserQuery, Response: dto.UserResponse{}},
+	{Method: http.MethodPost, Path: "/users", Tag: "Users", Summary: "Create a user", Access: openapi.Admin,
+		Body: dto.UserCreateRequest{}, Response: dto.UserResponse{}, Status: http.StatusCreated, ETag: true,
+		Errors: []int{http.StatusConflict}},
+	{Method: http.MethodGet, Path: "/users/:id", Tag: "Users", Summary: "Get a user",
+		Description: "Users may only get their own account.",
+		Response:    dto.UserResponse{}, ETag: true, Errors: []int{http.StatusForbidden}},
+	{Method: http.MethodPut, Path: "/users/:id", Tag: "Users", Summary: "Update a user",
+		Description: "Users may only update their own account. Versions work as in PUT /users/me.",
+		Headers:     []openapi.Param{ifMatch}, Body: dto.UserUpdateRequest{}, Response: dto.UserResponse{}, ETag: true,
+		Errors: []int{http.StatusBadRequest, http.StatusForbidden, http.StatusConflict, http.StatusPreconditionFailed}},
+	{Method: http.MethodDelete, Path: "/users/:id", Tag: "Users", Summary: "Delete a user",
+		Description: "Soft deletes the account and revokes its sessions; users may only delete their own. The last active admin can't be deleted.",
+		Response:    dto.AdminUserResponse{}, Errors: []int{http.StatusForbidden, http.StatusConflict}},
+
+	// Files
+	{Method: http.MethodGet, Path: "/files/*key", Tag: "Files", Summary: "Download a file",
+		Description: "Serves the files of the local storage driver. Private files need the signature of a signed link.",
+		Access:      openapi.Public, Query: []openapi.Param{
+			{Name: "expires", Description: "Expiry of a signed link, in Unix seconds"},
+			{Name: "signature", Description: "Signature of a signed link"},
+		}, Files: []string{"application/octet-stream"}, Errors: []int{http.StatusForbidden}},
+	{Method: http.MethodPost, Path: "/upload", Tag: "Files", Summary: "Upload a file (not implemented)",
+		Response: notImplemented, Plain: true},
+
+	// Admin: users
+	{Method: http.MethodGet, Path: "/admin/users", Tag: "Admin users", Summary: "Search users", Access: openapi.Admin,
+		List: &service.UserQuery, Response: dto.AdminUserResponse{}},
+	{Method: http.MethodGet, Path: "/admin/users/deleted", Tag: "Admin users", Summary: "List deleted users",
+		Access: openapi.Admin, List: &service.UserQuery, Response: dto.UserResponse{}},
+	{Method: http.MethodPost, Path: "/admin/users/import", Tag: "Admin users", Summary: "Import users",
+		Description: "Starts importing the users of a CSV or XLSX file in the background and returns the job " +
+			"to poll. A dry run responds 200 with the validation report (ImportReport) instead.",
+		Access: openapi.Admin, Form: []openapi.Param{
+			{Name: "file", Type: "file", Required: true, Description: "CSV or XLSX file, at most 10 MB"},
+			{Name: "mapping", Description: "JSON object mapping user fields to column names"},
+			{Name: "dry_run", Type: "boolean", Description: "Validate the file without importing it"},
+			{Name: "send_invites", Type: "boolean", Description: "Email an invitation to each imported user"},
+		}, Response: dto.ImportJobResponse{}, Status: http.StatusAccepted},
+	{Method: http.MethodGet, Path: "/admin/users/import/:id", Tag: "Admin users", Summary: "Get an import job",
+		Access: openapi.Admin, Response: dto.ImportJobResponse{}},
+	{Method: http.MethodGet, Path: "/admin/users/export", Tag: "Admin users", Summary: "Export users",
+		Description: "Downloads the users matching the filters, search and sort of GET /admin/users.",
+		Access:      openapi.Admin, Query: []openapi.Param{
+			{Name: "format", Enum: []string{sheet.FormatCSV, sheet.FormatXLSX, "json"}, Description: "File format, csv by default"},
+		}, List: &service.UserQuery,
+		Files:  []string{sheet.ContentTypes[sheet.FormatCSV], sheet.ContentTypes[sheet.FormatXLSX], "application/json"},
+		Errors: []int{http.StatusUnprocessableEntity}},
+	{Method: http.MethodGet, Path: "/admin/users/:id", Tag: "Admin users", Summary: "Get a user with their sessions",
+		Access: openapi.Admin, Response: dto.AdminUserDetailResponse{}},
+	{Method: http.MethodPost, Path: "/admin/users/:id/restore", Tag: "Admin users", Summary: "Restore a deleted user",
+		Access: openapi.Admin, Response: message, Errors: []int{http.StatusConflict}},
+	adminAction("suspend", "Suspend a user", "Revokes the user's sessions; they can't log in until reactivated."),
+	adminAction("reactivate", "Reactivate a suspended user", ""),
+	adminAction("promote", "Promote a user to admin", "Takes effect at the user's next login."),
+	adminAction("demote", "Demote an admin", "The last active admin can't be demoted."),
+	adminAction("logout", "Log a user out of every session", ""),
+	adminAction("password-reset", "Send a user a password reset link", ""),
+
+	// Admin: privacy
+	{Method: http.MethodGet, Path: "/admin/privacy/requests", Tag: "Admin privacy", Summary: "List data requests",
+		Access: openapi.Admin, List: &service.DataRequestQuery, Response: dto.DataRequestResponse{}},
+	{Method: http.MethodPost, Path: "/admin/privacy/requests/:id/approve", Tag: "Admin privacy",
+		Summary: "Approve an erasure request", Description: "Schedules the erasure at the end of the grace period.",
+		Access: openapi.Admin, Body: dto.DataRequestReviewRequest{}, OptionalBody: true,
+		Response: dto.DataRequestResponse{}, Errors: []int{http.StatusConflict}},
+	{Method: http.MethodPost, Path: "/admin/privacy/requests/:id/reject", Tag: "Admin privacy",
+		Summary: "Reject an erasure request", Access: openapi.Admin,
+		Body: dto.DataRequestReviewRequest{}, OptionalBody: true,
+		Response: dto.DataRequestResponse{}, Errors: []int{http.StatusConflict}},
+
+	// Admin: invitations
+	{Method: http.MethodGet, Path: "/admin/invitations", Tag: "Admin invitations", Summary: "List invitations",
+		Access: openapi.Admin, List: &service.InvitationQuery, Response: dto.InvitationResponse{}},
+	{Method: http.MethodPost, Path: "/admin/invitations", Tag: "Admin invitations", Summary: "Invite a user",
+		Description: "Emails a link to register, or to join for an existing account. Organization invitations (org_id) are not supported yet.",
+		Access:      openapi.Admin, Body: dto.InvitationCreateRequest{}, Response: dto.InvitationResponse{},
+		Status: http.StatusCreated, Errors: []int{http.StatusConflict}},
+	{Method: http.MethodPost, Path: "/admin/invitations/:id/resend", Tag: "Admin invitations",
+		Summary: "Resend an invitation", Description: "Sends a new link, which extends the expiry.",
+		Access: openapi.Admin, Response: dto.InvitationResponse{}, Errors: []int{http.StatusConflict}},
+	{Method: http.MethodPost, Path: "/admin/invitations/:id/revoke", Tag: "Admin invitations",
+		Summary: "Revoke an invitation", Access: openapi.Admin, Response: dto.InvitationResponse{},
+		Errors: []int{http.StatusConflict}},
+
+	// Admin: audit and history
+	{Method: http.MethodGet, Path: "/admin/audit-logs", Tag: "Admin audit", Summary: "List audit log entries",
+		Access: openapi.Admin, List: &service.AuditLogQuery, Response: dto.AuditLogResponse{}},
+	{Method: http.MethodGet, Path: "/admin/history/:entity/:id", Tag: "Admin audit",
+		Summary: "List the change history of a record", Description: "entity is the table of the record, e.g. users.",
+		Access: openapi.Admin, List: &service.HistoryQuery, Response: dto.HistoryResponse{}},
+
+	// Admin: database
+	{Method: http.MethodGet, Path: "/admin/db/stats", Tag: "Admin database", Summary: "Connection pool statistics",
+		Access: openapi.Admin, Response: []dbmanager.PoolStats{}},
+	{Method: http.MethodGet, Path: "/admin/db/queries", Tag: "Admin database", Summary: "Query statistics by table",
+		Access: openapi.Admin, Response: []dbmanager.QueryStat{}},
+
+	// Admin: products and orders, not implemented yet
+	{Method: http.MethodPost, Path: "/admin/products/import", Tag: "Admin products",
+		Summary: "Import products (not implemented)", Access: openapi.Admin, Response: notImplemented, Plain: true},
+	{Method: http.MethodPost, Path: "/admin/products/export", Tag: "Admin products",
+		Summary: "Export products (not implemented)", Access: openapi.Admin, Response: notImplemented, Plain: true},
+	{Method: http.MethodGet, Path: "/admin/all-orders", Tag: "Admin orders",
+		Summary: "List all orders (not implemented)", Access: openapi.Admin, Response: notImplemented, Plain: true},
+	{Method: http.MethodPut, Path: "/admin/orders/:id/status", Tag: "Admin orders",
+		Summary: "Update an order status (not implemented)", Access: openapi.Admin, Response: notImplemented, Plain: true},
+	{Method: http.MethodGet, Path: "/admin/stats/orders", Tag: "Admin orders",
+		Summary: "Order statistics (not implemented)", Access: openapi.Admin, Response: notImplemented, Plain: true},
+	{Method: http.MethodGet, Path: "/admin/stats/revenue", Tag: "Admin orders",
+		Summary: "Revenue statistics (not implemented)", Access: openapi.Admin, Response: notImplemented, Plain: true},
+}
+
+// adminAction documents POST /admin/users/:id/<action>, which takes an
+// optional reason for the audit log.
+func adminAction(action, summary, description string) openapi.Operation {
+	return openapi.Operation{
+		Method: http.MethodPost, Path: "/admin/users/:id/" + action, Tag: "Admin users",
+		Summary: summary, Description: description, Access: openapi.Admin,
+		Body: dto.AdminActionRequest{}, OptionalBody: true, Response: dto.AdminUserResponse{},
+		Errors: []int{http.StatusBadRequest, http.StatusConflict},
+	}
+}
+
+// Spec returns the OpenAPI document of a version of the API, from its routes
+// registered on engine, and false when there is no such version.
+func Spec(engine *gin.Engine, version string) (*openapi.Document, bool) {
+	v, ok := apiversion.Lookup(version)
+	if !ok {
+		return nil, false
+	}
+	base := apiBase + "/" + v.Name
+
+	ops := make([]openapi.Operation, len(apiDocs))
+	for i, op := range apiDocs {
+		_, op.Deprecated = v.Deprecated(op.Method, op.Path)
+		ops[i] = op
+	}
+	info := openapi.Info{
+		Title: config.Get().App.Name,
+		Description: "Responses are wrapped in an envelope: data holds the result, code is 0 on success and the HTTP " +
+			"status on failure, with error_code and details saying what failed. Unversioned paths such as " +
+			apiBase + "/users are served by the version the " + apiversion.Header + " header names, " +
+			apiversion.Default() + " without it.",
+		Version: v.Name,
+	}
+	return openapi.Build(info, []openapi.Server{{URL: base}}, base, engine.Routes(), ops), true
+}
+
+// CheckDocs returns the API routes registered on engine that apiDocs lacks,
+// as "<version> METHOD path", and its entries no version registers.
+func CheckDocs(engine *gin.Engine) (undocumented, stale []string) {
+	unusedIn := map[string]int{}
+	for _, v := range apiversion.Versions() {
+		missing, unused := openapi.Check(apiBase+"/"+v.Name, engine.Routes(), apiDocs)
+		for _, route := range missing {
+			undocumented = append(undocumented, v.Name+" "+route)
+		}
+		for _, route := range unused {
+			unusedIn[route]++
+		}
+	}
+	for _, op := range apiDocs {
+		if key := op.Method + " " + op.Path; unusedIn[key] == len(apiversion.Versions()) {
+			stale = append(stale, key)
+		}
+	}
+	return undocumented, stale
+}
+
+// serveDocs serves the spec of each API version at /openapi/<version>.json,
+// the one of the latest at /openapi.json, and their viewer at /docs, e.g.
+// /docs?version=v1. They are outside the API, so they need no token. Specs
+// are built on first use, once every route is registered.
+func serveDocs(engine *gin.Engine) {
+	var (
+		once  sync.Once
+		specs = map[string][]byte{}
+		err   error
+	)
+	serve := func(c *gin.Context, version string) {
+		once.Do(func() {
+			for _, name := range apiversion.Names() {
+				doc, _ := Spec(engine, name)
+				if specs[name], err = json.Marshal(doc); err != nil {
+					return
+				}
+			}
+		})
+		if err != nil {
+			_ = c.Error(apperror.Internal(err))
+			c.Abort()
+			return
+		}
+		spec, ok := specs[version]
+		if !ok {
+			controller.NotFound(c)
+			return
+		}
+		c.Data(http.StatusOK, "application/json; charset=utf-8", spec)
+	}
+
+	engine.GET("/openapi.json", func(c *gin.Context) {
+		serve(c, apiversion.Latest())
+	})
+	engine.GET("/openapi/:file", func(c *gin.Context) {
+		serve(c, strings.TrimSuffix(c.Param("file"), ".json"))
+	})
+	engine.GET("/docs", func(c *gin.Context) {
+		c.Data(http.StatusOK, "text/html; charset=utf-8", openapi.DocsPage)
+	})
+}
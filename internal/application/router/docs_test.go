@@ -0,0 +1,26 @@
+package router
+
+import (
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestRoutesAreDocumented fails when a route is added without its apiDocs
+// entry, or an entry outlives its route.
+func TestRoutesAreDocumented(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	Register(engine, nil)
+
+	undocumented, stale := CheckDocs(engine)
+	for _, route := range undocumented {
+		t.Errorf("route %s has no apiDocs entry", route)
+	}
+	for _, route := range stale {
+		t.Errorf("apiDocs entry %s matches no route", route)
+	}
+	if len(engine.Routes()) == 0 {
+		t.Fatal("Register registered no routes")
+	}
+}
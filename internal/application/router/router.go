@@ -1,56 +1,73 @@
 package router
 
 import (
+	"net/http"
+	"strings"
+
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
+
+	"boilerplate-golang/internal/application/apiversion"
+	"boilerplate-golang/internal/application/controller"
+	"boilerplate-golang/internal/application/dto"
+	"boilerplate-golang/internal/infrastructure/config"
+	"boilerplate-golang/internal/infrastructure/dbmanager"
+	"boilerplate-golang/internal/infrastructure/logger"
 )
 
+// apiBase is the path the API versions are mounted under.
+const apiBase = "/api"
+
+// versions lists the API versions, oldest first. Each is served under
+// /api/<name>; the first one also serves unversioned paths such as
+// /api/users, so existing clients keep working. A new version registers the
+// route groups of the previous one that didn't change and its own for those
+// that did. Deprecate a whole version with Deprecation, or single routes:
+//
+//	Routes: map[string]apiversion.Deprecation{
+//		"GET /users": {Since: time.Date(...), Sunset: time.Date(...), Link: "https://..."},
+//	},
+var versions = []apiversion.Version{
+	{Name: "v1", Register: registerV1},
+}
+
 // Register registers all HTTP routes on the given engine.
 func Register(router *gin.Engine, db *gorm.DB) {
-	// API base group
-	api := router.Group("/api")
-
-	// Health check
-	api.GET("/health", func(c *gin.Context) {
-		c.JSON(200, gin.H{"status": "ok"})
-	})
+	// Failures are sent as the error envelope, panics as internal errors
+	router.Use(controller.ErrorMiddleware(), config.RecoveryMiddleware())
+	router.NoRoute(controller.NotFound)
 
 	// Initialize services
 	// TODO: Initialize services when implementing the actual handlers
 	_ = db // Use db to avoid unused variable warning
 
-	// Auth endpoints
-	// TODO: Uncomment when auth controller is implemented
-	// authCtrl := controller.NewAuthController(authService)
-	// api.POST("/auth/register", authCtrl.Register)
-	// api.POST("/auth/login", authCtrl.Login)
-	// api.POST("/auth/refresh", authCtrl.RefreshToken)
-	// api.POST("/auth/password-reset/request", authCtrl.RequestPasswordReset)
-	// api.POST("/auth/password-reset/confirm", authCtrl.ConfirmPasswordReset)
+	// Every API route needs a valid token unless it is whitelisted in config
+	apiversion.Mount(router, apiBase, versions, config.AuthMiddleware())
 
-	// User endpoints
-	api.GET("/users/me", func(c *gin.Context) {
-		c.JSON(200, gin.H{"message": "Get user profile (not implemented)"})
-	})
-	api.PUT("/users/me", func(c *gin.Context) {
-		c.JSON(200, gin.H{"message": "Update user profile (not implemented)"})
-	})
-	api.GET("/users/:id", func(c *gin.Context) {
-		c.JSON(200, gin.H{"message": "Get user by ID (not implemented)"})
-	})
-	api.GET("/users", func(c *gin.Context) {
-		c.JSON(200, gin.H{"message": "Get all users (not implemented)"})
-	})
-	api.POST("/users", func(c *gin.Context) {
-		c.JSON(200, gin.H{"message": "Create user (not implemented)"})
-	})
-	api.PUT("/users/:id", func(c *gin.Context) {
-		c.JSON(200, gin.H{"message": "Update user (not implemented)"})
-	})
-	api.DELETE("/users/:id", func(c *gin.Context) {
-		c.JSON(200, gin.H{"message": "Delete user (not implemented)"})
+	// OpenAPI spec of the API routes and its viewer; new routes need an entry
+	// in apiDocs
+	serveDocs(router)
+	if undocumented, _ := CheckDocs(router); len(undocumented) > 0 {
+		logger.Warn("Routes missing from the OpenAPI spec: %s", strings.Join(undocumented, ", "))
+	}
+}
+
+// Handler returns engine as the server's handler, which serves unversioned
+// API paths with the version the request asks for.
+func Handler(engine *gin.Engine) http.Handler {
+	return apiversion.Negotiate(engine)
+}
+
+// registerV1 registers the routes of version 1.
+func registerV1(api *gin.RouterGroup) {
+	// Health check
+	api.GET("/health", func(c *gin.Context) {
+		c.JSON(200, gin.H{"status": "ok"})
 	})
 
+	registerAuth(api)
+	registerUsers(api)
+
 	// Product endpoints
 	// TODO: Uncomment when product controller is implemented
 	// productCtrl := controller.NewProductController(productService)
@@ -90,27 +107,104 @@ func Register(router *gin.Engine, db *gorm.DB) {
 	// api.POST("/payments/create-payment-intent", paymentCtrl.CreatePaymentIntent)
 	// api.POST("/payments/webhook", paymentCtrl.HandleWebhook)
 
-	// File uploads
-	api.Static("/files", "./uploads")
+	registerFiles(api)
+	registerAdmin(api)
+}
+
+// registerAuth registers the sign-up, login and account recovery routes.
+func registerAuth(api *gin.RouterGroup) {
+	// Auth endpoints
+	api.POST("/auth/register", controller.UserCtrl.Register)
+	api.POST("/auth/login", controller.AuthCtrl.Login)
+	api.POST("/auth/logout", controller.AuthCtrl.Logout)
+	api.GET("/auth/confirm-email", controller.AuthCtrl.PreviewEmailChange)
+	api.POST("/auth/confirm-email", controller.AuthCtrl.ConfirmEmail)
+	api.POST("/auth/forgot-password", controller.AuthCtrl.ForgotPassword)
+	api.POST("/auth/reset-password", controller.AuthCtrl.ResetPassword)
+	api.GET("/auth/invitation", controller.InvitationCtrl.PreviewInvitation)
+	api.POST("/auth/invitation/accept", controller.InvitationCtrl.AcceptInvitation)
+	// TODO: Uncomment when implemented
+	// api.POST("/auth/refresh", controller.AuthCtrl.RefreshToken)
+}
+
+// registerUsers registers the routes of user accounts.
+func registerUsers(api *gin.RouterGroup) {
+	// User endpoints
+	api.GET("/users/me", controller.UserCtrl.GetMe)
+	api.PUT("/users/me", controller.UserCtrl.UpdateMe)
+	api.POST("/users/me/avatar", controller.UserCtrl.UploadAvatar)
+	api.DELETE("/users/me/avatar", controller.UserCtrl.DeleteAvatar)
+	// Users act on their own account; listing and creating accounts is for admins
+	api.POST("/users/me/password", controller.UserCtrl.ChangePassword)
+	api.POST("/users/me/email", controller.UserCtrl.RequestEmailChange)
+	api.POST("/users/me/data-export", controller.PrivacyCtrl.RequestExport)
+	api.GET("/users/me/data-requests", controller.PrivacyCtrl.GetMyDataRequests)
+	api.GET("/users/me/data-requests/:id/download", controller.PrivacyCtrl.DownloadExport)
+	api.POST("/users/me/erasure", controller.PrivacyCtrl.RequestErasure)
+	api.DELETE("/users/me/erasure", controller.PrivacyCtrl.CancelErasure)
+	api.GET("/users/:id", controller.UserCtrl.GetUser)
+	api.GET("/users", config.AdminMiddleware(), controller.UserCtrl.GetUsers)
+	api.POST("/users", config.AdminMiddleware(), controller.UserCtrl.CreateUser)
+	api.PUT("/users/:id", controller.UserCtrl.UpdateUser)
+	api.DELETE("/users/:id", controller.UserCtrl.DeleteUser)
+}
+
+// registerFiles registers the file routes.
+func registerFiles(api *gin.RouterGroup) {
+	// Files of the local storage driver; private ones need a signed link
+	api.GET("/files/*key", controller.FileCtrl.GetFile)
 	api.POST("/upload", func(c *gin.Context) {
 		// TODO: Implement file upload handler
 		c.JSON(200, gin.H{"message": "File upload endpoint"})
 	})
+}
 
+// registerAdmin registers the /admin routes.
+func registerAdmin(api *gin.RouterGroup) {
 	// Admin routes (protected by admin middleware)
 	admin := api.Group("/admin")
-	admin.Use(func() gin.HandlerFunc {
-		return func(c *gin.Context) {
-			// TODO: Implement admin middleware
-			c.Next()
-		}
-	}())
+	admin.Use(config.ClientCertMiddleware())
+	admin.Use(config.AdminMiddleware())
 
 	// Admin user management
-	// TODO: Uncomment when user controller is implemented
-	// admin.GET("/users", userCtrl.GetAllUsers)
-	// admin.GET("/users/:id", userCtrl.GetUserByID)
-	// admin.DELETE("/users/:id", userCtrl.DeleteUser)
+	admin.GET("/users", controller.UserCtrl.SearchUsers)
+	admin.GET("/users/deleted", controller.UserCtrl.GetDeletedUsers)
+	admin.POST("/users/import", controller.UserCtrl.ImportUsers)
+	admin.GET("/users/import/:id", controller.UserCtrl.GetImportJob)
+	admin.GET("/users/export", controller.UserCtrl.ExportUsers)
+	admin.GET("/users/:id", controller.UserCtrl.GetUserDetail)
+	admin.POST("/users/:id/restore", controller.UserCtrl.RestoreUser)
+	admin.POST("/users/:id/suspend", controller.UserCtrl.SuspendUser)
+	admin.POST("/users/:id/reactivate", controller.UserCtrl.ReactivateUser)
+	admin.POST("/users/:id/promote", controller.UserCtrl.PromoteUser)
+	admin.POST("/users/:id/demote", controller.UserCtrl.DemoteUser)
+	admin.POST("/users/:id/logout", controller.UserCtrl.ForceLogout)
+	admin.POST("/users/:id/password-reset", controller.UserCtrl.TriggerPasswordReset)
+
+	// Admin data subject requests
+	admin.GET("/privacy/requests", controller.PrivacyCtrl.GetDataRequests)
+	admin.POST("/privacy/requests/:id/approve", controller.PrivacyCtrl.ApproveErasure)
+	admin.POST("/privacy/requests/:id/reject", controller.PrivacyCtrl.RejectErasure)
+
+	// Admin invitations
+	admin.GET("/invitations", controller.InvitationCtrl.GetInvitations)
+	admin.POST("/invitations", controller.InvitationCtrl.CreateInvitation)
+	admin.POST("/invitations/:id/resend", controller.InvitationCtrl.ResendInvitation)
+	admin.POST("/invitations/:id/revoke", controller.InvitationCtrl.RevokeInvitation)
+
+	// Admin audit log
+	admin.GET("/audit-logs", controller.AuditCtrl.GetAuditLogs)
+
+	// Admin change history
+	admin.GET("/history/:entity/:id", controller.HistoryCtrl.GetHistory)
+
+	// Admin database monitoring
+	admin.GET("/db/stats", func(c *gin.Context) {
+		c.JSON(200, dto.Success(dbmanager.Stats()))
+	})
+	admin.GET("/db/queries", func(c *gin.Context) {
+		c.JSON(200, dto.Success(dbmanager.QueryStats()))
+	})
 
 	// Admin product management
 	admin.POST("/products/import", func(c *gin.Context) {
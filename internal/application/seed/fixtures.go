@@ -0,0 +1,70 @@
+package seed
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+
+	"go.yaml.in/yaml/v3"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// DecodeFixture reads a YAML or JSON fixture file from fsys into out.
+// Keys follow the json tags of the target struct, so entities can be used directly.
+func DecodeFixture(fsys fs.FS, name string, out interface{}) error {
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return fmt.Errorf("failed to read fixture %s: %w", name, err)
+	}
+
+	switch strings.ToLower(path.Ext(name)) {
+	case ".json":
+	case ".yaml", ".yml":
+		// Round-trip through JSON so the json struct tags apply to YAML too
+		var raw interface{}
+		if err := yaml.Unmarshal(data, &raw); err != nil {
+			return fmt.Errorf("failed to parse fixture %s: %w", name, err)
+		}
+		if data, err = json.Marshal(raw); err != nil {
+			return fmt.Errorf("failed to convert fixture %s: %w", name, err)
+		}
+	default:
+		return fmt.Errorf("unsupported fixture format: %s", name)
+	}
+
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("failed to decode fixture %s: %w", name, err)
+	}
+	return nil
+}
+
+// LoadFixtures inserts raw rows into tables, for tests that need a known dataset.
+// Each file maps table names to a list of rows keyed by column:
+//
+//	users:
+//	  - id: u1
+//	    username: alice
+//
+// Rows that already exist (same primary key) are left untouched. Hooks don't
+// run, so values such as password hashes must be given as stored.
+func LoadFixtures(ctx context.Context, db *gorm.DB, fsys fs.FS, names ...string) error {
+	for _, name := range names {
+		var tables map[string][]map[string]interface{}
+		if err := DecodeFixture(fsys, name, &tables); err != nil {
+			return err
+		}
+		for table, rows := range tables {
+			if len(rows) == 0 {
+				continue
+			}
+			if err := db.WithContext(ctx).Table(table).Clauses(clause.OnConflict{DoNothing: true}).Create(rows).Error; err != nil {
+				return fmt.Errorf("failed to load fixture %s into %s: %w", name, table, err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package seed
+
+import (
+	"context"
+	"embed"
+	"fmt"
+
+	"boilerplate-golang/internal/infrastructure/dbmanager"
+	"boilerplate-golang/internal/infrastructure/logger"
+)
+
+//go:embed fixtures
+var fixtures embed.FS
+
+// Seeder populates reference or demo data. Run must be idempotent: seeding
+// twice leaves the database as seeding once.
+type Seeder struct {
+	Name string
+	// Envs restricts the seeder to these app environments; empty means all
+	Envs []string
+	Run  func(ctx context.Context) error
+}
+
+// registry holds the seeders in the order they run.
+var registry []Seeder
+
+// Register adds a seeder; seeders run in registration order.
+func Register(s Seeder) {
+	registry = append(registry, s)
+}
+
+// Seeders returns the registered seeders.
+func Seeders() []Seeder {
+	return registry
+}
+
+// Run executes the seeders enabled for env, each in its own transaction.
+// When names are given only those run, and asking for one that is unknown or
+// not enabled for env is an error.
+func Run(ctx context.Context, env string, names ...string) error {
+	selected := registry
+	if len(names) > 0 {
+		selected = nil
+		for _, name := range names {
+			s, ok := find(name)
+			if !ok {
+				return fmt.Errorf("unknown seeder %q", name)
+			}
+			if !s.enabledFor(env) {
+				return fmt.Errorf("seeder %q is not enabled for %s", name, env)
+			}
+			selected = append(selected, s)
+		}
+	}
+
+	for _, s := range selected {
+		if !s.enabledFor(env) {
+			continue
+		}
+		if err := dbmanager.WithTx(ctx, s.Run); err != nil {
+			return fmt.Errorf("seeder %s failed: %w", s.Name, err)
+		}
+		logger.Info("seed: ran %s", s.Name)
+	}
+	return nil
+}
+
+func find(name string) (Seeder, bool) {
+	for _, s := range registry {
+		if s.Name == name {
+			return s, true
+		}
+	}
+	return Seeder{}, false
+}
+
+func (s Seeder) enabledFor(env string) bool {
+	if len(s.Envs) == 0 {
+		return true
+	}
+	for _, e := range s.Envs {
+		if e == env {
+			return true
+		}
+	}
+	return false
+}
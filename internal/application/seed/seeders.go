@@ -0,0 +1,78 @@
+package seed
+
+import (
+	"context"
+	"errors"
+
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+
+	"boilerplate-golang/internal/application/entity"
+	"boilerplate-golang/internal/application/tools"
+	"boilerplate-golang/internal/infrastructure/config"
+	"boilerplate-golang/internal/infrastructure/dbmanager"
+	"boilerplate-golang/internal/infrastructure/logger"
+)
+
+func init() {
+	Register(Seeder{Name: "admin", Run: seedAdmin})
+	Register(Seeder{Name: "demo_users", Envs: []string{"development", "staging"}, Run: seedDemoUsers})
+}
+
+// seedAdmin creates the initial admin account from the [seed] config.
+func seedAdmin(ctx context.Context) error {
+	cfg := config.Get().Seed
+	if cfg.AdminEmail == "" || cfg.AdminPassword == "" {
+		logger.Warn("seed: admin_email or admin_password not set, skipping admin user")
+		return nil
+	}
+	username := cfg.AdminUsername
+	if username == "" {
+		username = "admin"
+	}
+
+	return ensureUser(ctx, entity.User{
+		Username: username,
+		Email:    cfg.AdminEmail,
+		Password: cfg.AdminPassword,
+		FullName: "Administrator",
+		IsAdmin:  true,
+	})
+}
+
+// seedDemoUsers creates the demo accounts listed in fixtures/demo_users.yaml.
+func seedDemoUsers(ctx context.Context) error {
+	var users []entity.User
+	if err := DecodeFixture(fixtures, "fixtures/demo_users.yaml", &users); err != nil {
+		return err
+	}
+	for _, u := range users {
+		if err := ensureUser(ctx, u); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ensureUser creates u unless a user with the same username exists.
+// u.Password is the plain-text password and is hashed here.
+func ensureUser(ctx context.Context, u entity.User) error {
+	db := dbmanager.DB(ctx)
+
+	var existing entity.User
+	err := db.Unscoped().Where("username = ?", u.Username).First(&existing).Error
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	hashed, err := bcrypt.GenerateFromPassword([]byte(u.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	u.ID = tools.NewUuid()
+	u.Password = string(hashed)
+	return db.Create(&u).Error
+}
@@ -0,0 +1,62 @@
+package service
+
+import (
+	"context"
+
+	"boilerplate-golang/internal/application/dto"
+	"boilerplate-golang/internal/application/entity"
+	"boilerplate-golang/internal/application/privacy"
+	"boilerplate-golang/internal/application/queryspec"
+	"boilerplate-golang/internal/infrastructure/dbmanager"
+)
+
+type auditService struct {
+}
+
+// AuditLogQuery whitelists the audit log fields admins may filter and sort on.
+var AuditLogQuery = queryspec.Whitelist{
+	Filters: map[string]queryspec.Field{
+		"action":      {Column: "action", Ops: []string{queryspec.OpEq, queryspec.OpIn, queryspec.OpLike}},
+		"actor_id":    {Column: "actor_id", Ops: []string{queryspec.OpEq}},
+		"target_type": {Column: "target_type", Ops: []string{queryspec.OpEq}},
+		"target_id":   {Column: "target_id", Ops: []string{queryspec.OpEq}},
+		"request_id":  {Column: "request_id", Ops: []string{queryspec.OpEq}},
+		"created_at":  {Column: "created_at", Type: queryspec.TypeTime, Ops: []string{queryspec.OpGte, queryspec.OpLte}},
+	},
+	Sorts: map[string]string{
+		"id":         "id",
+		"created_at": "created_at",
+	},
+	DefaultSort: "-id",
+}
+
+func init() {
+	privacy.OnExport("audit", exportAuditLogs)
+}
+
+// GetAuditLogs returns a page of audit log entries.
+func (s *auditService) GetAuditLogs(ctx context.Context, spec queryspec.Spec) dto.ResponseDto {
+	if spec.Cursor {
+		return queryspec.ListCursor[entity.AuditLog](dbmanager.DB(ctx), spec, AuditLogQuery, dto.GetAuditLogResponse)
+	}
+	return queryspec.List[entity.AuditLog](dbmanager.DB(ctx), spec, AuditLogQuery, dto.GetAuditLogResponse)
+}
+
+// exportAuditLogs includes the admin actions taken on the user's account.
+// Entries are kept on erasure, they record what admins did.
+func exportAuditLogs(ctx context.Context, userID string) ([]privacy.File, error) {
+	var rows []entity.AuditLog
+	if err := dbmanager.DB(ctx).Where("target_type = ? AND target_id = ?", "user", userID).
+		Order("id").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+	entries := make([]dto.AuditLogResponse, len(rows))
+	for i, row := range rows {
+		entries[i] = dto.GetAuditLogResponse(row)
+	}
+	f, err := privacy.JSONFile("audit.json", entries)
+	return []privacy.File{f}, err
+}
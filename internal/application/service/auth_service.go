@@ -0,0 +1,635 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+
+	"boilerplate-golang/internal/application/apperror"
+	"boilerplate-golang/internal/application/dto"
+	"boilerplate-golang/internal/application/entity"
+	"boilerplate-golang/internal/application/tools"
+	"boilerplate-golang/internal/infrastructure/config"
+	"boilerplate-golang/internal/infrastructure/cryptomanager"
+	"boilerplate-golang/internal/infrastructure/dbmanager"
+	"boilerplate-golang/internal/infrastructure/logger"
+	"boilerplate-golang/internal/infrastructure/mailmanager"
+)
+
+type authService struct {
+}
+
+const (
+	// emailChangeTTL is how long an email change confirmation link stays valid.
+	emailChangeTTL = 24 * time.Hour
+	// passwordResetTTL is how long a password reset link stays valid.
+	passwordResetTTL = time.Hour
+)
+
+// errSessionInactive is returned by CheckSession for revoked or expired sessions.
+var errSessionInactive = errors.New("session is not active")
+
+// dummyHash is compared against when a login names an unknown user, so the
+// response time doesn't reveal which emails have accounts.
+var dummyHash, _ = bcrypt.GenerateFromPassword([]byte("not-a-real-password"), bcrypt.DefaultCost)
+
+// Login verifies the credentials, opens a session and returns tokens bound to it.
+func (s *authService) Login(ctx context.Context, email, password, userAgent, ip string) dto.ResponseDto {
+	bidx, err := cryptomanager.BlindIndex(email)
+	if err != nil {
+		logger.Error("Error computing email blind index: %v", err)
+		return *dto.Fail("Error logging in")
+	}
+
+	// Read from the primary, a replica may not have a just-changed password yet
+	db := dbmanager.Primary(ctx)
+
+	var user entity.User
+	if err := db.Where("email_bidx = ?", bidx).First(&user).Error; err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			logger.Error("Error fetching user for login: %v", err)
+			return *dto.Fail("Error logging in")
+		}
+		_ = bcrypt.CompareHashAndPassword(dummyHash, []byte(password))
+		return *dto.FailWith(apperror.Unauthorized("invalid_credentials", "Invalid email or password"))
+	}
+	if bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)) != nil {
+		return *dto.FailWith(apperror.Unauthorized("invalid_credentials", "Invalid email or password"))
+	}
+	if !user.IsActive {
+		return *dto.FailWith(apperror.Forbidden("account_disabled", "Account is disabled"))
+	}
+
+	now := time.Now().UTC()
+	session := entity.UserSession{
+		ID:        tools.NewUuid(),
+		UserID:    user.ID,
+		UserAgent: truncate(userAgent, 255),
+		IP:        truncate(ip, 64),
+		CreatedAt: now,
+		ExpiresAt: now.Add(config.RefreshJWT.ExpireIn),
+	}
+	err = dbmanager.WithTx(ctx, func(ctx context.Context) error {
+		tx := dbmanager.DB(ctx)
+		if err := tx.Create(&session).Error; err != nil {
+			return err
+		}
+		return tx.Model(&user).UpdateColumn("last_login", now).Error
+	})
+	if err != nil {
+		logger.Error("Error creating session: %v", err)
+		return *dto.Fail("Error logging in")
+	}
+
+	role := entity.RoleUser
+	if user.IsAdmin {
+		role = entity.RoleAdmin
+	}
+	tokens, err := config.GenerateTokenPair(user.ID, "", role, session.ID)
+	if err != nil {
+		logger.Error("Error generating tokens: %v", err)
+		return *dto.Fail("Error logging in")
+	}
+
+	return *dto.Success(dto.TokenResponse{
+		AccessToken:  tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+		ExpiresIn:    int64(time.Until(tokens.ExpiresAt).Seconds()),
+		TokenType:    "Bearer",
+	})
+}
+
+// Logout revokes the caller's session.
+func (s *authService) Logout(ctx context.Context, userID, sessionID string) dto.ResponseDto {
+	if sessionID == "" {
+		return *dto.FailWith(apperror.BadRequest("session_unbound", "Token is not bound to a session"))
+	}
+	if _, err := revokeSessions(dbmanager.Primary(ctx), userID, "id = ?", sessionID); err != nil {
+		logger.Error("Error revoking session: %v", err)
+		return *dto.Fail("Error logging out")
+	}
+	config.InvalidateRefreshToken(userID, sessionID)
+	return *dto.Success("Logged out successfully")
+}
+
+// CheckSession returns an error unless the session exists, belongs to the
+// user and is neither revoked nor expired. AuthMiddleware calls it for every
+// session-bound token (see config.OnSessionCheck).
+func (s *authService) CheckSession(ctx context.Context, userID, sessionID string) error {
+	// Read from the primary so revocations apply immediately
+	var session entity.UserSession
+	if err := dbmanager.Primary(ctx).Where("id = ? AND user_id = ?", sessionID, userID).First(&session).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errSessionInactive
+		}
+		return err
+	}
+	if !session.Active(time.Now().UTC()) {
+		return errSessionInactive
+	}
+	return nil
+}
+
+// ChangePassword replaces the user's password after verifying the current one,
+// and revokes every other session of the user; the caller's session (if any)
+// stays signed in.
+func (s *authService) ChangePassword(ctx context.Context, userID, sessionID, currentPassword, newPassword string) dto.ResponseDto {
+	if currentPassword == newPassword {
+		return *dto.FailWith(apperror.BadRequest("password_unchanged", "New password must differ from the current one"))
+	}
+
+	db := dbmanager.Primary(ctx)
+
+	var user entity.User
+	if err := db.First(&user, "id = ?", userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return *dto.FailWith(apperror.NotFound("user_not_found", "User not found"))
+		}
+		logger.Error("Error fetching user for password change: %v", err)
+		return *dto.Fail("Error changing password")
+	}
+	if bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(currentPassword)) != nil {
+		return *dto.FailWith(apperror.Forbidden("incorrect_password", "Current password is incorrect"))
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+	if err != nil {
+		logger.Error("Error hashing password: %v", err)
+		return *dto.Fail("Error changing password")
+	}
+
+	var revoked []string
+	err = dbmanager.WithTx(ctx, func(ctx context.Context) error {
+		tx := dbmanager.DB(ctx)
+		if err := dbmanager.UpdateVersioned(tx, &user, user.Version, map[string]interface{}{
+			"password": string(hashedPassword),
+		}); err != nil {
+			return err
+		}
+		revoked, err = revokeSessions(tx, userID, "id <> ?", sessionID)
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, dbmanager.ErrVersionConflict) {
+			return *dto.FailWith(apperror.Conflict("version_conflict", "User was modified by someone else, try again"))
+		}
+		logger.Error("Error changing password: %v", err)
+		return *dto.Fail("Error changing password")
+	}
+
+	for _, id := range revoked {
+		config.InvalidateRefreshToken(userID, id)
+	}
+	mailmanager.SendAsync(mailmanager.Message{
+		To:      user.Email,
+		Subject: "Your password was changed",
+		Body: fmt.Sprintf("Hi %s,\n\nThe password of your account was just changed and your other sessions were signed out.\n"+
+			"If you didn't do this, reset your password right away.\n", user.Username),
+	})
+	return *dto.SuccessMessageKey("password_changed", "Password changed successfully", map[string]int{"revoked_sessions": len(revoked)})
+}
+
+// RequestEmailChange starts changing the user's email to newEmail: it mails a
+// confirmation link to the new address, and the change only applies once the
+// link is followed (see ConfirmEmailChange). A newer request supersedes any
+// pending one.
+func (s *authService) RequestEmailChange(ctx context.Context, userID, newEmail, password string) dto.ResponseDto {
+	if !tools.IsValidEmail(newEmail) {
+		return *dto.FailWith(apperror.BadRequest("invalid_email", "Invalid email format"))
+	}
+
+	db := dbmanager.Primary(ctx)
+
+	var user entity.User
+	if err := db.First(&user, "id = ?", userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return *dto.FailWith(apperror.NotFound("user_not_found", "User not found"))
+		}
+		logger.Error("Error fetching user for email change: %v", err)
+		return *dto.Fail("Error requesting email change")
+	}
+	if bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)) != nil {
+		return *dto.FailWith(apperror.Forbidden("incorrect_password", "Password is incorrect"))
+	}
+
+	bidx, err := cryptomanager.BlindIndex(newEmail)
+	if err != nil {
+		logger.Error("Error computing email blind index: %v", err)
+		return *dto.Fail("Error requesting email change")
+	}
+	if bidx == user.EmailBidx {
+		return *dto.FailWith(apperror.BadRequest("email_unchanged", "This is already your email"))
+	}
+	if res, ok := emailTaken(db, bidx, userID); !ok {
+		return res
+	}
+
+	err = dbmanager.WithTx(ctx, func(ctx context.Context) error {
+		secret, err := issueToken(dbmanager.DB(ctx), userID, entity.TokenEmailChange, newEmail, emailChangeTTL)
+		if err != nil {
+			return err
+		}
+
+		link := strings.TrimSuffix(config.Get().App.FrontendURL, "/") + "/confirm-email?token=" + url.QueryEscape(secret)
+		dbmanager.AfterCommit(ctx, func() {
+			mailmanager.SendAsync(mailmanager.Message{
+				To:      newEmail,
+				Subject: "Confirm your new email address",
+				Body: fmt.Sprintf("Hi %s,\n\nConfirm that this is your new email address by opening the link below:\n\n%s\n\n"+
+					"The link expires in %s. If you didn't ask for this, ignore this email.\n", user.Username, link, emailChangeTTL),
+			})
+		})
+		return nil
+	})
+	if err != nil {
+		logger.Error("Error creating email change token: %v", err)
+		return *dto.Fail("Error requesting email change")
+	}
+
+	return *dto.Success("Confirmation link sent to the new address")
+}
+
+// PreviewEmailChange returns the new address of the email change a link was
+// issued for, without applying it: link scanners and prefetchers follow the
+// link too, so the client confirms with ConfirmEmailChange.
+func (s *authService) PreviewEmailChange(ctx context.Context, secret string) dto.ResponseDto {
+	var token entity.UserToken
+	if err := dbmanager.Primary(ctx).Where("token_hash = ? AND purpose = ? AND used_at IS NULL", hashSecret(secret), entity.TokenEmailChange).
+		First(&token).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return *dto.FailWith(apperror.BadRequest("invalid_link", "Link is invalid or has expired"))
+		}
+		logger.Error("Error fetching email change token: %v", err)
+		return *dto.Fail("Error fetching email change")
+	}
+	if !time.Now().UTC().Before(token.ExpiresAt) {
+		return *dto.FailWith(apperror.BadRequest("invalid_link", "Link is invalid or has expired"))
+	}
+	return *dto.Success(dto.EmailChangePreview{Email: token.Payload, ExpiresAt: token.ExpiresAt})
+}
+
+// ConfirmEmailChange applies the email change a confirmation link was issued
+// for and notifies the previous address.
+func (s *authService) ConfirmEmailChange(ctx context.Context, secret string) dto.ResponseDto {
+	hash := hashSecret(secret)
+
+	var (
+		res      dto.ResponseDto
+		user     entity.User
+		oldEmail string
+	)
+	err := dbmanager.WithTx(ctx, func(ctx context.Context) error {
+		tx := dbmanager.DB(ctx)
+		now := time.Now().UTC()
+
+		var token entity.UserToken
+		if err := tx.Where("token_hash = ? AND purpose = ? AND used_at IS NULL", hash, entity.TokenEmailChange).
+			First(&token).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				res = *dto.FailWith(apperror.BadRequest("invalid_link", "Link is invalid or has expired"))
+				return errRollback
+			}
+			logger.Error("Error fetching email change token: %v", err)
+			res = *dto.Fail("Error confirming email change")
+			return err
+		}
+		if !now.Before(token.ExpiresAt) {
+			res = *dto.FailWith(apperror.BadRequest("invalid_link", "Link is invalid or has expired"))
+			return errRollback
+		}
+
+		if err := tx.First(&user, "id = ?", token.UserID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				res = *dto.FailWith(apperror.NotFound("user_not_found", "User not found"))
+				return errRollback
+			}
+			logger.Error("Error fetching user for email change: %v", err)
+			res = *dto.Fail("Error confirming email change")
+			return err
+		}
+		oldEmail = user.Email
+
+		bidx, err := cryptomanager.BlindIndex(token.Payload)
+		if err != nil {
+			logger.Error("Error computing email blind index: %v", err)
+			res = *dto.Fail("Error confirming email change")
+			return err
+		}
+		if taken, ok := emailTaken(tx, bidx, user.ID); !ok {
+			res = taken
+			return errRollback
+		}
+
+		// Map updates bypass the encrypted serializer, so encrypt explicitly
+		encrypted, err := cryptomanager.EncryptColumn(user.TableName(), "email", token.Payload)
+		if err != nil {
+			logger.Error("Error encrypting email: %v", err)
+			res = *dto.Fail("Error confirming email change")
+			return err
+		}
+		if err := dbmanager.UpdateVersioned(tx, &user, user.Version, map[string]interface{}{
+			"email":      encrypted,
+			"email_bidx": bidx,
+		}); err != nil {
+			if errors.Is(err, dbmanager.ErrVersionConflict) {
+				res = *dto.FailWith(apperror.Conflict("version_conflict", "User was modified by someone else, try again"))
+				return errRollback
+			}
+			if taken, ok := userTaken(err); ok {
+				res = taken
+				return errRollback
+			}
+			logger.Error("Error updating email: %v", err)
+			res = *dto.Fail("Error confirming email change")
+			return err
+		}
+		if err := tx.Model(&token).Update("used_at", now).Error; err != nil {
+			logger.Error("Error marking email change token used: %v", err)
+			res = *dto.Fail("Error confirming email change")
+			return err
+		}
+
+		// Reload, the model now holds the encrypted value from the update map
+		if err := tx.First(&user, "id = ?", user.ID).Error; err != nil {
+			logger.Error("Error reloading user: %v", err)
+			res = *dto.Fail("Error confirming email change")
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		if res.Code == 0 {
+			// Failed while committing
+			logger.Error("Error committing transaction: %v", err)
+			return *dto.Fail("Error confirming email change")
+		}
+		return res
+	}
+
+	mailmanager.SendAsync(mailmanager.Message{
+		To:      oldEmail,
+		Subject: "Your email address was changed",
+		Body: fmt.Sprintf("Hi %s,\n\nThe email address of your account was changed from this address to %s.\n"+
+			"If you didn't do this, contact support right away.\n", user.Username, user.Email),
+	})
+	return *dto.SuccessMessageKey("email_changed", "Email changed successfully", dto.GetUserResponse(user))
+}
+
+// RequestPasswordReset mails a password reset link to the account with the
+// given email. The response is the same whether or not the account exists, so
+// it can't be used to probe for registered emails.
+func (s *authService) RequestPasswordReset(ctx context.Context, email string) dto.ResponseDto {
+	sent := *dto.Success("If an account exists for this email, a reset link has been sent")
+
+	bidx, err := cryptomanager.BlindIndex(email)
+	if err != nil {
+		logger.Error("Error computing email blind index: %v", err)
+		return *dto.Fail("Error requesting password reset")
+	}
+
+	var user entity.User
+	if err := dbmanager.Primary(ctx).Where("email_bidx = ?", bidx).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return sent
+		}
+		logger.Error("Error fetching user for password reset: %v", err)
+		return *dto.Fail("Error requesting password reset")
+	}
+	if !user.IsActive {
+		return sent
+	}
+
+	err = dbmanager.WithTx(ctx, func(ctx context.Context) error {
+		return sendPasswordReset(ctx, user)
+	})
+	if err != nil {
+		logger.Error("Error creating password reset token: %v", err)
+		return *dto.Fail("Error requesting password reset")
+	}
+	return sent
+}
+
+// ResetPassword sets a new password through a reset or invite link and signs
+// out every session of the user.
+func (s *authService) ResetPassword(ctx context.Context, secret, newPassword string) dto.ResponseDto {
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+	if err != nil {
+		logger.Error("Error hashing password: %v", err)
+		return *dto.Fail("Error resetting password")
+	}
+
+	var (
+		res     dto.ResponseDto
+		user    entity.User
+		revoked []string
+	)
+	err = dbmanager.WithTx(ctx, func(ctx context.Context) error {
+		tx := dbmanager.DB(ctx)
+		now := time.Now().UTC()
+
+		var token entity.UserToken
+		if err := tx.Where("token_hash = ? AND purpose = ? AND used_at IS NULL", hashSecret(secret), entity.TokenPasswordReset).
+			First(&token).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				res = *dto.FailWith(apperror.BadRequest("invalid_link", "Link is invalid or has expired"))
+				return errRollback
+			}
+			logger.Error("Error fetching password reset token: %v", err)
+			res = *dto.Fail("Error resetting password")
+			return err
+		}
+		if !now.Before(token.ExpiresAt) {
+			res = *dto.FailWith(apperror.BadRequest("invalid_link", "Link is invalid or has expired"))
+			return errRollback
+		}
+
+		if err := tx.First(&user, "id = ?", token.UserID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				res = *dto.FailWith(apperror.NotFound("user_not_found", "User not found"))
+				return errRollback
+			}
+			logger.Error("Error fetching user for password reset: %v", err)
+			res = *dto.Fail("Error resetting password")
+			return err
+		}
+		if !user.IsActive {
+			res = *dto.FailWith(apperror.Forbidden("account_disabled", "Account is disabled"))
+			return errRollback
+		}
+
+		if err := dbmanager.UpdateVersioned(tx, &user, user.Version, map[string]interface{}{
+			"password": string(hashedPassword),
+		}); err != nil {
+			if errors.Is(err, dbmanager.ErrVersionConflict) {
+				res = *dto.FailWith(apperror.Conflict("version_conflict", "User was modified by someone else, try again"))
+				return errRollback
+			}
+			logger.Error("Error resetting password: %v", err)
+			res = *dto.Fail("Error resetting password")
+			return err
+		}
+		if err := tx.Model(&token).Update("used_at", now).Error; err != nil {
+			logger.Error("Error marking password reset token used: %v", err)
+			res = *dto.Fail("Error resetting password")
+			return err
+		}
+		if revoked, err = revokeSessions(tx, user.ID, ""); err != nil {
+			logger.Error("Error revoking sessions: %v", err)
+			res = *dto.Fail("Error resetting password")
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		if res.Code == 0 {
+			// Failed while committing
+			logger.Error("Error committing transaction: %v", err)
+			return *dto.Fail("Error resetting password")
+		}
+		return res
+	}
+
+	for _, id := range revoked {
+		config.InvalidateRefreshToken(user.ID, id)
+	}
+	mailmanager.SendAsync(mailmanager.Message{
+		To:      user.Email,
+		Subject: "Your password was changed",
+		Body: fmt.Sprintf("Hi %s,\n\nThe password of your account was just reset and all sessions were signed out.\n"+
+			"If you didn't do this, contact support right away.\n", user.Username),
+	})
+	return *dto.Success("Password reset successfully, you can now log in")
+}
+
+// sendPasswordReset issues a password reset token for the user and mails the
+// link once the transaction in ctx commits.
+func sendPasswordReset(ctx context.Context, user entity.User) error {
+	secret, err := issueToken(dbmanager.DB(ctx), user.ID, entity.TokenPasswordReset, "", passwordResetTTL)
+	if err != nil {
+		return err
+	}
+
+	dbmanager.AfterCommit(ctx, func() {
+		mailmanager.SendAsync(mailmanager.Message{
+			To:      user.Email,
+			Subject: "Reset your password",
+			Body: fmt.Sprintf("Hi %s,\n\nOpen the link below to choose a new password:\n\n%s\n\n"+
+				"The link expires in %s. If you didn't ask for this, ignore this email.\n", user.Username, passwordResetLink(secret), passwordResetTTL),
+		})
+	})
+	return nil
+}
+
+// emailTaken reports a conflict when another user already has the email with
+// blind index bidx; ok is true when the email is free.
+func emailTaken(db *gorm.DB, bidx, userID string) (res dto.ResponseDto, ok bool) {
+	var taken int64
+	if err := db.Model(&entity.User{}).Where("email_bidx = ? AND id <> ?", bidx, userID).Count(&taken).Error; err != nil {
+		logger.Error("Error checking email existence: %v", err)
+		return *dto.Fail("Error checking email availability"), false
+	}
+	if taken > 0 {
+		return *dto.FailWith(apperror.Conflict("email_taken", "Email already in use")), false
+	}
+	return dto.ResponseDto{}, true
+}
+
+// userTaken reports the conflict of a user write that broke the unique index on
+// usernames or emails, which concurrent writes can race past the checks; ok is
+// false for other errors.
+func userTaken(err error) (res dto.ResponseDto, ok bool) {
+	switch {
+	case dbmanager.IsUniqueViolation(err, "users", "username"):
+		return *dto.FailWith(apperror.Conflict("username_taken", "Username already exists")), true
+	case dbmanager.IsUniqueViolation(err, "users", "email_bidx"):
+		return *dto.FailWith(apperror.Conflict("email_taken", "Email already in use")), true
+	}
+	return dto.ResponseDto{}, false
+}
+
+// revokeSessions revokes the user's active sessions matching cond (all of them
+// when cond is empty) and returns their IDs.
+func revokeSessions(db *gorm.DB, userID string, cond string, args ...interface{}) ([]string, error) {
+	query := db.Model(&entity.UserSession{}).Where("user_id = ? AND revoked_at IS NULL", userID)
+	if cond != "" {
+		query = query.Where(cond, args...)
+	}
+	var ids []string
+	if err := query.Pluck("id", &ids).Error; err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	err := db.Model(&entity.UserSession{}).Where("id IN ?", ids).Update("revoked_at", time.Now().UTC()).Error
+	return ids, err
+}
+
+// issueToken creates a user token for purpose, superseding any unused token
+// the user has for it, and returns the secret to send. Call it inside a
+// transaction.
+func issueToken(db *gorm.DB, userID, purpose, payload string, ttl time.Duration) (string, error) {
+	secret, hash, err := newTokenSecret()
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now().UTC()
+	if err := db.Model(&entity.UserToken{}).
+		Where("user_id = ? AND purpose = ? AND used_at IS NULL", userID, purpose).
+		Update("used_at", now).Error; err != nil {
+		return "", err
+	}
+	token := entity.UserToken{
+		ID:        tools.NewUuid(),
+		UserID:    userID,
+		Purpose:   purpose,
+		TokenHash: hash,
+		Payload:   payload,
+		CreatedAt: now,
+		ExpiresAt: now.Add(ttl),
+	}
+	if err := db.Create(&token).Error; err != nil {
+		return "", err
+	}
+	return secret, nil
+}
+
+// passwordResetLink returns the web client link that resets a password with secret.
+func passwordResetLink(secret string) string {
+	return strings.TrimSuffix(config.Get().App.FrontendURL, "/") + "/reset-password?token=" + url.QueryEscape(secret)
+}
+
+// newTokenSecret returns a random URL-safe secret and the hash stored for it.
+func newTokenSecret() (secret, hash string, err error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	secret = base64.RawURLEncoding.EncodeToString(b)
+	return secret, hashSecret(secret), nil
+}
+
+// hashSecret returns the hash a token secret is stored and looked up by.
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// truncate shortens s to at most n bytes.
+func truncate(s string, n int) string {
+	if len(s) > n {
+		return s[:n]
+	}
+	return s
+}
@@ -0,0 +1,45 @@
+package service
+
+import (
+	"context"
+
+	"golang.org/x/exp/slices"
+
+	"boilerplate-golang/internal/application/apperror"
+	"boilerplate-golang/internal/application/dto"
+	"boilerplate-golang/internal/application/entity"
+	"boilerplate-golang/internal/application/queryspec"
+	"boilerplate-golang/internal/infrastructure/dbmanager"
+)
+
+type historyService struct {
+}
+
+// HistoryQuery whitelists the history fields list callers may filter and sort on.
+var HistoryQuery = queryspec.Whitelist{
+	Filters: map[string]queryspec.Field{
+		"action":     {Column: "action", Ops: []string{queryspec.OpEq, queryspec.OpIn}},
+		"actor_id":   {Column: "actor_id", Ops: []string{queryspec.OpEq}},
+		"request_id": {Column: "request_id", Ops: []string{queryspec.OpEq}},
+		"created_at": {Column: "created_at", Type: queryspec.TypeTime, Ops: []string{queryspec.OpGte, queryspec.OpLte}},
+	},
+	Sorts: map[string]string{
+		"id":         "id",
+		"created_at": "created_at",
+	},
+	// Newest first; ids follow insertion order even within one transaction
+	DefaultSort: "-id",
+}
+
+// GetHistory returns a page of the recorded changes of one entity row.
+func (s *historyService) GetHistory(ctx context.Context, name, id string, spec queryspec.Spec) dto.ResponseDto {
+	if !slices.Contains(dbmanager.TrackedEntities(), name) {
+		return *dto.FailWith(apperror.NotFound("unknown_entity", "Unknown entity"))
+	}
+
+	db := dbmanager.DB(ctx).Where("entity = ? AND entity_id = ?", name, id)
+	if spec.Cursor {
+		return queryspec.ListCursor[entity.EntityHistory](db, spec, HistoryQuery, dto.GetHistoryResponse)
+	}
+	return queryspec.List[entity.EntityHistory](db, spec, HistoryQuery, dto.GetHistoryResponse)
+}
@@ -0,0 +1,426 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+
+	"boilerplate-golang/internal/application/apperror"
+	"boilerplate-golang/internal/application/dto"
+	"boilerplate-golang/internal/application/entity"
+	"boilerplate-golang/internal/application/privacy"
+	"boilerplate-golang/internal/application/purge"
+	"boilerplate-golang/internal/application/queryspec"
+	"boilerplate-golang/internal/application/tools"
+	"boilerplate-golang/internal/infrastructure/config"
+	"boilerplate-golang/internal/infrastructure/cryptomanager"
+	"boilerplate-golang/internal/infrastructure/dbmanager"
+	"boilerplate-golang/internal/infrastructure/logger"
+	"boilerplate-golang/internal/infrastructure/mailmanager"
+)
+
+type invitationService struct {
+}
+
+// InvitationQuery whitelists the invitation fields admins may filter and sort on.
+var InvitationQuery = queryspec.Whitelist{
+	Filters: map[string]queryspec.Field{
+		// email is encrypted and matched exactly through its blind index
+		"email":      {Column: "email_bidx", Ops: []string{queryspec.OpEq}, Index: cryptomanager.BlindIndex},
+		"status":     {Column: "status", Ops: []string{queryspec.OpEq, queryspec.OpIn}},
+		"role":       {Column: "role", Ops: []string{queryspec.OpEq}},
+		"org_id":     {Column: "org_id", Ops: []string{queryspec.OpEq}},
+		"invited_by": {Column: "invited_by", Ops: []string{queryspec.OpEq}},
+		"created_at": {Column: "created_at", Type: queryspec.TypeTime, Ops: []string{queryspec.OpGte, queryspec.OpLte}},
+		"expires_at": {Column: "expires_at", Type: queryspec.TypeTime, Ops: []string{queryspec.OpGte, queryspec.OpLte}},
+	},
+	Sorts: map[string]string{
+		"created_at": "created_at",
+		"expires_at": "expires_at",
+	},
+	DefaultSort: "-created_at",
+}
+
+// invalidInvitation is the response for unknown, used, revoked and expired
+// invitation links alike.
+var invalidInvitation = *dto.FailWith(apperror.BadRequest("invalid_link", "Invitation is invalid or has expired"))
+
+func init() {
+	privacy.OnExport("invitations", exportInvitations)
+	privacy.OnErase("invitations", eraseInvitations)
+	purge.OnPurge("users", purgeInvitations)
+}
+
+// CreateInvitation invites email to join with role and mails the invitation
+// link. Users don't belong to organizations yet, so an invitation to one
+// would grant nothing and orgID must be empty.
+func (s *invitationService) CreateInvitation(ctx context.Context, inviterID, email, role, orgID string) dto.ResponseDto {
+	if !tools.IsValidEmail(email) {
+		return *dto.FailWith(apperror.BadRequest("invalid_email", "Invalid email format"))
+	}
+	if orgID != "" {
+		return *dto.FailWith(apperror.BadRequest("org_unsupported", "Organization invitations are not supported yet"))
+	}
+	if role == "" {
+		role = entity.RoleUser
+	}
+
+	bidx, err := cryptomanager.BlindIndex(email)
+	if err != nil {
+		logger.Error("Error computing email blind index: %v", err)
+		return *dto.Fail("Error creating invitation")
+	}
+	secret, hash, err := newTokenSecret()
+	if err != nil {
+		logger.Error("Error generating invitation secret: %v", err)
+		return *dto.Fail("Error creating invitation")
+	}
+
+	now := time.Now().UTC()
+	inv := entity.Invitation{
+		ID:        tools.NewUuid(),
+		Email:     email,
+		Role:      role,
+		OrgID:     orgID,
+		InvitedBy: inviterID,
+		Status:    entity.InvitationPending,
+		TokenHash: hash,
+		SentCount: 1,
+		ExpiresAt: now.Add(config.Get().Invitations.TTL),
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	var res dto.ResponseDto
+	err = dbmanager.WithTx(ctx, func(ctx context.Context) error {
+		tx := dbmanager.DB(ctx)
+
+		var pending int64
+		if err := tx.Model(&entity.Invitation{}).
+			Where("email_bidx = ? AND org_id = ? AND status = ? AND expires_at > ?", bidx, orgID, entity.InvitationPending, now).
+			Count(&pending).Error; err != nil {
+			logger.Error("Error checking pending invitations: %v", err)
+			res = *dto.Fail("Error creating invitation")
+			return err
+		}
+		if pending > 0 {
+			res = *dto.FailWith(apperror.Conflict("invitation_pending", "This email already has a pending invitation, resend it instead"))
+			return errRollback
+		}
+
+		// Inviting an existing user only makes sense if it grants something
+		var user entity.User
+		if err := tx.Where("email_bidx = ?", bidx).First(&user).Error; err == nil {
+			if role == entity.RoleUser || user.IsAdmin {
+				res = *dto.FailWith(apperror.Conflict("user_has_access", "A user with this email already has this access"))
+				return errRollback
+			}
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			logger.Error("Error checking email existence: %v", err)
+			res = *dto.Fail("Error creating invitation")
+			return err
+		}
+
+		if err := tx.Create(&inv).Error; err != nil {
+			logger.Error("Error creating invitation: %v", err)
+			res = *dto.Fail("Error creating invitation")
+			return err
+		}
+
+		inviter := inviterName(tx, inviterID)
+		dbmanager.AfterCommit(ctx, func() {
+			sendInvitation(inv, secret, inviter)
+		})
+		return nil
+	})
+	if err != nil {
+		if res.Code == 0 {
+			// Failed while committing
+			logger.Error("Error committing transaction: %v", err)
+			return *dto.Fail("Error creating invitation")
+		}
+		return res
+	}
+
+	logger.Info("invitation %s created by %s", inv.ID, inviterID)
+	return *dto.SuccessMessage("Invitation sent", dto.GetInvitationResponse(inv))
+}
+
+// GetInvitations returns a page of invitations for admins.
+func (s *invitationService) GetInvitations(ctx context.Context, spec queryspec.Spec) dto.ResponseDto {
+	if spec.Cursor {
+		return queryspec.ListCursor(dbmanager.DB(ctx), spec, InvitationQuery, dto.GetInvitationResponse)
+	}
+	return queryspec.List(dbmanager.DB(ctx), spec, InvitationQuery, dto.GetInvitationResponse)
+}
+
+// ResendInvitation mails a pending invitation again with a new link, which
+// restarts its expiry. Links sent before stop working.
+func (s *invitationService) ResendInvitation(ctx context.Context, id string) dto.ResponseDto {
+	db := dbmanager.Primary(ctx)
+
+	inv, res, ok := findPendingInvitation(db, id)
+	if !ok {
+		return res
+	}
+
+	secret, hash, err := newTokenSecret()
+	if err != nil {
+		logger.Error("Error generating invitation secret: %v", err)
+		return *dto.Fail("Error resending invitation")
+	}
+	if res, ok := transitionInvitation(db, &inv, map[string]interface{}{
+		"token_hash": hash,
+		"sent_count": gorm.Expr("sent_count + 1"),
+		"expires_at": time.Now().UTC().Add(config.Get().Invitations.TTL),
+	}); !ok {
+		return res
+	}
+
+	sendInvitation(inv, secret, inviterName(db, inv.InvitedBy))
+	return *dto.SuccessMessage("Invitation resent", dto.GetInvitationResponse(inv))
+}
+
+// RevokeInvitation withdraws a pending invitation, so its link can no longer
+// be accepted.
+func (s *invitationService) RevokeInvitation(ctx context.Context, id string) dto.ResponseDto {
+	db := dbmanager.Primary(ctx)
+
+	inv, res, ok := findPendingInvitation(db, id)
+	if !ok {
+		return res
+	}
+	if res, ok := transitionInvitation(db, &inv, map[string]interface{}{
+		"status":     entity.InvitationRevoked,
+		"revoked_at": time.Now().UTC(),
+	}); !ok {
+		return res
+	}
+	return *dto.SuccessMessage("Invitation revoked", dto.GetInvitationResponse(inv))
+}
+
+// PreviewInvitation returns what an invitation link grants, so the invitee
+// can be shown it before accepting.
+func (s *invitationService) PreviewInvitation(ctx context.Context, secret string) dto.ResponseDto {
+	db := dbmanager.Primary(ctx)
+
+	var inv entity.Invitation
+	if err := db.Where("token_hash = ?", hashSecret(secret)).First(&inv).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return invalidInvitation
+		}
+		logger.Error("Error fetching invitation: %v", err)
+		return *dto.Fail("Error fetching invitation")
+	}
+	if inv.CurrentStatus(time.Now().UTC()) != entity.InvitationPending {
+		return invalidInvitation
+	}
+
+	var users int64
+	if err := db.Model(&entity.User{}).Where("email_bidx = ?", inv.EmailBidx).Count(&users).Error; err != nil {
+		logger.Error("Error checking email existence: %v", err)
+		return *dto.Fail("Error fetching invitation")
+	}
+
+	return *dto.Success(dto.InvitationPreview{
+		Email:      inv.Email,
+		Role:       inv.Role,
+		OrgID:      inv.OrgID,
+		InvitedBy:  inviterName(db, inv.InvitedBy),
+		HasAccount: users > 0,
+		ExpiresAt:  inv.ExpiresAt,
+	})
+}
+
+// AcceptInvitation accepts the invitation a link was issued for. Without an
+// account for the invited email, one is created from username, fullName and
+// password; otherwise password must be the account's password and the
+// account is granted the invitation's role.
+func (s *invitationService) AcceptInvitation(ctx context.Context, secret, username, fullName, password string) dto.ResponseDto {
+	var (
+		res  dto.ResponseDto
+		user entity.User
+	)
+	err := dbmanager.WithTx(ctx, func(ctx context.Context) error {
+		tx := dbmanager.DB(ctx)
+		now := time.Now().UTC()
+
+		var inv entity.Invitation
+		if err := tx.Where("token_hash = ?", hashSecret(secret)).First(&inv).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				res = invalidInvitation
+				return errRollback
+			}
+			logger.Error("Error fetching invitation: %v", err)
+			res = *dto.Fail("Error accepting invitation")
+			return err
+		}
+		if inv.CurrentStatus(now) != entity.InvitationPending {
+			res = invalidInvitation
+			return errRollback
+		}
+
+		err := tx.Where("email_bidx = ?", inv.EmailBidx).First(&user).Error
+		switch {
+		case err == nil:
+			// The link proves access to the mailbox, the password that the
+			// person following it owns the account
+			if bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)) != nil {
+				res = *dto.FailWith(apperror.Forbidden("incorrect_password", "Password is incorrect"))
+				return errRollback
+			}
+			if !user.IsActive {
+				res = *dto.FailWith(apperror.Forbidden("account_disabled", "Account is disabled"))
+				return errRollback
+			}
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			if username == "" || fullName == "" {
+				res = *dto.FailWith(apperror.BadRequest("account_details_required", "username and full_name are required to create an account"))
+				return errRollback
+			}
+			if created := IUserService.CreateUser(ctx, username, inv.Email, password, fullName); created.Code != 0 {
+				res = created
+				return errRollback
+			}
+			if err := tx.Where("email_bidx = ?", inv.EmailBidx).First(&user).Error; err != nil {
+				logger.Error("Error fetching created user: %v", err)
+				res = *dto.Fail("Error accepting invitation")
+				return err
+			}
+		default:
+			logger.Error("Error fetching user for invitation: %v", err)
+			res = *dto.Fail("Error accepting invitation")
+			return err
+		}
+
+		if inv.Role == entity.RoleAdmin && !user.IsAdmin {
+			if err := dbmanager.UpdateVersioned(tx, &user, user.Version, map[string]interface{}{
+				"is_admin": true,
+			}); err != nil {
+				if errors.Is(err, dbmanager.ErrVersionConflict) {
+					res = *dto.FailWith(apperror.Conflict("version_conflict", "User was modified by someone else, try again"))
+					return errRollback
+				}
+				logger.Error("Error granting invitation role: %v", err)
+				res = *dto.Fail("Error accepting invitation")
+				return err
+			}
+			if err := tx.First(&user, "id = ?", user.ID).Error; err != nil {
+				logger.Error("Error reloading user: %v", err)
+				res = *dto.Fail("Error accepting invitation")
+				return err
+			}
+		}
+
+		if r, ok := transitionInvitation(tx, &inv, map[string]interface{}{
+			"status":      entity.InvitationAccepted,
+			"accepted_by": user.ID,
+			"accepted_at": now,
+		}); !ok {
+			res = r
+			return errRollback
+		}
+		logger.Info("invitation %s accepted by user %s", inv.ID, user.ID)
+		return nil
+	})
+	if err != nil {
+		if res.Code == 0 {
+			// Failed while committing
+			logger.Error("Error committing transaction: %v", err)
+			return *dto.Fail("Error accepting invitation")
+		}
+		return res
+	}
+
+	return *dto.SuccessMessage("Invitation accepted, you can now log in", dto.GetUserResponse(user))
+}
+
+// findPendingInvitation loads the invitation with id, responding with a
+// conflict unless it is still pending (expired ones included).
+func findPendingInvitation(db *gorm.DB, id string) (entity.Invitation, dto.ResponseDto, bool) {
+	var inv entity.Invitation
+	if err := db.First(&inv, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return inv, *dto.FailWith(apperror.NotFound("invitation_not_found", "Invitation not found")), false
+		}
+		logger.Error("Error fetching invitation: %v", err)
+		return inv, *dto.Fail("Error fetching invitation"), false
+	}
+	if inv.Status != entity.InvitationPending {
+		return inv, *dto.FailWith(apperror.Conflict("invitation_not_pending",
+			"Invitation is "+inv.Status+", only pending invitations can be changed").WithParams(inv.Status)), false
+	}
+	return inv, dto.ResponseDto{}, true
+}
+
+// transitionInvitation applies updates to inv unless it stopped being pending
+// since it was read, and reloads it.
+func transitionInvitation(db *gorm.DB, inv *entity.Invitation, updates map[string]interface{}) (dto.ResponseDto, bool) {
+	res := db.Model(inv).Where("status = ?", entity.InvitationPending).Updates(updates)
+	if res.Error != nil {
+		logger.Error("Error updating invitation: %v", res.Error)
+		return *dto.Fail("Error updating invitation"), false
+	}
+	if res.RowsAffected == 0 {
+		return *dto.FailWith(apperror.Conflict("version_conflict", "Invitation was changed by someone else, reload and try again")), false
+	}
+	if err := db.First(inv, "id = ?", inv.ID).Error; err != nil {
+		logger.Error("Error reloading invitation: %v", err)
+		return *dto.Fail("Error updating invitation"), false
+	}
+	return dto.ResponseDto{}, true
+}
+
+// sendInvitation mails the invitation link for secret.
+func sendInvitation(inv entity.Invitation, secret, inviter string) {
+	link := strings.TrimSuffix(config.Get().App.FrontendURL, "/") + "/accept-invitation?token=" + url.QueryEscape(secret)
+	mailmanager.SendAsync(mailmanager.Message{
+		To:      inv.Email,
+		Subject: "You're invited to " + config.Get().App.Name,
+		Body: fmt.Sprintf("Hi,\n\n%s invited you to join %s. Open the link below to accept:\n\n%s\n\n"+
+			"The link expires on %s. If you weren't expecting this, ignore this email.\n",
+			inviter, config.Get().App.Name, link, inv.ExpiresAt.Format("2 January 2006")),
+	})
+}
+
+// inviterName returns the username of the inviter, for display to the invitee.
+func inviterName(db *gorm.DB, id string) string {
+	var inviter entity.User
+	if err := db.Unscoped().Select("id", "username").First(&inviter, "id = ?", id).Error; err != nil {
+		return "An administrator"
+	}
+	return inviter.Username
+}
+
+func exportInvitations(ctx context.Context, userID string) ([]privacy.File, error) {
+	var invs []entity.Invitation
+	if err := dbmanager.DB(ctx).Where("accepted_by = ?", userID).Order("created_at").Find(&invs).Error; err != nil {
+		return nil, err
+	}
+	if len(invs) == 0 {
+		return nil, nil
+	}
+	result := make([]dto.InvitationResponse, len(invs))
+	for i, inv := range invs {
+		result[i] = dto.GetInvitationResponse(inv)
+	}
+	f, err := privacy.JSONFile("invitations.json", result)
+	return []privacy.File{f}, err
+}
+
+// eraseInvitations deletes the invitations the user accepted, which hold the
+// user's email.
+func eraseInvitations(ctx context.Context, userID string) error {
+	return dbmanager.DB(ctx).Where("accepted_by = ?", userID).Delete(&entity.Invitation{}).Error
+}
+
+// purgeInvitations deletes the invitations accepted by purged users.
+func purgeInvitations(ctx context.Context, ids []string) error {
+	return dbmanager.DB(ctx).Where("accepted_by IN ?", ids).Delete(&entity.Invitation{}).Error
+}
@@ -0,0 +1,636 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+
+	"boilerplate-golang/internal/application/apperror"
+	"boilerplate-golang/internal/application/dto"
+	"boilerplate-golang/internal/application/entity"
+	"boilerplate-golang/internal/application/privacy"
+	"boilerplate-golang/internal/application/purge"
+	"boilerplate-golang/internal/application/queryspec"
+	"boilerplate-golang/internal/application/tools"
+	"boilerplate-golang/internal/infrastructure/config"
+	"boilerplate-golang/internal/infrastructure/cryptomanager"
+	"boilerplate-golang/internal/infrastructure/dbmanager"
+	"boilerplate-golang/internal/infrastructure/logger"
+	"boilerplate-golang/internal/infrastructure/mailmanager"
+	"boilerplate-golang/internal/infrastructure/storagemanager"
+)
+
+type privacyService struct {
+}
+
+const (
+	// downloadLinkTTL is how long a data export download link stays valid.
+	downloadLinkTTL = 15 * time.Minute
+	// exportStaleAfter lets a user request a new export when one has been
+	// building for this long, e.g. because its instance was restarted.
+	exportStaleAfter = 10 * time.Minute
+	// erasedFullName replaces the name of an erased user.
+	erasedFullName = "Deleted User"
+)
+
+// DataRequestQuery whitelists the data request fields admins may filter and sort on.
+var DataRequestQuery = queryspec.Whitelist{
+	Filters: map[string]queryspec.Field{
+		"kind":          {Column: "kind", Ops: []string{queryspec.OpEq}},
+		"status":        {Column: "status", Ops: []string{queryspec.OpEq, queryspec.OpIn}},
+		"user_id":       {Column: "user_id", Ops: []string{queryspec.OpEq}},
+		"created_at":    {Column: "created_at", Type: queryspec.TypeTime, Ops: []string{queryspec.OpGte, queryspec.OpLte}},
+		"scheduled_for": {Column: "scheduled_for", Type: queryspec.TypeTime, Ops: []string{queryspec.OpGte, queryspec.OpLte}},
+	},
+	Sorts: map[string]string{
+		"created_at":    "created_at",
+		"scheduled_for": "scheduled_for",
+	},
+	DefaultSort: "-created_at",
+}
+
+func init() {
+	// Export sections and erase steps for the data owned by this package.
+	// Orders, payments and other modules register their own as they are added.
+	privacy.OnExport("profile", exportProfile)
+	privacy.OnExport("sessions", exportSessions)
+	privacy.OnExport("history", exportHistory)
+	privacy.OnExport("files", exportFiles)
+	privacy.OnExport("requests", exportDataRequests)
+
+	// Files first, the profile step clears the avatar key they are found by
+	privacy.OnErase("files", eraseFiles)
+	privacy.OnErase("profile", eraseProfile)
+	privacy.OnErase("history", eraseHistory)
+	privacy.OnErase("events", eraseEvents)
+	privacy.OnErase("auth", eraseAuth)
+
+	purge.OnPurge("users", purgeDataRequests)
+}
+
+// RequestExport starts building an archive of the user's data in the
+// background. The user is mailed once it can be downloaded.
+func (s *privacyService) RequestExport(ctx context.Context, userID string) dto.ResponseDto {
+	db := dbmanager.Primary(ctx)
+
+	var inProgress int64
+	if err := db.Model(&entity.DataRequest{}).
+		Where("user_id = ? AND kind = ? AND status IN ? AND updated_at > ?", userID, entity.DataRequestExport,
+			[]string{entity.DataRequestPending, entity.DataRequestRunning}, time.Now().UTC().Add(-exportStaleAfter)).
+		Count(&inProgress).Error; err != nil {
+		logger.Error("Error checking running exports: %v", err)
+		return *dto.Fail("Error requesting data export")
+	}
+	if inProgress > 0 {
+		return *dto.FailWith(apperror.Conflict("export_in_progress", "A data export is already being prepared"))
+	}
+
+	req := entity.DataRequest{
+		ID:     tools.NewUuid(),
+		UserID: userID,
+		Kind:   entity.DataRequestExport,
+		Status: entity.DataRequestPending,
+	}
+	if err := db.Create(&req).Error; err != nil {
+		logger.Error("Error creating data export request: %v", err)
+		return *dto.Fail("Error requesting data export")
+	}
+
+	go s.runExport(context.WithoutCancel(ctx), req)
+
+	return *dto.SuccessMessage("Your data export is being prepared, you will be notified by email",
+		dto.GetDataRequestResponse(req))
+}
+
+// GetMyDataRequests returns the user's data requests, newest first.
+func (s *privacyService) GetMyDataRequests(ctx context.Context, userID string) dto.ResponseDto {
+	var reqs []entity.DataRequest
+	if err := dbmanager.Primary(ctx).Where("user_id = ?", userID).Order("created_at DESC").Limit(50).
+		Find(&reqs).Error; err != nil {
+		logger.Error("Error fetching data requests: %v", err)
+		return *dto.Fail("Error fetching data requests")
+	}
+
+	result := make([]dto.DataRequestResponse, len(reqs))
+	for i, req := range reqs {
+		result[i] = dto.GetDataRequestResponse(req)
+	}
+	return *dto.Success(result)
+}
+
+// GetExportDownload returns a short-lived link to the archive of one of the
+// user's ready data exports.
+func (s *privacyService) GetExportDownload(ctx context.Context, userID, requestID string) dto.ResponseDto {
+	var req entity.DataRequest
+	if err := dbmanager.Primary(ctx).Where("id = ? AND user_id = ? AND kind = ?", requestID, userID, entity.DataRequestExport).
+		First(&req).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return *dto.FailWith(apperror.NotFound("export_not_found", "Data export not found"))
+		}
+		logger.Error("Error fetching data export: %v", err)
+		return *dto.Fail("Error fetching data export")
+	}
+	if req.Status != entity.DataRequestReady || req.ExpiresAt == nil || !time.Now().Before(*req.ExpiresAt) {
+		return *dto.FailWith(apperror.Conflict("export_not_ready",
+			"Data export is "+req.Status+", not ready for download").WithParams(req.Status))
+	}
+
+	link, err := storagemanager.SignedURL(ctx, req.FileKey, downloadLinkTTL)
+	if err != nil {
+		logger.Error("Error signing data export link: %v", err)
+		return *dto.Fail("Error fetching data export")
+	}
+	return *dto.Success(dto.DownloadResponse{URL: link, ExpiresAt: time.Now().UTC().Add(downloadLinkTTL)})
+}
+
+// RequestErasure asks for the user's account to be erased. Once an admin has
+// approved, it is carried out at the end of the grace period; the user can
+// cancel until then.
+func (s *privacyService) RequestErasure(ctx context.Context, userID, password string) dto.ResponseDto {
+	db := dbmanager.Primary(ctx)
+
+	var user entity.User
+	if err := db.First(&user, "id = ?", userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return *dto.FailWith(apperror.NotFound("user_not_found", "User not found"))
+		}
+		logger.Error("Error fetching user for erasure request: %v", err)
+		return *dto.Fail("Error requesting account erasure")
+	}
+	if bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)) != nil {
+		return *dto.FailWith(apperror.Forbidden("incorrect_password", "Password is incorrect"))
+	}
+
+	scheduled := time.Now().UTC().Add(config.Get().Privacy.ErasureGrace)
+	req := entity.DataRequest{
+		ID:           tools.NewUuid(),
+		UserID:       userID,
+		Kind:         entity.DataRequestErasure,
+		Status:       entity.DataRequestPending,
+		ScheduledFor: &scheduled,
+	}
+
+	var res dto.ResponseDto
+	err := dbmanager.WithTx(ctx, func(ctx context.Context) error {
+		tx := dbmanager.DB(ctx)
+
+		var open int64
+		if err := tx.Model(&entity.DataRequest{}).
+			Where("user_id = ? AND kind = ? AND status IN ?", userID, entity.DataRequestErasure,
+				[]string{entity.DataRequestPending, entity.DataRequestApproved}).
+			Count(&open).Error; err != nil {
+			logger.Error("Error checking open erasure requests: %v", err)
+			res = *dto.Fail("Error requesting account erasure")
+			return err
+		}
+		if open > 0 {
+			res = *dto.FailWith(apperror.Conflict("erasure_requested", "Account erasure has already been requested"))
+			return errRollback
+		}
+
+		if err := tx.Create(&req).Error; err != nil {
+			logger.Error("Error creating erasure request: %v", err)
+			res = *dto.Fail("Error requesting account erasure")
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		if res.Code == 0 {
+			// Failed while committing
+			logger.Error("Error committing transaction: %v", err)
+			return *dto.Fail("Error requesting account erasure")
+		}
+		return res
+	}
+
+	mailmanager.SendAsync(mailmanager.Message{
+		To:      user.Email,
+		Subject: "Your account erasure request",
+		Body: fmt.Sprintf("Hi %s,\n\nWe received your request to erase your account. Once reviewed, your personal data "+
+			"will be erased on %s.\nUntil then you can cancel the request from your account settings.\n",
+			user.Username, scheduled.Format("2 January 2006")),
+	})
+	return *dto.SuccessMessage("Account erasure requested", dto.GetDataRequestResponse(req))
+}
+
+// CancelErasure withdraws the user's open erasure request.
+func (s *privacyService) CancelErasure(ctx context.Context, userID string) dto.ResponseDto {
+	db := dbmanager.Primary(ctx)
+
+	var req entity.DataRequest
+	if err := db.Where("user_id = ? AND kind = ? AND status IN ?", userID, entity.DataRequestErasure,
+		[]string{entity.DataRequestPending, entity.DataRequestApproved}).First(&req).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return *dto.FailWith(apperror.NotFound("no_open_erasure", "No open erasure request"))
+		}
+		logger.Error("Error fetching erasure request: %v", err)
+		return *dto.Fail("Error cancelling erasure request")
+	}
+
+	if res, ok := transitionRequest(db, &req, map[string]interface{}{"status": entity.DataRequestCancelled}); !ok {
+		return res
+	}
+	return *dto.SuccessMessage("Erasure request cancelled", dto.GetDataRequestResponse(req))
+}
+
+// GetDataRequests returns a page of all users' data requests for admins.
+func (s *privacyService) GetDataRequests(ctx context.Context, spec queryspec.Spec) dto.ResponseDto {
+	if spec.Cursor {
+		return queryspec.ListCursor(dbmanager.DB(ctx), spec, DataRequestQuery, dto.GetDataRequestResponse)
+	}
+	return queryspec.List(dbmanager.DB(ctx), spec, DataRequestQuery, dto.GetDataRequestResponse)
+}
+
+// ReviewErasure approves or rejects a pending erasure request. An approved
+// erasure is carried out by RunScheduled once its grace period has ended.
+func (s *privacyService) ReviewErasure(ctx context.Context, adminID, requestID string, approve bool, note string) dto.ResponseDto {
+	db := dbmanager.Primary(ctx)
+
+	var req entity.DataRequest
+	if err := db.Where("id = ? AND kind = ?", requestID, entity.DataRequestErasure).First(&req).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return *dto.FailWith(apperror.NotFound("erasure_not_found", "Erasure request not found"))
+		}
+		logger.Error("Error fetching erasure request: %v", err)
+		return *dto.Fail("Error reviewing erasure request")
+	}
+	if req.Status != entity.DataRequestPending {
+		return *dto.FailWith(apperror.Conflict("erasure_not_pending",
+			"Erasure request is "+req.Status+", only pending requests can be reviewed").WithParams(req.Status))
+	}
+
+	status := entity.DataRequestRejected
+	if approve {
+		status = entity.DataRequestApproved
+	}
+	now := time.Now().UTC()
+	if res, ok := transitionRequest(db, &req, map[string]interface{}{
+		"status":      status,
+		"note":        note,
+		"reviewed_by": adminID,
+		"reviewed_at": now,
+	}); !ok {
+		return res
+	}
+	logger.Info("erasure request %s for user %s %s by %s", req.ID, req.UserID, status, adminID)
+
+	if !approve {
+		var user entity.User
+		if err := db.First(&user, "id = ?", req.UserID).Error; err == nil {
+			body := fmt.Sprintf("Hi %s,\n\nYour request to erase your account was declined.\n", user.Username)
+			if note != "" {
+				body += "\nReason: " + note + "\n"
+			}
+			mailmanager.SendAsync(mailmanager.Message{To: user.Email, Subject: "Your account erasure request", Body: body})
+		}
+	}
+	return *dto.SuccessMessage("Erasure request "+status, dto.GetDataRequestResponse(req))
+}
+
+// RunScheduled deletes data export archives past their expiry and carries out
+// approved erasures whose grace period has ended. It runs as a cleanup task.
+func (s *privacyService) RunScheduled(ctx context.Context) error {
+	db := dbmanager.DB(ctx)
+	now := time.Now().UTC()
+
+	var expired []entity.DataRequest
+	if err := db.Where("kind = ? AND status = ? AND expires_at <= ?", entity.DataRequestExport, entity.DataRequestReady, now).
+		Find(&expired).Error; err != nil {
+		return fmt.Errorf("fetching expired exports: %w", err)
+	}
+	for _, req := range expired {
+		if err := storagemanager.Delete(ctx, req.FileKey); err != nil {
+			logger.Error("Error deleting data export %s: %v", req.FileKey, err)
+			continue
+		}
+		if err := db.Model(&req).Where("status = ?", entity.DataRequestReady).
+			Updates(map[string]interface{}{"status": entity.DataRequestExpired, "file_key": ""}).Error; err != nil {
+			logger.Error("Error expiring data export %s: %v", req.ID, err)
+		}
+	}
+
+	var due []entity.DataRequest
+	if err := db.Where("kind = ? AND status = ? AND scheduled_for <= ?", entity.DataRequestErasure, entity.DataRequestApproved, now).
+		Find(&due).Error; err != nil {
+		return fmt.Errorf("fetching due erasures: %w", err)
+	}
+	var failed int
+	for _, req := range due {
+		if err := s.runErasure(ctx, req); err != nil {
+			logger.Error("Error erasing user %s: %v", req.UserID, err)
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d erasure(s) failed, they are retried on the next run", failed, len(due))
+	}
+	if len(expired)+len(due) > 0 {
+		logger.Info("privacy: expired %d data export(s), erased %d account(s)", len(expired), len(due))
+	}
+	return nil
+}
+
+// runExport builds the archive of an export request and stores it privately.
+func (s *privacyService) runExport(ctx context.Context, req entity.DataRequest) {
+	db := dbmanager.DB(ctx)
+
+	fail := func(format string, args ...interface{}) {
+		logger.Error(format, args...)
+		if err := db.Model(&req).Update("status", entity.DataRequestFailed).Error; err != nil {
+			logger.Error("Error marking data export %s failed: %v", req.ID, err)
+		}
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			fail("data export %s panicked: %v", req.ID, r)
+		}
+	}()
+
+	if err := db.Model(&req).Update("status", entity.DataRequestRunning).Error; err != nil {
+		logger.Error("Error starting data export %s: %v", req.ID, err)
+		return
+	}
+
+	archive, err := privacy.BuildArchive(ctx, req.UserID)
+	if err != nil {
+		fail("Error building data export %s: %v", req.ID, err)
+		return
+	}
+	key := fmt.Sprintf("%sexports/%s/%s.zip", storagemanager.PrivatePrefix, req.UserID, req.ID)
+	if err := storagemanager.Put(ctx, key, archive, "application/zip"); err != nil {
+		fail("Error storing data export %s: %v", req.ID, err)
+		return
+	}
+
+	now := time.Now().UTC()
+	expires := now.Add(config.Get().Privacy.ExportTTL)
+	if err := db.Model(&req).Updates(map[string]interface{}{
+		"status":       entity.DataRequestReady,
+		"file_key":     key,
+		"completed_at": now,
+		"expires_at":   expires,
+	}).Error; err != nil {
+		deleteFiles([]string{key})
+		fail("Error saving data export %s: %v", req.ID, err)
+		return
+	}
+
+	var user entity.User
+	if err := db.First(&user, "id = ?", req.UserID).Error; err != nil {
+		logger.Error("Error fetching user for data export mail: %v", err)
+		return
+	}
+	mailmanager.SendAsync(mailmanager.Message{
+		To:      user.Email,
+		Subject: "Your data export is ready",
+		Body: fmt.Sprintf("Hi %s,\n\nThe export of your data is ready. Download it from your account settings "+
+			"before %s, after which it is deleted.\n", user.Username, expires.Format("2 January 2006 15:04 MST")),
+	})
+}
+
+// runErasure erases the user of an approved erasure request. The request is
+// claimed first, so concurrent runs on several instances don't repeat it.
+func (s *privacyService) runErasure(ctx context.Context, req entity.DataRequest) error {
+	db := dbmanager.DB(ctx)
+
+	claim := db.Model(&req).Where("status = ?", entity.DataRequestApproved).Update("status", entity.DataRequestRunning)
+	if claim.Error != nil {
+		return claim.Error
+	}
+	if claim.RowsAffected == 0 {
+		return nil
+	}
+
+	if err := privacy.Erase(ctx, req.UserID); err != nil {
+		if rerr := db.Model(&req).Update("status", entity.DataRequestApproved).Error; rerr != nil {
+			logger.Error("Error releasing erasure request %s: %v", req.ID, rerr)
+		}
+		return err
+	}
+
+	if err := db.Model(&req).Updates(map[string]interface{}{
+		"status":       entity.DataRequestCompleted,
+		"completed_at": time.Now().UTC(),
+	}).Error; err != nil {
+		return fmt.Errorf("user erased, but the request could not be completed: %w", err)
+	}
+	logger.Info("user %s erased (request %s)", req.UserID, req.ID)
+	return nil
+}
+
+// transitionRequest applies updates to req unless its status changed since it
+// was read, and reloads it.
+func transitionRequest(db *gorm.DB, req *entity.DataRequest, updates map[string]interface{}) (dto.ResponseDto, bool) {
+	res := db.Model(req).Where("status = ?", req.Status).Updates(updates)
+	if res.Error != nil {
+		logger.Error("Error updating data request: %v", res.Error)
+		return *dto.Fail("Error updating data request"), false
+	}
+	if res.RowsAffected == 0 {
+		return *dto.FailWith(apperror.Conflict("version_conflict", "Request was changed by someone else, reload and try again")), false
+	}
+	if err := db.First(req, "id = ?", req.ID).Error; err != nil {
+		logger.Error("Error reloading data request: %v", err)
+		return *dto.Fail("Error updating data request"), false
+	}
+	return dto.ResponseDto{}, true
+}
+
+func exportProfile(ctx context.Context, userID string) ([]privacy.File, error) {
+	var user entity.User
+	if err := dbmanager.DB(ctx).First(&user, "id = ?", userID).Error; err != nil {
+		return nil, err
+	}
+	f, err := privacy.JSONFile("profile.json", struct {
+		dto.UserExportRow
+		Bio       string    `json:"bio"`
+		UpdatedAt time.Time `json:"updated_at"`
+	}{dto.GetUserExportRow(user), user.Bio, user.UpdatedAt})
+	return []privacy.File{f}, err
+}
+
+func exportSessions(ctx context.Context, userID string) ([]privacy.File, error) {
+	var sessions []entity.UserSession
+	if err := dbmanager.DB(ctx).Where("user_id = ?", userID).Order("created_at").Find(&sessions).Error; err != nil {
+		return nil, err
+	}
+	f, err := privacy.JSONFile("sessions.json", sessions)
+	return []privacy.File{f}, err
+}
+
+// exportHistory includes the changes made to the user's account and the
+// changes the user made to other records.
+func exportHistory(ctx context.Context, userID string) ([]privacy.File, error) {
+	var rows []entity.EntityHistory
+	if err := dbmanager.DB(ctx).Where("(entity = ? AND entity_id = ?) OR actor_id = ?", entity.User{}.HistoryName(), userID, userID).
+		Order("id").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	history := make([]dto.HistoryResponse, len(rows))
+	for i, row := range rows {
+		history[i] = dto.GetHistoryResponse(row)
+	}
+	f, err := privacy.JSONFile("history.json", history)
+	return []privacy.File{f}, err
+}
+
+func exportFiles(ctx context.Context, userID string) ([]privacy.File, error) {
+	var user entity.User
+	if err := dbmanager.DB(ctx).First(&user, "id = ?", userID).Error; err != nil {
+		return nil, err
+	}
+	if user.AvatarKey == "" {
+		return nil, nil
+	}
+	// The largest variant is the closest to the uploaded image, which isn't kept
+	data, err := storagemanager.Get(ctx, user.AvatarVariantKey("large"))
+	if err != nil {
+		return nil, err
+	}
+	return []privacy.File{{Name: "avatar.jpg", Data: data}}, nil
+}
+
+func exportDataRequests(ctx context.Context, userID string) ([]privacy.File, error) {
+	var reqs []entity.DataRequest
+	if err := dbmanager.DB(ctx).Where("user_id = ?", userID).Order("created_at").Find(&reqs).Error; err != nil {
+		return nil, err
+	}
+	result := make([]dto.DataRequestResponse, len(reqs))
+	for i, req := range reqs {
+		result[i] = dto.GetDataRequestResponse(req)
+	}
+	f, err := privacy.JSONFile("requests.json", result)
+	return []privacy.File{f}, err
+}
+
+// eraseFiles deletes the user's avatar and data export archives once the
+// erasure commits.
+func eraseFiles(ctx context.Context, userID string) error {
+	db := dbmanager.DB(ctx)
+
+	var user entity.User
+	if err := db.Unscoped().Select("id", "avatar_key").First(&user, "id = ?", userID).Error; err != nil {
+		return err
+	}
+	keys := avatarKeys(user)
+
+	var exports []string
+	if err := db.Model(&entity.DataRequest{}).Where("user_id = ? AND file_key <> ''", userID).
+		Pluck("file_key", &exports).Error; err != nil {
+		return err
+	}
+	if err := db.Model(&entity.DataRequest{}).Where("user_id = ? AND file_key <> ''", userID).
+		Updates(map[string]interface{}{"file_key": "", "status": entity.DataRequestExpired}).Error; err != nil {
+		return err
+	}
+	keys = append(keys, exports...)
+
+	if len(keys) > 0 {
+		purge.DeleteFilesAfterCommit(ctx, keys)
+	}
+	return nil
+}
+
+// eraseProfile anonymizes the user row in place, so records that must be kept
+// still point at an account, and makes it unusable for login.
+func eraseProfile(ctx context.Context, userID string) error {
+	db := dbmanager.DB(ctx)
+
+	var user entity.User
+	if err := db.Unscoped().First(&user, "id = ?", userID).Error; err != nil {
+		return err
+	}
+
+	placeholder := "deleted-" + strings.ReplaceAll(userID, "-", "")
+	email := placeholder + "@deleted.invalid"
+	bidx, err := cryptomanager.BlindIndex(email)
+	if err != nil {
+		return err
+	}
+	// Map updates bypass the encrypted serializer, so encrypt explicitly
+	encryptedEmail, err := cryptomanager.EncryptColumn(user.TableName(), "email", email)
+	if err != nil {
+		return err
+	}
+	encryptedName, err := cryptomanager.EncryptColumn(user.TableName(), "full_name", erasedFullName)
+	if err != nil {
+		return err
+	}
+
+	return dbmanager.UpdateVersioned(db.Unscoped(), &user, user.Version, map[string]interface{}{
+		"username":   truncate(placeholder, 50),
+		"email":      encryptedEmail,
+		"email_bidx": bidx,
+		"full_name":  encryptedName,
+		// No bcrypt hash matches an empty one, so the account can't be logged into
+		"password":   "",
+		"locale":     "",
+		"timezone":   "",
+		"bio":        "",
+		"avatar_key": "",
+		"is_active":  false,
+		"is_admin":   false,
+		"last_login": nil,
+	})
+}
+
+// eraseHistory deletes the change history of the user's account, which holds
+// its previous personal data (including the anonymization just recorded).
+// Changes the user made to other records keep their actor ID.
+func eraseHistory(ctx context.Context, userID string) error {
+	return dbmanager.DB(ctx).Where("entity = ? AND entity_id = ?", entity.User{}.HistoryName(), userID).
+		Delete(&entity.EntityHistory{}).Error
+}
+
+// eraseEvents deletes the user's outbox events, whose payloads carry personal data.
+func eraseEvents(ctx context.Context, userID string) error {
+	return dbmanager.DB(ctx).Where("aggregate_type = ? AND aggregate_id = ?", "user", userID).
+		Delete(&entity.OutboxMessage{}).Error
+}
+
+// eraseAuth deletes the user's sessions and tokens, signing them out everywhere.
+func eraseAuth(ctx context.Context, userID string) error {
+	db := dbmanager.DB(ctx)
+
+	var sessions []string
+	if err := db.Model(&entity.UserSession{}).Where("user_id = ?", userID).Pluck("id", &sessions).Error; err != nil {
+		return err
+	}
+	if err := db.Where("user_id = ?", userID).Delete(&entity.UserSession{}).Error; err != nil {
+		return err
+	}
+	if err := db.Where("user_id = ?", userID).Delete(&entity.UserToken{}).Error; err != nil {
+		return err
+	}
+
+	dbmanager.AfterCommit(ctx, func() {
+		for _, id := range sessions {
+			config.InvalidateRefreshToken(userID, id)
+		}
+	})
+	return nil
+}
+
+// purgeDataRequests deletes the data requests and export archives of purged users.
+func purgeDataRequests(ctx context.Context, ids []string) error {
+	db := dbmanager.DB(ctx)
+
+	var keys []string
+	if err := db.Model(&entity.DataRequest{}).Where("user_id IN ? AND file_key <> ''", ids).
+		Pluck("file_key", &keys).Error; err != nil {
+		return err
+	}
+	if err := db.Where("user_id IN ?", ids).Delete(&entity.DataRequest{}).Error; err != nil {
+		return err
+	}
+	if len(keys) > 0 {
+		purge.DeleteFilesAfterCommit(ctx, keys)
+	}
+	return nil
+}
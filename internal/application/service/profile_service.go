@@ -0,0 +1,265 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif" // register decoders for accepted avatar formats
+	"image/jpeg"
+	_ "image/png"
+	"net/http"
+	"strconv"
+
+	"gorm.io/gorm"
+
+	"boilerplate-golang/internal/application/apperror"
+	"boilerplate-golang/internal/application/dto"
+	"boilerplate-golang/internal/application/entity"
+	"boilerplate-golang/internal/application/purge"
+	"boilerplate-golang/internal/application/tools"
+	"boilerplate-golang/internal/infrastructure/cryptomanager"
+	"boilerplate-golang/internal/infrastructure/dbmanager"
+	"boilerplate-golang/internal/infrastructure/logger"
+	"boilerplate-golang/internal/infrastructure/storagemanager"
+)
+
+// maxAvatarPixels bounds the decoded size of an avatar upload, so a small file
+// can't expand into a huge bitmap.
+const maxAvatarPixels = 4096 * 4096
+
+// avatarTypes are the accepted avatar content types, detected from the bytes.
+var avatarTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/gif":  true,
+}
+
+func init() {
+	purge.OnPurge("users", purgeAvatars)
+}
+
+// UpdateProfile updates the profile fields of a user. Nil fields are left
+// unchanged; version works as in UpdateUser.
+func (s *userService) UpdateProfile(ctx context.Context, id string, version int64, fullName, locale, timezone, bio *string) dto.ResponseDto {
+	// Read from the primary so the update is based on the latest row
+	db := dbmanager.Primary(ctx)
+
+	var user entity.User
+	if err := db.First(&user, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return *dto.FailWith(apperror.NotFound("user_not_found", "User not found"))
+		}
+		logger.Error("Error fetching user for profile update: %v", err)
+		return *dto.Fail("Error updating profile")
+	}
+	if version == 0 {
+		version = user.Version
+	}
+
+	// Map updates bypass the encrypted serializer, so encrypt explicitly
+	updates := map[string]interface{}{}
+	if fullName != nil {
+		encrypted, err := cryptomanager.EncryptColumn(user.TableName(), "full_name", *fullName)
+		if err != nil {
+			logger.Error("Error encrypting full name: %v", err)
+			return *dto.Fail("Error updating profile")
+		}
+		updates["full_name"] = encrypted
+	}
+	if locale != nil {
+		updates["locale"] = *locale
+	}
+	if timezone != nil {
+		updates["timezone"] = *timezone
+	}
+	if bio != nil {
+		updates["bio"] = *bio
+	}
+	if len(updates) == 0 {
+		return *dto.Success(dto.GetUserResponse(user))
+	}
+
+	if err := dbmanager.UpdateVersioned(db, &user, version, updates); err != nil {
+		if errors.Is(err, dbmanager.ErrVersionConflict) {
+			return *dto.FailWith(apperror.Conflict("version_conflict", "User was modified by someone else, reload and try again"))
+		}
+		logger.Error("Error updating profile: %v", err)
+		return *dto.Fail("Error updating profile")
+	}
+
+	if err := db.First(&user, "id = ?", id).Error; err != nil {
+		logger.Error("Error reloading updated user: %v", err)
+		return *dto.Fail("Error updating profile")
+	}
+	return *dto.SuccessMessageKey("profile_updated", "Profile updated successfully", dto.GetUserResponse(user))
+}
+
+// PreferredLocale returns the language the user chose in their profile, ""
+// when they chose none.
+func (s *userService) PreferredLocale(ctx context.Context, id string) string {
+	var locales []string
+	if err := dbmanager.DB(ctx).Model(&entity.User{}).Where("id = ?", id).Limit(1).
+		Pluck("locale", &locales).Error; err != nil {
+		logger.Error("Error fetching user locale: %v", err)
+		return ""
+	}
+	if len(locales) == 0 {
+		return ""
+	}
+	return locales[0]
+}
+
+// SetAvatar validates an uploaded image, stores it in every avatar variant and
+// points the user at it, deleting the previous avatar once saved. Variants
+// are keyed by a hash of the upload, so their URLs are stable until it changes.
+func (s *userService) SetAvatar(ctx context.Context, id string, data []byte) dto.ResponseDto {
+	contentType := http.DetectContentType(data)
+	if !avatarTypes[contentType] {
+		return *dto.FailWith(apperror.BadRequest("avatar_unsupported", "Avatar must be a JPEG, PNG or GIF image"))
+	}
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return *dto.FailWith(apperror.BadRequest("avatar_unreadable", "Avatar image could not be read"))
+	}
+	if cfg.Width*cfg.Height > maxAvatarPixels {
+		return *dto.FailWith(apperror.BadRequest("avatar_too_large", fmt.Sprintf("Avatar must be at most %d pixels", maxAvatarPixels)).
+			WithParams(strconv.Itoa(maxAvatarPixels)))
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return *dto.FailWith(apperror.BadRequest("avatar_unreadable", "Avatar image could not be read"))
+	}
+
+	db := dbmanager.Primary(ctx)
+
+	var user entity.User
+	if err := db.First(&user, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return *dto.FailWith(apperror.NotFound("user_not_found", "User not found"))
+		}
+		logger.Error("Error fetching user for avatar upload: %v", err)
+		return *dto.Fail("Error saving avatar")
+	}
+
+	sum := sha256.Sum256(data)
+	previous := user
+	user.AvatarKey = fmt.Sprintf("avatars/%s/%s", user.ID, hex.EncodeToString(sum[:8]))
+	if user.AvatarKey == previous.AvatarKey {
+		return *dto.Success(dto.GetUserResponse(user))
+	}
+
+	// Upload before committing; if the update fails the files are removed again
+	square := tools.FlattenSquare(img)
+	var stored []string
+	for variant, size := range entity.AvatarVariants {
+		var buf bytes.Buffer
+		if err := jpeg.Encode(&buf, tools.ResizeSquare(square, size), &jpeg.Options{Quality: 85}); err != nil {
+			logger.Error("Error encoding avatar: %v", err)
+			deleteFiles(stored)
+			return *dto.Fail("Error saving avatar")
+		}
+		key := user.AvatarVariantKey(variant)
+		if err := storagemanager.Put(ctx, key, buf.Bytes(), "image/jpeg"); err != nil {
+			logger.Error("Error storing avatar: %v", err)
+			deleteFiles(stored)
+			return *dto.Fail("Error saving avatar")
+		}
+		stored = append(stored, key)
+	}
+
+	if err := dbmanager.UpdateVersioned(db, &user, user.Version, map[string]interface{}{
+		"avatar_key": user.AvatarKey,
+	}); err != nil {
+		deleteFiles(stored)
+		if errors.Is(err, dbmanager.ErrVersionConflict) {
+			return *dto.FailWith(apperror.Conflict("version_conflict", "User was modified by someone else, try again"))
+		}
+		logger.Error("Error saving avatar: %v", err)
+		return *dto.Fail("Error saving avatar")
+	}
+	deleteFiles(avatarKeys(previous))
+
+	if err := db.First(&user, "id = ?", id).Error; err != nil {
+		logger.Error("Error reloading updated user: %v", err)
+		return *dto.Fail("Error saving avatar")
+	}
+	return *dto.SuccessMessageKey("avatar_updated", "Avatar updated successfully", dto.GetUserResponse(user))
+}
+
+// RemoveAvatar deletes the user's avatar.
+func (s *userService) RemoveAvatar(ctx context.Context, id string) dto.ResponseDto {
+	db := dbmanager.Primary(ctx)
+
+	var user entity.User
+	if err := db.First(&user, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return *dto.FailWith(apperror.NotFound("user_not_found", "User not found"))
+		}
+		logger.Error("Error fetching user for avatar removal: %v", err)
+		return *dto.Fail("Error removing avatar")
+	}
+	if user.AvatarKey == "" {
+		return *dto.Success(dto.GetUserResponse(user))
+	}
+
+	previous := user
+	if err := dbmanager.UpdateVersioned(db, &user, user.Version, map[string]interface{}{
+		"avatar_key": "",
+	}); err != nil {
+		if errors.Is(err, dbmanager.ErrVersionConflict) {
+			return *dto.FailWith(apperror.Conflict("version_conflict", "User was modified by someone else, try again"))
+		}
+		logger.Error("Error removing avatar: %v", err)
+		return *dto.Fail("Error removing avatar")
+	}
+	deleteFiles(avatarKeys(previous))
+
+	if err := db.First(&user, "id = ?", id).Error; err != nil {
+		logger.Error("Error reloading updated user: %v", err)
+		return *dto.Fail("Error removing avatar")
+	}
+	return *dto.SuccessMessageKey("avatar_removed", "Avatar removed successfully", dto.GetUserResponse(user))
+}
+
+// purgeAvatars deletes the avatar files of purged users once the purge commits.
+func purgeAvatars(ctx context.Context, ids []string) error {
+	var users []entity.User
+	if err := dbmanager.DB(ctx).Unscoped().Select("id", "avatar_key").
+		Where("id IN ? AND avatar_key <> ''", ids).Find(&users).Error; err != nil {
+		return err
+	}
+	var keys []string
+	for _, u := range users {
+		keys = append(keys, avatarKeys(u)...)
+	}
+	if len(keys) > 0 {
+		purge.DeleteFilesAfterCommit(ctx, keys)
+	}
+	return nil
+}
+
+// avatarKeys returns the storage keys of all variants of the user's avatar.
+func avatarKeys(user entity.User) []string {
+	if user.AvatarKey == "" {
+		return nil
+	}
+	keys := make([]string, 0, len(entity.AvatarVariants))
+	for variant := range entity.AvatarVariants {
+		keys = append(keys, user.AvatarVariantKey(variant))
+	}
+	return keys
+}
+
+// deleteFiles removes stored files, logging failures; they are orphaned
+// rather than failing a request whose data is already consistent.
+func deleteFiles(keys []string) {
+	for _, key := range keys {
+		if err := storagemanager.Delete(context.Background(), key); err != nil {
+			logger.Error("Error deleting file %s: %v", key, err)
+		}
+	}
+}
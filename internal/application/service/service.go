@@ -1,6 +1,16 @@
 package service
 
+import "errors"
+
 var (
 	IUserService = &userService{}
+	IHistoryService = &historyService{}
+	IAuthService = &authService{}
+	IPrivacyService = &privacyService{}
+	IInvitationService = &invitationService{}
+	IAuditService = &auditService{}
+
+)
 
-)
\ No newline at end of file
+// errRollback aborts a transaction whose failure response has already been set.
+var errRollback = errors.New("rollback")
\ No newline at end of file
@@ -0,0 +1,288 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+
+	"boilerplate-golang/internal/application/apperror"
+	"boilerplate-golang/internal/application/audit"
+	"boilerplate-golang/internal/application/dto"
+	"boilerplate-golang/internal/application/entity"
+	"boilerplate-golang/internal/application/queryspec"
+	"boilerplate-golang/internal/infrastructure/config"
+	"boilerplate-golang/internal/infrastructure/dbmanager"
+	"boilerplate-golang/internal/infrastructure/logger"
+)
+
+// adminAction is an admin operation on a user account, applied by
+// runAdminAction in one transaction with its audit log entry.
+type adminAction struct {
+	// name is the audit log action
+	name string
+	// check rejects the action for the user, e.g. when it changes nothing
+	check func(tx *gorm.DB, user entity.User) (dto.ResponseDto, bool)
+	// updates are applied to the user row
+	updates map[string]interface{}
+	// revoke signs the user out of every session
+	revoke bool
+	// apply does further work in the transaction
+	apply func(ctx context.Context, user entity.User) error
+	// key translates message, see package i18n
+	key     string
+	message string
+}
+
+// SearchUsers returns a page of users matching the query spec, with their
+// account status and last login.
+func (s *userService) SearchUsers(ctx context.Context, spec queryspec.Spec) dto.ResponseDto {
+	if spec.Cursor {
+		return queryspec.ListCursor(dbmanager.DB(ctx), spec, UserQuery, dto.GetAdminUserResponse)
+	}
+	return queryspec.List(dbmanager.DB(ctx), spec, UserQuery, dto.GetAdminUserResponse)
+}
+
+// GetUserDetail returns a user with their active login sessions.
+func (s *userService) GetUserDetail(ctx context.Context, id string) dto.ResponseDto {
+	db := dbmanager.DB(ctx)
+
+	var user entity.User
+	if err := db.First(&user, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return *dto.FailWith(apperror.NotFound("user_not_found", "User not found"))
+		}
+		logger.Error("Error fetching user detail: %v", err)
+		return *dto.Fail("Error fetching user")
+	}
+
+	var sessions []entity.UserSession
+	if err := db.Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", id, time.Now().UTC()).
+		Order("created_at DESC").Find(&sessions).Error; err != nil {
+		logger.Error("Error fetching user sessions: %v", err)
+		return *dto.Fail("Error fetching user")
+	}
+
+	detail := dto.AdminUserDetailResponse{
+		AdminUserResponse: dto.GetAdminUserResponse(user),
+		Sessions:          make([]dto.SessionResponse, len(sessions)),
+	}
+	for i, session := range sessions {
+		detail.Sessions[i] = dto.GetSessionResponse(session)
+	}
+	return *dto.Success(detail)
+}
+
+// SuspendUser deactivates the account and signs it out everywhere; it can't
+// log in until reactivated.
+func (s *userService) SuspendUser(ctx context.Context, actorID, id, reason string) dto.ResponseDto {
+	return s.runAdminAction(ctx, id, reason, adminAction{
+		name: "user.suspend",
+		check: func(tx *gorm.DB, user entity.User) (dto.ResponseDto, bool) {
+			if id == actorID {
+				return *dto.FailWith(apperror.BadRequest("cannot_suspend_self", "You can't suspend your own account")), false
+			}
+			if !user.IsActive {
+				return *dto.FailWith(apperror.Conflict("user_already_suspended", "User is already suspended")), false
+			}
+			return keepsAnAdmin(tx, user)
+		},
+		updates: map[string]interface{}{"is_active": false},
+		revoke:  true,
+		key:     "user_suspended",
+		message: "User suspended",
+	})
+}
+
+// ReactivateUser lets a suspended account log in again.
+func (s *userService) ReactivateUser(ctx context.Context, id, reason string) dto.ResponseDto {
+	return s.runAdminAction(ctx, id, reason, adminAction{
+		name: "user.reactivate",
+		check: func(tx *gorm.DB, user entity.User) (dto.ResponseDto, bool) {
+			if user.IsActive {
+				return *dto.FailWith(apperror.Conflict("user_already_active", "User is already active")), false
+			}
+			return dto.ResponseDto{}, true
+		},
+		updates: map[string]interface{}{"is_active": true},
+		key:     "user_reactivated",
+		message: "User reactivated",
+	})
+}
+
+// PromoteUser grants admin rights, which apply from the user's next login.
+func (s *userService) PromoteUser(ctx context.Context, id, reason string) dto.ResponseDto {
+	return s.runAdminAction(ctx, id, reason, adminAction{
+		name: "user.promote",
+		check: func(tx *gorm.DB, user entity.User) (dto.ResponseDto, bool) {
+			if user.IsAdmin {
+				return *dto.FailWith(apperror.Conflict("user_already_admin", "User is already an admin")), false
+			}
+			return dto.ResponseDto{}, true
+		},
+		updates: map[string]interface{}{"is_admin": true},
+		key:     "user_promoted",
+		message: "User promoted to admin, effective from their next login",
+	})
+}
+
+// DemoteUser withdraws admin rights. The user is signed out everywhere, since
+// their tokens still carry the admin role.
+func (s *userService) DemoteUser(ctx context.Context, actorID, id, reason string) dto.ResponseDto {
+	return s.runAdminAction(ctx, id, reason, adminAction{
+		name: "user.demote",
+		check: func(tx *gorm.DB, user entity.User) (dto.ResponseDto, bool) {
+			if id == actorID {
+				return *dto.FailWith(apperror.BadRequest("cannot_demote_self", "You can't demote your own account")), false
+			}
+			if !user.IsAdmin {
+				return *dto.FailWith(apperror.Conflict("user_not_admin", "User is not an admin")), false
+			}
+			return keepsAnAdmin(tx, user)
+		},
+		updates: map[string]interface{}{"is_admin": false},
+		revoke:  true,
+		key:     "user_demoted",
+		message: "User demoted",
+	})
+}
+
+// ForceLogout revokes every session of the user.
+func (s *userService) ForceLogout(ctx context.Context, id, reason string) dto.ResponseDto {
+	return s.runAdminAction(ctx, id, reason, adminAction{
+		name:    "user.force_logout",
+		revoke:  true,
+		key:     "user_logged_out",
+		message: "User signed out of all sessions",
+	})
+}
+
+// TriggerPasswordReset mails the user a password reset link, as if they had
+// used forgot password.
+func (s *userService) TriggerPasswordReset(ctx context.Context, id, reason string) dto.ResponseDto {
+	return s.runAdminAction(ctx, id, reason, adminAction{
+		name: "user.password_reset",
+		check: func(tx *gorm.DB, user entity.User) (dto.ResponseDto, bool) {
+			if !user.IsActive {
+				return *dto.FailWith(apperror.Conflict("user_inactive", "User is suspended")), false
+			}
+			return dto.ResponseDto{}, true
+		},
+		apply:   sendPasswordReset,
+		key:     "password_reset_sent",
+		message: "Password reset link sent",
+	})
+}
+
+// runAdminAction applies action to the user with id and records it in the
+// audit log with reason.
+func (s *userService) runAdminAction(ctx context.Context, id, reason string, action adminAction) dto.ResponseDto {
+	var (
+		res     dto.ResponseDto
+		user    entity.User
+		revoked []string
+	)
+	err := dbmanager.WithTx(ctx, func(ctx context.Context) error {
+		tx := dbmanager.DB(ctx)
+
+		if err := tx.First(&user, "id = ?", id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				res = *dto.FailWith(apperror.NotFound("user_not_found", "User not found"))
+				return errRollback
+			}
+			logger.Error("Error fetching user for %s: %v", action.name, err)
+			res = *dto.Fail("Error updating user")
+			return err
+		}
+		if action.check != nil {
+			if r, ok := action.check(tx, user); !ok {
+				res = r
+				return errRollback
+			}
+		}
+
+		if len(action.updates) > 0 {
+			if err := dbmanager.UpdateVersioned(tx, &user, user.Version, action.updates); err != nil {
+				if errors.Is(err, dbmanager.ErrVersionConflict) {
+					res = *dto.FailWith(apperror.Conflict("version_conflict", "User was modified by someone else, try again"))
+					return errRollback
+				}
+				logger.Error("Error updating user for %s: %v", action.name, err)
+				res = *dto.Fail("Error updating user")
+				return err
+			}
+			// Unscoped, the action may have deleted the user
+			if err := tx.Unscoped().First(&user, "id = ?", id).Error; err != nil {
+				logger.Error("Error reloading user: %v", err)
+				res = *dto.Fail("Error updating user")
+				return err
+			}
+		}
+		if action.revoke {
+			var err error
+			if revoked, err = revokeSessions(tx, id, ""); err != nil {
+				logger.Error("Error revoking sessions: %v", err)
+				res = *dto.Fail("Error updating user")
+				return err
+			}
+		}
+		if action.apply != nil {
+			if err := action.apply(ctx, user); err != nil {
+				logger.Error("Error applying %s: %v", action.name, err)
+				res = *dto.Fail("Error updating user")
+				return err
+			}
+		}
+
+		details := map[string]interface{}{}
+		if reason != "" {
+			details["reason"] = reason
+		}
+		if action.revoke {
+			details["revoked_sessions"] = len(revoked)
+		}
+		var payload interface{}
+		if len(details) > 0 {
+			payload = details
+		}
+		if err := audit.Record(ctx, action.name, "user", id, payload); err != nil {
+			logger.Error("Error recording audit log: %v", err)
+			res = *dto.Fail("Error updating user")
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		if res.Code == 0 {
+			// Failed while committing
+			logger.Error("Error committing transaction: %v", err)
+			return *dto.Fail("Error updating user")
+		}
+		return res
+	}
+
+	for _, sessionID := range revoked {
+		config.InvalidateRefreshToken(id, sessionID)
+	}
+	logger.Info("%s of user %s by %s", action.name, id, logger.UserID(ctx))
+	return *dto.SuccessMessageKey(action.key, action.message, dto.GetAdminUserResponse(user))
+}
+
+// keepsAnAdmin rejects taking admin rights or access from the last active
+// admin, which would leave nobody able to manage users.
+func keepsAnAdmin(tx *gorm.DB, user entity.User) (dto.ResponseDto, bool) {
+	if !user.IsAdmin || !user.IsActive {
+		return dto.ResponseDto{}, true
+	}
+	var others int64
+	if err := tx.Model(&entity.User{}).Where("is_admin = ? AND is_active = ? AND id <> ?", true, true, user.ID).
+		Count(&others).Error; err != nil {
+		logger.Error("Error counting admins: %v", err)
+		return *dto.Fail("Error updating user"), false
+	}
+	if others == 0 {
+		return *dto.FailWith(apperror.Conflict("last_admin", "User is the last active admin")), false
+	}
+	return dto.ResponseDto{}, true
+}
@@ -0,0 +1,484 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"boilerplate-golang/internal/application/apperror"
+	"boilerplate-golang/internal/application/dto"
+	"boilerplate-golang/internal/application/entity"
+	"boilerplate-golang/internal/application/queryspec"
+	"boilerplate-golang/internal/application/sheet"
+	"boilerplate-golang/internal/application/tools"
+	"boilerplate-golang/internal/infrastructure/cryptomanager"
+	"boilerplate-golang/internal/infrastructure/dbmanager"
+	"boilerplate-golang/internal/infrastructure/logger"
+	"boilerplate-golang/internal/infrastructure/mailmanager"
+)
+
+const (
+	// maxImportRows bounds the data rows of one import file.
+	maxImportRows = 10000
+	// maxExportRows bounds one export; narrower filters are needed beyond it.
+	maxExportRows = 50000
+	// importProgressEvery is how many rows are processed between progress saves.
+	importProgressEvery = 25
+	// importStaleAfter marks a job without progress for this long as failed,
+	// e.g. because the instance running it was restarted.
+	importStaleAfter = 5 * time.Minute
+	// inviteTTL is how long the set-password link of an invite email stays valid.
+	inviteTTL = 7 * 24 * time.Hour
+)
+
+// importFields are the user fields read from an import file, all required.
+// Each lists the column names it is matched to when no mapping is given,
+// compared without case, spaces, dashes or underscores.
+var importFields = map[string][]string{
+	"username":  {"username", "login", "user"},
+	"email":     {"email", "emailaddress", "mail"},
+	"full_name": {"fullname", "name", "displayname"},
+}
+
+// importRow is a validated row of an import file.
+type importRow struct {
+	Row      int
+	Username string
+	Email    string
+	FullName string
+}
+
+// PreviewImport validates an import file without creating any user and reports
+// the rows that would be rejected. mapping maps user fields to file columns and
+// may be partial; unmapped fields are matched by column name.
+func (s *userService) PreviewImport(ctx context.Context, filename string, data []byte, mapping map[string]string) dto.ResponseDto {
+	report, _, res := validateImport(ctx, filename, data, mapping)
+	if res != nil {
+		return *res
+	}
+	return *dto.Success(report)
+}
+
+// StartImport validates an import file and creates its valid rows as users in
+// the background, returning the job to poll for progress. Invalid rows are
+// skipped and reported on the job. With sendInvites, every created user is
+// mailed a link to set their password.
+func (s *userService) StartImport(ctx context.Context, adminID, filename string, data []byte, mapping map[string]string, sendInvites bool) dto.ResponseDto {
+	report, rows, res := validateImport(ctx, filename, data, mapping)
+	if res != nil {
+		return *res
+	}
+
+	job := entity.UserImportJob{
+		ID:          tools.NewUuid(),
+		CreatedBy:   adminID,
+		Filename:    truncate(filename, 255),
+		Status:      entity.ImportPending,
+		SendInvites: sendInvites,
+		Total:       report.Total,
+		Processed:   report.Invalid,
+		Skipped:     report.Invalid,
+		Errors:      encodeImportErrors(report.Errors),
+	}
+	if err := dbmanager.DB(ctx).Create(&job).Error; err != nil {
+		logger.Error("Error creating import job: %v", err)
+		return *dto.Fail("Error starting import")
+	}
+
+	// Keep the request values (user and request ID for the change history),
+	// but not its cancellation
+	go s.runImport(context.WithoutCancel(ctx), job, rows, report.Errors)
+
+	return *dto.SuccessMessageKey("import_started", "Import started", dto.GetImportJobResponse(job))
+}
+
+// GetImportJob returns the progress of an import job.
+func (s *userService) GetImportJob(ctx context.Context, id string) dto.ResponseDto {
+	db := dbmanager.Primary(ctx)
+
+	var job entity.UserImportJob
+	if err := db.First(&job, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return *dto.FailWith(apperror.NotFound("import_job_not_found", "Import job not found"))
+		}
+		logger.Error("Error fetching import job: %v", err)
+		return *dto.Fail("Error fetching import job")
+	}
+
+	if (job.Status == entity.ImportPending || job.Status == entity.ImportRunning) &&
+		time.Since(job.UpdatedAt) > importStaleAfter {
+		now := time.Now().UTC()
+		if err := db.Model(&job).Where("status = ?", job.Status).Updates(map[string]interface{}{
+			"status":      entity.ImportFailed,
+			"finished_at": now,
+		}).Error; err != nil {
+			logger.Error("Error failing stale import job: %v", err)
+		} else {
+			logger.Warn("import job %s made no progress for %s, marked failed", job.ID, importStaleAfter)
+			job.Status = entity.ImportFailed
+			job.FinishedAt = &now
+		}
+	}
+
+	return *dto.Success(dto.GetImportJobResponse(job))
+}
+
+// ExportUsers returns every user matching the filters and sort of spec, for
+// CSV, XLSX or JSON export. Pagination is ignored.
+func (s *userService) ExportUsers(ctx context.Context, spec queryspec.Spec) dto.ResponseDto {
+	query := spec.Where(dbmanager.DB(ctx).Model(&entity.User{}), UserQuery).Session(&gorm.Session{})
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		logger.Error("Error counting users for export: %v", err)
+		return *dto.Fail("Error exporting users")
+	}
+	if total > maxExportRows {
+		return *dto.FailWith(apperror.BadRequest("export_too_many_rows", fmt.Sprintf("Export is limited to %d users, narrow the filters", maxExportRows)).
+			WithParams(strconv.Itoa(maxExportRows)))
+	}
+
+	var users []entity.User
+	if err := spec.Order(query, UserQuery).Find(&users).Error; err != nil {
+		logger.Error("Error fetching users for export: %v", err)
+		return *dto.Fail("Error exporting users")
+	}
+
+	rows := make([]dto.UserExportRow, len(users))
+	for i, user := range users {
+		rows[i] = dto.GetUserExportRow(user)
+	}
+	return *dto.SuccessCount(rows, total)
+}
+
+// runImport creates the users of an import job, saving progress as it goes.
+func (s *userService) runImport(ctx context.Context, job entity.UserImportJob, rows []importRow, rowErrors []dto.ImportRowError) {
+	db := dbmanager.DB(ctx)
+
+	defer func() {
+		if r := recover(); r != nil {
+			logger.Error("import job %s panicked: %v", job.ID, r)
+			job.Status = entity.ImportFailed
+			saveImportJob(db, &job, rowErrors)
+		}
+	}()
+
+	now := time.Now().UTC()
+	job.Status = entity.ImportRunning
+	job.StartedAt = &now
+	saveImportJob(db, &job, rowErrors)
+
+	for i, row := range rows {
+		// Users set their own password through the invite or a password reset
+		password, _, err := newTokenSecret()
+		if err != nil {
+			logger.Error("Error generating password for import: %v", err)
+			job.Status = entity.ImportFailed
+			saveImportJob(db, &job, rowErrors)
+			return
+		}
+
+		res := s.CreateUser(ctx, row.Username, row.Email, password, row.FullName)
+		if res.Code != 0 {
+			job.Failed++
+			rowErrors = append(rowErrors, dto.ImportRowError{Row: row.Row, Message: res.Msg})
+		} else {
+			job.Created++
+			if job.SendInvites {
+				if err := sendInvite(ctx, res.Data.(dto.UserResponse)); err != nil {
+					logger.Error("Error sending invite for import job %s: %v", job.ID, err)
+					rowErrors = append(rowErrors, dto.ImportRowError{Row: row.Row, Message: "User created, but the invite could not be sent"})
+				}
+			}
+		}
+		job.Processed++
+
+		if (i+1)%importProgressEvery == 0 {
+			saveImportJob(db, &job, rowErrors)
+		}
+	}
+
+	job.Status = entity.ImportCompleted
+	saveImportJob(db, &job, rowErrors)
+	logger.Info("import job %s finished: %d created, %d skipped, %d failed", job.ID, job.Created, job.Skipped, job.Failed)
+}
+
+// saveImportJob stores the progress of job, setting FinishedAt once it is done.
+func saveImportJob(db *gorm.DB, job *entity.UserImportJob, rowErrors []dto.ImportRowError) {
+	if job.Status == entity.ImportCompleted || job.Status == entity.ImportFailed {
+		now := time.Now().UTC()
+		job.FinishedAt = &now
+	}
+	sort.SliceStable(rowErrors, func(i, j int) bool { return rowErrors[i].Row < rowErrors[j].Row })
+	job.Errors = encodeImportErrors(rowErrors)
+	if err := db.Save(job).Error; err != nil {
+		logger.Error("Error saving import job %s: %v", job.ID, err)
+	}
+}
+
+// sendInvite mails a newly imported user a link to set their password.
+func sendInvite(ctx context.Context, user dto.UserResponse) error {
+	return dbmanager.WithTx(ctx, func(ctx context.Context) error {
+		secret, err := issueToken(dbmanager.DB(ctx), user.ID, entity.TokenPasswordReset, "", inviteTTL)
+		if err != nil {
+			return err
+		}
+
+		dbmanager.AfterCommit(ctx, func() {
+			mailmanager.SendAsync(mailmanager.Message{
+				To:      user.Email,
+				Subject: "Your account is ready",
+				Body: fmt.Sprintf("Hi %s,\n\nAn account was created for you with the username %s.\n"+
+					"Open the link below to choose your password:\n\n%s\n\nThe link expires in %s.\n",
+					user.FullName, user.Username, passwordResetLink(secret), inviteTTL),
+			})
+		})
+		return nil
+	})
+}
+
+// validateImport parses an import file and checks every row: required fields,
+// username and email format, duplicates within the file and users that already
+// exist. It returns the report and the valid rows, or a failure response when
+// the file itself can't be used.
+func validateImport(ctx context.Context, filename string, data []byte, mapping map[string]string) (dto.ImportReport, []importRow, *dto.ResponseDto) {
+	format := sheet.FormatOf(filename)
+	if format == "" {
+		return dto.ImportReport{}, nil, dto.FailWith(apperror.BadRequest("import_unsupported", "Import file must be a .csv or .xlsx file"))
+	}
+	header, records, err := sheet.Read(format, data)
+	if err != nil {
+		return dto.ImportReport{}, nil, dto.FailWith(apperror.BadRequest("invalid_import_file", err.Error()))
+	}
+	if len(records) == 0 {
+		return dto.ImportReport{}, nil, dto.FailWith(apperror.BadRequest("import_empty", "Import file has no data rows"))
+	}
+	if len(records) > maxImportRows {
+		return dto.ImportReport{}, nil, dto.FailWith(apperror.BadRequest("import_too_many_rows", fmt.Sprintf("Import file must have at most %d rows", maxImportRows)).
+			WithParams(strconv.Itoa(maxImportRows)))
+	}
+	columns, err := resolveColumns(header, mapping)
+	if err != nil {
+		return dto.ImportReport{}, nil, dto.FailWith(apperror.BadRequest("invalid_import_file", err.Error()))
+	}
+
+	report := dto.ImportReport{Columns: map[string]string{}, Total: len(records), Errors: []dto.ImportRowError{}}
+	for field, i := range columns {
+		report.Columns[field] = header[i]
+	}
+
+	var (
+		rows      = make([]importRow, len(records))
+		rowErrors = make([][]dto.ImportRowError, len(records))
+		bidxs     = map[int]string{}
+		usernames = map[string]int{}
+		emails    = map[string]int{}
+	)
+	for i, record := range records {
+		// Row numbers follow the file, where the header is row 1
+		row := importRow{
+			Row:      i + 2,
+			Username: strings.TrimSpace(record[columns["username"]]),
+			Email:    strings.TrimSpace(record[columns["email"]]),
+			FullName: strings.TrimSpace(record[columns["full_name"]]),
+		}
+		rows[i] = row
+		rowErrors[i] = checkImportRow(row)
+
+		if first, ok := usernames[row.Username]; ok && row.Username != "" {
+			rowErrors[i] = append(rowErrors[i], dto.ImportRowError{Row: row.Row, Field: "username", Message: fmt.Sprintf("Duplicate of row %d", first)})
+		} else if row.Username != "" {
+			usernames[row.Username] = row.Row
+		}
+		if row.Email != "" {
+			bidx, err := cryptomanager.BlindIndex(row.Email)
+			if err != nil {
+				logger.Error("Error computing email blind index: %v", err)
+				return dto.ImportReport{}, nil, dto.Fail("Error validating import file")
+			}
+			if first, ok := emails[bidx]; ok {
+				rowErrors[i] = append(rowErrors[i], dto.ImportRowError{Row: row.Row, Field: "email", Message: fmt.Sprintf("Duplicate of row %d", first)})
+			} else {
+				emails[bidx] = row.Row
+			}
+			bidxs[row.Row] = bidx
+		}
+	}
+
+	takenUsernames, takenEmails, err := existingUsers(dbmanager.DB(ctx), rows, bidxs)
+	if err != nil {
+		logger.Error("Error checking existing users for import: %v", err)
+		return dto.ImportReport{}, nil, dto.Fail("Error validating import file")
+	}
+
+	var valid []importRow
+	for i, row := range rows {
+		if takenUsernames[row.Username] {
+			rowErrors[i] = append(rowErrors[i], dto.ImportRowError{Row: row.Row, Field: "username", Message: "Username already exists"})
+		}
+		if bidx, ok := bidxs[row.Row]; ok && takenEmails[bidx] {
+			rowErrors[i] = append(rowErrors[i], dto.ImportRowError{Row: row.Row, Field: "email", Message: "Email already in use"})
+		}
+		if len(rowErrors[i]) > 0 {
+			report.Errors = append(report.Errors, rowErrors[i]...)
+			continue
+		}
+		valid = append(valid, row)
+	}
+
+	report.Valid = len(valid)
+	report.Invalid = report.Total - report.Valid
+	return report, valid, nil
+}
+
+// checkImportRow applies the rules of UserCreateRequest to a row.
+func checkImportRow(row importRow) []dto.ImportRowError {
+	var errs []dto.ImportRowError
+	fail := func(field, message string) {
+		errs = append(errs, dto.ImportRowError{Row: row.Row, Field: field, Message: message})
+	}
+
+	switch n := len([]rune(row.Username)); {
+	case n == 0:
+		fail("username", "Username is required")
+	case n < 3 || n > 50:
+		fail("username", "Username must be 3 to 50 characters")
+	case strings.ContainsAny(row.Username, " \t"):
+		fail("username", "Username should not contain spaces")
+	}
+
+	switch {
+	case row.Email == "":
+		fail("email", "Email is required")
+	case !tools.IsValidEmail(row.Email):
+		fail("email", "Invalid email format")
+	}
+
+	switch n := len([]rune(row.FullName)); {
+	case n == 0:
+		fail("full_name", "Full name is required")
+	case n < 2 || n > 100:
+		fail("full_name", "Full name must be 2 to 100 characters")
+	}
+	return errs
+}
+
+// existingUsers returns the usernames and email blind indexes of rows that
+// already belong to a user.
+func existingUsers(db *gorm.DB, rows []importRow, bidxs map[int]string) (map[string]bool, map[string]bool, error) {
+	const chunk = 500
+	usernames := map[string]bool{}
+	emails := map[string]bool{}
+
+	for start := 0; start < len(rows); start += chunk {
+		end := start + chunk
+		if end > len(rows) {
+			end = len(rows)
+		}
+		names := make([]string, 0, end-start)
+		indexes := make([]string, 0, end-start)
+		for _, row := range rows[start:end] {
+			if row.Username != "" {
+				names = append(names, row.Username)
+			}
+			if bidx, ok := bidxs[row.Row]; ok {
+				indexes = append(indexes, bidx)
+			}
+		}
+
+		var found []string
+		if err := db.Model(&entity.User{}).Where("username IN ?", names).Pluck("username", &found).Error; err != nil {
+			return nil, nil, err
+		}
+		for _, name := range found {
+			usernames[name] = true
+		}
+
+		found = nil
+		if err := db.Model(&entity.User{}).Where("email_bidx IN ?", indexes).Pluck("email_bidx", &found).Error; err != nil {
+			return nil, nil, err
+		}
+		for _, bidx := range found {
+			emails[bidx] = true
+		}
+	}
+	return usernames, emails, nil
+}
+
+// resolveColumns returns the index of the file column each import field is
+// read from, using the explicit mapping first and column names otherwise.
+func resolveColumns(header []string, mapping map[string]string) (map[string]int, error) {
+	for field := range mapping {
+		if _, ok := importFields[field]; !ok {
+			return nil, fmt.Errorf("unknown field %q in mapping, expected one of %s", field, strings.Join(importFieldNames(), ", "))
+		}
+	}
+
+	columns := map[string]int{}
+	for _, field := range importFieldNames() {
+		if name, ok := mapping[field]; ok {
+			i := indexOfColumn(header, func(h string) bool { return strings.EqualFold(h, strings.TrimSpace(name)) })
+			if i < 0 {
+				return nil, fmt.Errorf("column %q mapped to %s is not in the file", name, field)
+			}
+			columns[field] = i
+			continue
+		}
+
+		i := indexOfColumn(header, func(h string) bool {
+			h = normalizeColumn(h)
+			for _, alias := range importFields[field] {
+				if h == alias {
+					return true
+				}
+			}
+			return false
+		})
+		if i < 0 {
+			return nil, fmt.Errorf("no column found for %s, map it explicitly (columns: %s)", field, strings.Join(header, ", "))
+		}
+		columns[field] = i
+	}
+	return columns, nil
+}
+
+func indexOfColumn(header []string, match func(string) bool) int {
+	for i, h := range header {
+		if match(h) {
+			return i
+		}
+	}
+	return -1
+}
+
+func normalizeColumn(name string) string {
+	return strings.NewReplacer(" ", "", "-", "", "_", "").Replace(strings.ToLower(name))
+}
+
+func importFieldNames() []string {
+	names := make([]string, 0, len(importFields))
+	for field := range importFields {
+		names = append(names, field)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func encodeImportErrors(errs []dto.ImportRowError) string {
+	if len(errs) == 0 {
+		return ""
+	}
+	b, err := json.Marshal(errs)
+	if err != nil {
+		logger.Error("Error encoding import errors: %v", err)
+		return ""
+	}
+	return string(b)
+}
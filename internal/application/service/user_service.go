@@ -1,15 +1,22 @@
 package service
 
 import (
+	"context"
+	"errors"
 	"strings"
 	"time"
 
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 
+	"boilerplate-golang/internal/application/apperror"
+	"boilerplate-golang/internal/application/audit"
 	"boilerplate-golang/internal/application/dto"
 	"boilerplate-golang/internal/application/entity"
+	"boilerplate-golang/internal/application/outbox"
+	"boilerplate-golang/internal/application/queryspec"
 	"boilerplate-golang/internal/application/tools"
+	"boilerplate-golang/internal/infrastructure/cryptomanager"
 	"boilerplate-golang/internal/infrastructure/dbmanager"
 	"boilerplate-golang/internal/infrastructure/logger"
 )
@@ -17,64 +24,47 @@ import (
 type userService struct {
 }
 
-// GetAllUsers returns a paginated list of users with filtering and sorting
-func (s *userService) GetAllUsers(page, pageSize int, filterSearch, sortBy, sortOrder string) dto.ResponseDto {
-	var users []entity.User
-	var totalCount int64
-
-	db := dbmanager.GetDB()
-
-	// Base query
-	query := db.Model(&entity.User{}).Where("deleted_at IS NULL")
-
-	// Apply search filter
-	if filterSearch != "" {
-		searchTerm := "%" + filterSearch + "%"
-		query = query.Where(
-			"LOWER(username) LIKE LOWER(?) OR LOWER(email) LIKE LOWER(?) OR LOWER(full_name) LIKE LOWER(?)",
-			searchTerm, searchTerm, searchTerm,
-		)
-	}
-
-	// Apply sorting
-	if sortBy != "" && (sortOrder == "asc" || sortOrder == "desc") {
-		query = query.Order(sortBy + " " + sortOrder)
-	} else {
-		// Default sorting
-		query = query.Order("created_at DESC")
-	}
-
-	// Apply pagination
-	if pageSize > 0 {
-		offset := (page - 1) * pageSize
-		if err := query.Count(&totalCount).Limit(pageSize).Offset(offset).Find(&users).Error; err != nil {
-			logger.Error("Error fetching users with pagination: %v", err)
-			return *dto.Fail("Error fetching users with pagination")
-		}
-	} else {
-		// If no pagination, fetch all records
-		if err := query.Count(&totalCount).Find(&users).Error; err != nil {
-			logger.Error("Error fetching users with no pagination: %v", err)
-			return *dto.Fail("Error fetching users with no pagination")
-		}
-	}
+// EventUserCreated is published through the outbox when a user is created.
+const EventUserCreated = "user.created"
+
+// UserQuery whitelists the user fields list callers may filter, sort and search on.
+var UserQuery = queryspec.Whitelist{
+	Filters: map[string]queryspec.Field{
+		"username":   {Column: "username", Ops: []string{queryspec.OpEq, queryspec.OpLike, queryspec.OpIn}},
+		// email and full_name are encrypted; email is matched exactly through its blind index
+		"email":      {Column: "email_bidx", Ops: []string{queryspec.OpEq, queryspec.OpIn}, Index: cryptomanager.BlindIndex},
+		"is_active":  {Column: "is_active", Type: queryspec.TypeBool, Ops: []string{queryspec.OpEq}},
+		"is_admin":   {Column: "is_admin", Type: queryspec.TypeBool, Ops: []string{queryspec.OpEq}},
+		"created_at": {Column: "created_at", Type: queryspec.TypeTime, Ops: []string{queryspec.OpGte, queryspec.OpLte}},
+		"last_login": {Column: "last_login", Type: queryspec.TypeTime, Ops: []string{queryspec.OpGte, queryspec.OpLte}},
+	},
+	Sorts: map[string]string{
+		"username":   "username",
+		"created_at": "created_at",
+		"last_login": "last_login",
+	},
+	Search:      []string{"username"},
+	DefaultSort: "-created_at",
+}
 
-	// Convert entities to DTOs
-	userDtos := make([]dto.UserResponse, len(users))
-	for i, user := range users {
-		userDtos[i] = dto.GetUserResponse(user)
+// GetAllUsers returns a page of users matching the query spec. Offset pages
+// include the total count; keyset pages (page[after]/page[before]) carry cursors.
+func (s *userService) GetAllUsers(ctx context.Context, spec queryspec.Spec) dto.ResponseDto {
+	if spec.Cursor {
+		return queryspec.ListCursor(dbmanager.DB(ctx), spec, UserQuery, dto.GetUserResponse)
 	}
-
-	// Return paginated response
-	return *dto.SuccessCount(userDtos, totalCount)
+	return queryspec.List(dbmanager.DB(ctx), spec, UserQuery, dto.GetUserResponse)
 }
 
 // GetUserByID retrieves a user by their ID
-func (s *userService) GetUserByID(id string) dto.ResponseDto {
+func (s *userService) GetUserByID(ctx context.Context, id string) dto.ResponseDto {
 	var user entity.User
 
-	db := dbmanager.GetDB()
-	if err := db.First(&user, id).Error; err != nil {
+	db := dbmanager.DB(ctx)
+	if err := db.First(&user, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return *dto.FailWith(apperror.NotFound("user_not_found", "User not found"))
+		}
 		logger.Error("Error fetching user by ID: %v", err)
 		return *dto.Fail("Error fetching user by ID")
 	}
@@ -83,55 +73,35 @@ func (s *userService) GetUserByID(id string) dto.ResponseDto {
 }
 
 // CreateUser creates a new user
-func (s *userService) CreateUser(username, email, password, fullName string) dto.ResponseDto {
-	db := dbmanager.GetDB()
-	tx := db.Begin()
-
+func (s *userService) CreateUser(ctx context.Context, username, email, password, fullName string) dto.ResponseDto {
 	// Validate required fields
 	if username == "" || email == "" || password == "" || fullName == "" {
-		return *dto.Fail("All fields are required")
+		return *dto.FailWith(apperror.BadRequest("fields_required", "All fields are required"))
 	}
 
 	// Check if username contains spaces
 	if strings.Contains(username, " ") {
-		return *dto.Fail("Username should not contain spaces")
-	}
-
-	// Check if username already exists
-	var existingUser entity.User
-	if err := tx.Where("username = ?", username).First(&existingUser).Error; err == nil {
-		tx.Rollback()
-		return *dto.Fail("Username already exists")
-	} else if err != gorm.ErrRecordNotFound {
-		tx.Rollback()
-		logger.Error("Error checking username existence: %v", err)
-		return *dto.Fail("Error checking username availability")
+		return *dto.FailWith(apperror.BadRequest("username_has_spaces", "Username should not contain spaces"))
 	}
 
 	// Validate email format
 	if !tools.IsValidEmail(email) {
-		return *dto.Fail("Invalid email format")
+		return *dto.FailWith(apperror.BadRequest("invalid_email", "Invalid email format"))
 	}
 
-	// Check if email already exists
-	if err := tx.Where("email = ?", email).First(&existingUser).Error; err == nil {
-		tx.Rollback()
-		return *dto.Fail("Email already in use")
-	} else if err != gorm.ErrRecordNotFound {
-		tx.Rollback()
-		logger.Error("Error checking email existence: %v", err)
-		return *dto.Fail("Error checking email availability")
-	}
-
-	// Hash the password
+	// Hash the password before opening the transaction, bcrypt is slow
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
 	if err != nil {
-		tx.Rollback()
 		logger.Error("Error hashing password: %v", err)
 		return *dto.Fail("Error creating user account")
 	}
 
-	// Create new user
+	emailBidx, err := cryptomanager.BlindIndex(email)
+	if err != nil {
+		logger.Error("Error computing email blind index: %v", err)
+		return *dto.Fail("Error creating user account")
+	}
+
 	newUser := entity.User{
 		ID:       tools.NewUuid(),
 		Username: username,
@@ -142,73 +112,219 @@ func (s *userService) CreateUser(username, email, password, fullName string) dto
 		IsAdmin:  false,
 	}
 
-	// Save user to database
-	if err := tx.Create(&newUser).Error; err != nil {
-		tx.Rollback()
-		logger.Error("Error creating user: %v", err)
-		return *dto.Fail("Error creating user account")
-	}
+	var res dto.ResponseDto
+	err = dbmanager.WithTx(ctx, func(ctx context.Context) error {
+		tx := dbmanager.DB(ctx)
+
+		// Check if username already exists
+		var existingUser entity.User
+		if err := tx.Where("username = ?", username).First(&existingUser).Error; err == nil {
+			res = *dto.FailWith(apperror.Conflict("username_taken", "Username already exists"))
+			return errRollback
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			logger.Error("Error checking username existence: %v", err)
+			res = *dto.Fail("Error checking username availability")
+			return err
+		}
 
-	// Commit the transaction
-	if err := tx.Commit().Error; err != nil {
-		logger.Error("Error committing transaction: %v", err)
-		return *dto.Fail("Error creating user account")
+		// Check if email already exists, through its blind index since emails are encrypted
+		if err := tx.Where("email_bidx = ?", emailBidx).First(&existingUser).Error; err == nil {
+			res = *dto.FailWith(apperror.Conflict("email_taken", "Email already in use"))
+			return errRollback
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			logger.Error("Error checking email existence: %v", err)
+			res = *dto.Fail("Error checking email availability")
+			return err
+		}
+
+		// Save user to database
+		if err := tx.Create(&newUser).Error; err != nil {
+			if taken, ok := userTaken(err); ok {
+				res = taken
+				return errRollback
+			}
+			logger.Error("Error creating user: %v", err)
+			res = *dto.Fail("Error creating user account")
+			return err
+		}
+
+		// Recorded in the same transaction so the event can't be lost or sent for a rolled back user
+		if err := outbox.Enqueue(ctx, "user", newUser.ID, EventUserCreated, dto.GetUserResponse(newUser)); err != nil {
+			logger.Error("Error enqueueing user created event: %v", err)
+			res = *dto.Fail("Error creating user account")
+			return err
+		}
+
+		dbmanager.AfterCommit(ctx, func() {
+			logger.Info("user created: %s", newUser.ID)
+		})
+		return nil
+	})
+	if err != nil {
+		if res.Code == 0 {
+			// Failed while committing
+			logger.Error("Error committing transaction: %v", err)
+			return *dto.Fail("Error creating user account")
+		}
+		return res
 	}
 
 	// Return the created user (without sensitive data)
 	return *dto.Success(dto.GetUserResponse(newUser))
 }
 
-// UpdateUser updates an existing user
-func (s *userService) UpdateUser(id, username, email, password, fullName string) dto.ResponseDto {
-	db := dbmanager.GetDB()
+// UpdateUser updates an existing user. The update only applies if the user is
+// still at version; pass 0 to update whatever version is current. Concurrent
+// edits fail with a conflict instead of overwriting each other. Email and
+// password are changed through authService, which verifies the change.
+func (s *userService) UpdateUser(ctx context.Context, id string, version int64, username, fullName string) dto.ResponseDto {
+	// Read from the primary so the update is based on the latest row
+	db := dbmanager.Primary(ctx)
 
-	var user entity.User
-	if err := db.First(&user, id).Error; err != nil {
-		return *dto.Fail("User not found")
+	if strings.Contains(username, " ") {
+		return *dto.FailWith(apperror.BadRequest("username_has_spaces", "Username should not contain spaces"))
 	}
 
-	if username != "" {
-		user.Username = username
+	var user entity.User
+	if err := db.First(&user, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return *dto.FailWith(apperror.NotFound("user_not_found", "User not found"))
+		}
+		logger.Error("Error fetching user for update: %v", err)
+		return *dto.Fail("Error updating user")
 	}
-	if email != "" {
-		user.Email = email
+	if version == 0 {
+		version = user.Version
 	}
-	if password != "" {
-		user.Password = password
+
+	// Map updates bypass the encrypted serializer, so encrypt explicitly
+	updates := map[string]interface{}{}
+	if username != "" && username != user.Username {
+		var taken int64
+		if err := db.Model(&entity.User{}).Where("username = ? AND id <> ?", username, id).Count(&taken).Error; err != nil {
+			logger.Error("Error checking username existence: %v", err)
+			return *dto.Fail("Error updating user")
+		}
+		if taken > 0 {
+			return *dto.FailWith(apperror.Conflict("username_taken", "Username already exists"))
+		}
+		updates["username"] = username
 	}
 	if fullName != "" {
-		user.FullName = fullName
+		encrypted, err := cryptomanager.EncryptColumn(user.TableName(), "full_name", fullName)
+		if err != nil {
+			logger.Error("Error encrypting full name: %v", err)
+			return *dto.Fail("Error updating user")
+		}
+		updates["full_name"] = encrypted
 	}
 
-	if err := db.Save(&user).Error; err != nil {
+	if err := dbmanager.UpdateVersioned(db, &user, version, updates); err != nil {
+		if errors.Is(err, dbmanager.ErrVersionConflict) {
+			return *dto.FailWith(apperror.Conflict("version_conflict", "User was modified by someone else, reload and try again"))
+		}
+		if taken, ok := userTaken(err); ok {
+			return taken
+		}
 		logger.Error("Error updating user: %v", err)
 		return *dto.Fail("Error updating user")
 	}
 
-	return *dto.Success("User updated successfully")
+	if err := db.First(&user, "id = ?", id).Error; err != nil {
+		logger.Error("Error reloading updated user: %v", err)
+		return *dto.Fail("Error updating user")
+	}
+	return *dto.SuccessMessageKey("user_updated", "User updated successfully", dto.GetUserResponse(user))
 }
 
-// SoftDeleteUser soft deletes a user by their ID
-func (s *userService) SoftDeleteUser(id string) dto.ResponseDto {
-	db := dbmanager.GetDB()
+// SoftDeleteUser soft deletes a user by their ID and signs them out of every
+// session, as an admin action: it is audited and can't remove the last admin.
+func (s *userService) SoftDeleteUser(ctx context.Context, id string) dto.ResponseDto {
+	return s.runAdminAction(ctx, id, "", adminAction{
+		name:  "user.delete",
+		check: keepsAnAdmin,
+		updates: map[string]interface{}{
+			"is_active":  false,
+			"deleted_at": time.Now().UTC(),
+		},
+		revoke:  true,
+		key:     "user_deleted",
+		message: "User deleted",
+	})
+}
 
-	var user entity.User
-	if err := db.First(&user, id).Error; err != nil {
-		logger.Error("Error soft deleting user: %v", err)
-		return *dto.Fail("User not found")
+// GetDeletedUsers returns a page of soft-deleted users matching the query spec.
+func (s *userService) GetDeletedUsers(ctx context.Context, spec queryspec.Spec) dto.ResponseDto {
+	db := dbmanager.DB(ctx).Unscoped().Where("deleted_at IS NOT NULL")
+	if spec.Cursor {
+		return queryspec.ListCursor(db, spec, UserQuery, dto.GetUserResponse)
 	}
+	return queryspec.List(db, spec, UserQuery, dto.GetUserResponse)
+}
 
-	user.IsActive = false
-	user.DeletedAt = gorm.DeletedAt{
-		Time:  time.Now().UTC(),
-		Valid: true,
-	}
+// RestoreUser undoes the soft delete of a user, unless its username or email
+// has been taken by another user in the meantime.
+func (s *userService) RestoreUser(ctx context.Context, id string) dto.ResponseDto {
+	var res dto.ResponseDto
+	err := dbmanager.WithTx(ctx, func(ctx context.Context) error {
+		tx := dbmanager.DB(ctx)
+
+		var user entity.User
+		if err := tx.Unscoped().Where("deleted_at IS NOT NULL").First(&user, "id = ?", id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				res = *dto.FailWith(apperror.NotFound("deleted_user_not_found", "Deleted user not found"))
+				return errRollback
+			}
+			logger.Error("Error fetching deleted user: %v", err)
+			res = *dto.Fail("Error restoring user")
+			return err
+		}
+
+		emailBidx, err := cryptomanager.BlindIndex(user.Email)
+		if err != nil {
+			logger.Error("Error computing email blind index: %v", err)
+			res = *dto.Fail("Error restoring user")
+			return err
+		}
 
-	if err := db.Save(&user).Error; err != nil {
-		logger.Error("Error soft deleting user: %v", err)
-		return *dto.Fail("Error soft deleting user")
+		var taken int64
+		if err := tx.Model(&entity.User{}).Where("username = ? OR email_bidx = ?", user.Username, emailBidx).Count(&taken).Error; err != nil {
+			logger.Error("Error checking username and email availability: %v", err)
+			res = *dto.Fail("Error restoring user")
+			return err
+		}
+		if taken > 0 {
+			res = *dto.FailWith(apperror.Conflict("user_exists", "Username or email is now used by another user"))
+			return errRollback
+		}
+
+		if err := tx.Unscoped().Model(&user).Updates(map[string]interface{}{
+			"deleted_at": nil,
+			"is_active":  true,
+			"version":    gorm.Expr("version + 1"),
+		}).Error; err != nil {
+			if _, ok := userTaken(err); ok {
+				res = *dto.FailWith(apperror.Conflict("user_exists", "Username or email is now used by another user"))
+				return errRollback
+			}
+			logger.Error("Error restoring user: %v", err)
+			res = *dto.Fail("Error restoring user")
+			return err
+		}
+		if err := audit.Record(ctx, "user.restore", "user", id, nil); err != nil {
+			logger.Error("Error recording audit log: %v", err)
+			res = *dto.Fail("Error restoring user")
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		if res.Code == 0 {
+			logger.Error("Error committing transaction: %v", err)
+			return *dto.Fail("Error restoring user")
+		}
+		return res
 	}
 
-	return *dto.Success("User soft deleted successfully")
+	return *dto.Success("User restored successfully")
 }
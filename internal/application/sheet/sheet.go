@@ -0,0 +1,156 @@
+package sheet
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// Supported formats.
+const (
+	FormatCSV  = "csv"
+	FormatXLSX = "xlsx"
+)
+
+// ContentTypes maps each format to the content type it is served with.
+var ContentTypes = map[string]string{
+	FormatCSV:  "text/csv; charset=utf-8",
+	FormatXLSX: "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+}
+
+// FormatOf returns the format of a file from its name, or "" if unsupported.
+func FormatOf(filename string) string {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".csv":
+		return FormatCSV
+	case ".xlsx":
+		return FormatXLSX
+	}
+	return ""
+}
+
+// Read parses a CSV file or the first worksheet of an XLSX file into its
+// header row and data rows. Fully empty rows are dropped and every row is
+// padded to the width of the header.
+func Read(format string, data []byte) (header []string, rows [][]string, err error) {
+	var all [][]string
+	switch format {
+	case FormatCSV:
+		r := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))))
+		r.FieldsPerRecord = -1
+		r.TrimLeadingSpace = true
+		all, err = r.ReadAll()
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid CSV: %w", err)
+		}
+	case FormatXLSX:
+		f, err := excelize.OpenReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid XLSX: %w", err)
+		}
+		defer f.Close()
+		sheets := f.GetSheetList()
+		if len(sheets) == 0 {
+			return nil, nil, errors.New("XLSX file has no worksheets")
+		}
+		all, err = f.GetRows(sheets[0])
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid XLSX: %w", err)
+		}
+	default:
+		return nil, nil, fmt.Errorf("unsupported format %q", format)
+	}
+
+	if len(all) == 0 {
+		return nil, nil, errors.New("file is empty")
+	}
+	header = make([]string, len(all[0]))
+	for i, h := range all[0] {
+		header[i] = strings.TrimSpace(h)
+	}
+	for _, row := range all[1:] {
+		if blank(row) {
+			continue
+		}
+		padded := make([]string, len(header))
+		copy(padded, row)
+		rows = append(rows, padded)
+	}
+	return header, rows, nil
+}
+
+// Write encodes a header row and data rows in format.
+func Write(w io.Writer, format string, header []string, rows [][]string) error {
+	switch format {
+	case FormatCSV:
+		cw := csv.NewWriter(w)
+		if err := cw.Write(header); err != nil {
+			return err
+		}
+		for _, row := range rows {
+			if err := cw.Write(escapeFormula(row)); err != nil {
+				return err
+			}
+		}
+		cw.Flush()
+		return cw.Error()
+	case FormatXLSX:
+		f := excelize.NewFile()
+		defer f.Close()
+		sw, err := f.NewStreamWriter("Sheet1")
+		if err != nil {
+			return err
+		}
+		if err := sw.SetRow("A1", cells(header)); err != nil {
+			return err
+		}
+		for i, row := range rows {
+			// Written as strings, so values are never evaluated as formulas
+			if err := sw.SetRow(fmt.Sprintf("A%d", i+2), cells(row)); err != nil {
+				return err
+			}
+		}
+		if err := sw.Flush(); err != nil {
+			return err
+		}
+		return f.Write(w)
+	default:
+		return fmt.Errorf("unsupported format %q", format)
+	}
+}
+
+func blank(row []string) bool {
+	for _, v := range row {
+		if strings.TrimSpace(v) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+func cells(row []string) []interface{} {
+	out := make([]interface{}, len(row))
+	for i, v := range row {
+		out[i] = v
+	}
+	return out
+}
+
+// escapeFormula prefixes values a spreadsheet would run as a formula when the
+// CSV is opened (CSV injection).
+func escapeFormula(row []string) []string {
+	out := make([]string, len(row))
+	for i, v := range row {
+		if v != "" && strings.ContainsRune("=+-@\t\r", rune(v[0])) {
+			v = "'" + v
+		}
+		out[i] = v
+	}
+	return out
+}
@@ -0,0 +1,66 @@
+package tools
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+)
+
+// FlattenSquare center-crops img to a square and draws it onto an opaque white
+// background, ready to be scaled with ResizeSquare and encoded as JPEG.
+func FlattenSquare(img image.Image) *image.RGBA {
+	b := img.Bounds()
+	side := b.Dx()
+	if b.Dy() < side {
+		side = b.Dy()
+	}
+	crop := image.Rect(0, 0, side, side)
+	offset := image.Pt(b.Min.X+(b.Dx()-side)/2, b.Min.Y+(b.Dy()-side)/2)
+
+	dst := image.NewRGBA(crop)
+	draw.Draw(dst, crop, image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(dst, crop, img, offset, draw.Over)
+	return dst
+}
+
+// ResizeSquare scales a square image to size×size. Each target pixel averages
+// the source pixels it covers, which keeps downscaled photos smooth; when
+// enlarging, pixels are repeated.
+func ResizeSquare(src *image.RGBA, size int) *image.RGBA {
+	side := src.Bounds().Dx()
+	dst := image.NewRGBA(image.Rect(0, 0, size, size))
+
+	span := func(i int) (int, int) {
+		lo := i * side / size
+		hi := (i + 1) * side / size
+		if hi <= lo {
+			hi = lo + 1
+		}
+		return lo, hi
+	}
+
+	for dy := 0; dy < size; dy++ {
+		y0, y1 := span(dy)
+		for dx := 0; dx < size; dx++ {
+			x0, x1 := span(dx)
+			var r, g, b, a, n int
+			for y := y0; y < y1; y++ {
+				row := src.Pix[y*src.Stride:]
+				for x := x0; x < x1; x++ {
+					p := row[x*4 : x*4+4]
+					r += int(p[0])
+					g += int(p[1])
+					b += int(p[2])
+					a += int(p[3])
+					n++
+				}
+			}
+			o := dy*dst.Stride + dx*4
+			dst.Pix[o] = uint8(r / n)
+			dst.Pix[o+1] = uint8(g / n)
+			dst.Pix[o+2] = uint8(b / n)
+			dst.Pix[o+3] = uint8(a / n)
+		}
+	}
+	return dst
+}
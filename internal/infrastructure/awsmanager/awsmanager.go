@@ -101,11 +101,7 @@ func UploadFile(ctx context.Context, file multipart.File, header *multipart.File
 	}
 
 	// Generate URL
-	url := fmt.Sprintf("https://%s.s3.%s.amazonaws.com/%s",
-		bucketName,
-		region,
-		key,
-	)
+	url := ObjectURL(key)
 
 	log.Printf("awsmanager: uploaded file to %s", url)
 
@@ -118,6 +114,56 @@ func UploadFile(ctx context.Context, file multipart.File, header *multipart.File
 	}, nil
 }
 
+// Enabled reports whether the S3 client has been initialized.
+func Enabled() bool {
+	return s3Client != nil
+}
+
+// PutObject uploads data under key, e.g. a file generated by the application.
+func PutObject(ctx context.Context, key string, data []byte, contentType string) error {
+	if s3Client == nil {
+		return fmt.Errorf("S3 client not initialized")
+	}
+
+	_, err := s3Client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(bucketName),
+		Key:         aws.String(key),
+		Body:        bytes.NewReader(data),
+		ContentType: aws.String(contentType),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to upload file to S3: %w", err)
+	}
+	return nil
+}
+
+// GetObject downloads the object stored under key.
+func GetObject(ctx context.Context, key string) ([]byte, error) {
+	if s3Client == nil {
+		return nil, fmt.Errorf("S3 client not initialized")
+	}
+
+	out, err := s3Client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(bucketName),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to download file from S3: %w", err)
+	}
+	defer out.Body.Close()
+
+	data, err := io.ReadAll(out.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download file from S3: %w", err)
+	}
+	return data, nil
+}
+
+// ObjectURL returns the public URL of key in the bucket.
+func ObjectURL(key string) string {
+	return fmt.Sprintf("https://%s.s3.%s.amazonaws.com/%s", bucketName, region, key)
+}
+
 // DeleteFile removes a file from S3
 func DeleteFile(ctx context.Context, key string) error {
 	if s3Client == nil {
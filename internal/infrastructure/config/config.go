@@ -1,6 +1,7 @@
 package config
 
 import (
+	"fmt"
 	"log"
 	"strings"
 	"time"
@@ -14,8 +15,28 @@ type AppConfig struct {
 		Name string
 		Port int
 		Env  string
+		// BaseURL is the public URL of the API, used in links sent by email
+		BaseURL string `mapstructure:"base_url"`
+		// FrontendURL is the public URL of the web client, which hosts pages
+		// such as the password reset form that emailed links open
+		FrontendURL string `mapstructure:"frontend_url"`
 	}
+	Server struct {
+		HTTP2        bool `mapstructure:"http2"`
+		H2C          bool `mapstructure:"h2c"`
+		RedirectPort int  `mapstructure:"redirect_port"`
+		TLS          struct {
+			Enabled         bool          `mapstructure:"enabled"`
+			CertFile        string        `mapstructure:"cert_file"`
+			KeyFile         string        `mapstructure:"key_file"`
+			ClientCAFile    string        `mapstructure:"client_ca_file"`
+			AdminClientAuth bool          `mapstructure:"admin_client_auth"`
+			ReloadInterval  time.Duration `mapstructure:"reload_interval"`
+		} `mapstructure:"tls"`
+	} `mapstructure:"server"`
 	Database struct {
+		// Driver selects the database: mysql (default), postgres or sqlite
+		Driver    string `mapstructure:"driver"`
 		Host      string
 		Port      int
 		User      string
@@ -25,11 +46,38 @@ type AppConfig struct {
 		ParseTime bool
 		Loc       string
 		Timeout   string
+		// SSLMode is passed to PostgreSQL (disable, require, verify-full)
+		SSLMode string `mapstructure:"ssl_mode"`
+		// AutoMigrate applies pending migrations at startup instead of failing
+		AutoMigrate bool `mapstructure:"auto_migrate"`
+		Pool        struct {
+			MaxOpenConns    int           `mapstructure:"max_open_conns"`
+			MaxIdleConns    int           `mapstructure:"max_idle_conns"`
+			ConnMaxLifetime time.Duration `mapstructure:"conn_max_lifetime"`
+			ConnMaxIdleTime time.Duration `mapstructure:"conn_max_idle_time"`
+		} `mapstructure:"pool"`
+		// Replicas receive read queries; empty user/port fall back to the primary's
+		Replicas []struct {
+			Host     string `mapstructure:"host"`
+			Port     int    `mapstructure:"port"`
+			User     string `mapstructure:"user"`
+			Password string `mapstructure:"password"`
+		} `mapstructure:"replicas"`
+		// ReplicaPolicy balances reads across replicas: random (default) or round_robin
+		ReplicaPolicy string `mapstructure:"replica_policy"`
+		// SlowQueryThreshold logs statements slower than this as warnings (0 disables)
+		SlowQueryThreshold time.Duration `mapstructure:"slow_query_threshold"`
+		// LogQueries logs every statement, not only slow and failed ones
+		LogQueries bool `mapstructure:"log_queries"`
+		// RedactColumns lists columns whose bound values are masked in SQL logs,
+		// in addition to encrypted columns, password and token_hash
+		RedactColumns []string `mapstructure:"redact_columns"`
 	}
 	JWT struct {
-		Secret   string        `mapstructure:"secret"`
-		Issuer   string        `mapstructure:"issuer"`
-		ExpireIn time.Duration `mapstructure:"expire_in"`
+		Secret          string        `mapstructure:"secret_key"`
+		Issuer          string        `mapstructure:"issuer"`
+		ExpireIn        time.Duration `mapstructure:"access_token_expiry"`
+		RefreshExpireIn time.Duration `mapstructure:"refresh_token_expiry"`
 	} `mapstructure:"jwt"`
 	Stripe struct {
 		APIKey          string `mapstructure:"api_key"`
@@ -46,7 +94,77 @@ type AppConfig struct {
 		Password string
 		DB       int
 	}
-	CronJob struct {
+	Mail struct {
+		// Driver is "smtp", or "log" (default) to only log outgoing mail
+		Driver   string `mapstructure:"driver"`
+		Host     string `mapstructure:"host"`
+		Port     int    `mapstructure:"port"`
+		Username string `mapstructure:"username"`
+		Password string `mapstructure:"password"`
+		From     string `mapstructure:"from"`
+	} `mapstructure:"mail"`
+	Outbox struct {
+		Enabled bool `mapstructure:"enabled"`
+		// Publisher is "bus" (in-process subscribers) or "redis"
+		Publisher    string        `mapstructure:"publisher"`
+		RedisChannel string        `mapstructure:"redis_channel"`
+		PollInterval time.Duration `mapstructure:"poll_interval"`
+		BatchSize    int           `mapstructure:"batch_size"`
+		MaxAttempts  int           `mapstructure:"max_attempts"`
+		// Retention is how long published messages are kept before cleanup
+		Retention time.Duration `mapstructure:"retention"`
+	} `mapstructure:"outbox"`
+	Seed struct {
+		// Auto runs the seeders at startup in the development environment
+		Auto          bool   `mapstructure:"auto"`
+		AdminUsername string `mapstructure:"admin_username"`
+		AdminEmail    string `mapstructure:"admin_email"`
+		AdminPassword string `mapstructure:"admin_password"`
+	} `mapstructure:"seed"`
+	Encryption struct {
+		// ActiveKey is the id of the key new values are encrypted with
+		ActiveKey string `mapstructure:"active_key"`
+		// Keys maps key ids to base64 AES-256 keys; keep retired keys until
+		// the reencrypt command has moved all data off them
+		Keys map[string]string `mapstructure:"keys"`
+		// BlindIndexKey keys the HMAC used for lookups on encrypted columns
+		BlindIndexKey string `mapstructure:"blind_index_key"`
+	} `mapstructure:"encryption"`
+	Storage struct {
+		// Driver is "local" (default) or "s3", which uses the [aws] bucket
+		Driver   string `mapstructure:"driver"`
+		LocalDir string `mapstructure:"local_dir"`
+		// PublicURL is the base URL files are served from, e.g. a CDN; defaults
+		// to /api/files for local storage and the bucket URL for s3
+		PublicURL string `mapstructure:"public_url"`
+		// MaxAvatarSize is the largest avatar upload accepted, in bytes
+		MaxAvatarSize int64 `mapstructure:"max_avatar_size"`
+		// SigningSecret signs the expiring download links of private local
+		// files; share it across replicas
+		SigningSecret string `mapstructure:"signing_secret"`
+	} `mapstructure:"storage"`
+	Pagination struct {
+		// CursorSecret signs cursor tokens; share it across replicas
+		CursorSecret string `mapstructure:"cursor_secret"`
+	} `mapstructure:"pagination"`
+	Privacy struct {
+		// ErasureGrace is how long after an erasure request the account is
+		// anonymized, provided an admin approved it; the user can cancel until then
+		ErasureGrace time.Duration `mapstructure:"erasure_grace"`
+		// ExportTTL is how long a data export archive is kept for download
+		ExportTTL time.Duration `mapstructure:"export_ttl"`
+	} `mapstructure:"privacy"`
+	Invitations struct {
+		// InviteOnly rejects open registration, accounts are only created by
+		// admins or through invitations
+		InviteOnly bool `mapstructure:"invite_only"`
+		// TTL is how long an invitation link stays valid
+		TTL time.Duration `mapstructure:"ttl"`
+	} `mapstructure:"invitations"`
+	// Retention maps a table to how long its soft-deleted rows are kept
+	// before the cleanup job purges them; missing or zero keeps them forever
+	Retention map[string]time.Duration `mapstructure:"retention"`
+	CronJob   struct {
 		CleanupInterval string
 		EmailReport     string
 	}
@@ -101,6 +219,21 @@ func Load() AppConfig {
 	if cfg.App.Port == 0 {
 		cfg.App.Port = 8080
 	}
+	if cfg.App.BaseURL == "" {
+		cfg.App.BaseURL = fmt.Sprintf("http://localhost:%d", cfg.App.Port)
+	}
+	if cfg.App.FrontendURL == "" {
+		cfg.App.FrontendURL = cfg.App.BaseURL
+	}
+	if cfg.Privacy.ErasureGrace == 0 {
+		cfg.Privacy.ErasureGrace = 14 * 24 * time.Hour
+	}
+	if cfg.Privacy.ExportTTL == 0 {
+		cfg.Privacy.ExportTTL = 7 * 24 * time.Hour
+	}
+	if cfg.Invitations.TTL == 0 {
+		cfg.Invitations.TTL = 7 * 24 * time.Hour
+	}
 	if cfg.Database.Timeout == "" {
 		cfg.Database.Timeout = (10 * time.Second).String()
 	}
@@ -111,3 +244,6 @@ func Load() AppConfig {
 
 // Get returns the loaded configuration. Call Load() first.
 func Get() AppConfig { return cfg }
+
+// Set replaces the configuration, for tests that run without a config file.
+func Set(c AppConfig) { cfg = c }
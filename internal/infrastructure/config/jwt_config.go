@@ -2,12 +2,16 @@ package config
 
 import (
 	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
 	"encoding/base64"
+	"encoding/hex"
 	"fmt"
+	"log"
+	"sync"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
-	"golang.org/x/crypto/bcrypt"
 
 	"boilerplate-golang/internal/infrastructure/jwtmanager"
 )
@@ -17,14 +21,23 @@ var (
 	JWT            *jwtmanager.Manager
 	RefreshJWT     *jwtmanager.Manager
 	RefreshSecrets = make(map[string]string) // In production, use Redis or database
+	refreshMu      sync.Mutex                // guards RefreshSecrets, written by concurrent logins
 )
 
-// InitJWT initializes the JWT manager with configuration
+// placeholderJWTSecret is the secret shipped in config.toml, public knowledge.
+const placeholderJWTSecret = "your-secret-key-here"
+
+// InitJWT initializes the JWT manager with configuration. Outside development
+// it refuses to start without a real secret, since anyone could sign tokens
+// with the placeholder.
 func InitJWT() {
 	cfg := Get()
 
-	// Generate a secure random secret if not set
-	if cfg.JWT.Secret == "" {
+	// Generate a secure random secret in development if not set
+	if cfg.JWT.Secret == "" || cfg.JWT.Secret == placeholderJWTSecret {
+		if cfg.App.Env != "development" {
+			log.Fatalf("jwt.secret_key must be set to a random secret in %s (e.g. via JWT_SECRET_KEY)", cfg.App.Env)
+		}
 		secret, err := generateRandomKey(64) // 64 bytes = 512 bits
 		if err != nil {
 			panic(fmt.Sprintf("Failed to generate JWT secret: %v", err))
@@ -32,6 +45,13 @@ func InitJWT() {
 		cfg.JWT.Secret = secret
 	}
 
+	if cfg.JWT.ExpireIn == 0 {
+		cfg.JWT.ExpireIn = 15 * time.Minute
+	}
+	if cfg.JWT.RefreshExpireIn == 0 {
+		cfg.JWT.RefreshExpireIn = 24 * time.Hour * 7 // 7 days
+	}
+
 	// Initialize access token manager
 	JWT = jwtmanager.New(
 		cfg.JWT.Secret,
@@ -39,11 +59,11 @@ func InitJWT() {
 		cfg.JWT.ExpireIn,
 	)
 
-	// Initialize refresh token manager with longer expiration (7 days)
+	// Initialize refresh token manager with longer expiration
 	RefreshJWT = jwtmanager.New(
 		cfg.JWT.Secret,
 		cfg.JWT.Issuer,
-		24*time.Hour*7, // 7 days
+		cfg.JWT.RefreshExpireIn,
 	)
 }
 
@@ -54,28 +74,26 @@ type TokenPair struct {
 	ExpiresAt    time.Time `json:"expires_at"`
 }
 
-// GenerateTokenPair generates a new access token and refresh token for a user
-func GenerateTokenPair(userID, organizationID, role string) (*TokenPair, error) {
+// GenerateTokenPair generates a new access token and refresh token for a user.
+// With a sessionID both tokens are bound to that login session and stop
+// working once it is revoked; the refresh token is then kept per session.
+func GenerateTokenPair(userID, organizationID, role, sessionID string) (*TokenPair, error) {
 	// Generate access token
-	accessToken, expiresAt, err := generateToken(userID, organizationID, role, JWT)
+	accessToken, expiresAt, err := generateToken(userID, organizationID, role, sessionID, JWT)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate access token: %w", err)
 	}
 
 	// Generate refresh token
-	refreshToken, _, err := generateToken(userID, organizationID, role, RefreshJWT)
+	refreshToken, _, err := generateToken(userID, organizationID, role, sessionID, RefreshJWT)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate refresh token: %w", err)
 	}
 
-	// Hash the refresh token for secure storage
-	hashedToken, err := bcrypt.GenerateFromPassword([]byte(refreshToken), bcrypt.DefaultCost)
-	if err != nil {
-		return nil, fmt.Errorf("failed to hash refresh token: %w", err)
-	}
-
 	// In production, store this in Redis or database with user ID and expiration
-	RefreshSecrets[userID] = string(hashedToken)
+	refreshMu.Lock()
+	RefreshSecrets[refreshKey(userID, sessionID)] = hashToken(refreshToken)
+	refreshMu.Unlock()
 
 	return &TokenPair{
 		AccessToken:  accessToken,
@@ -85,30 +103,50 @@ func GenerateTokenPair(userID, organizationID, role string) (*TokenPair, error)
 }
 
 // VerifyRefreshToken verifies a refresh token and returns a new token pair
-func VerifyRefreshToken(userID, organizationID, role, refreshToken string) (*TokenPair, error) {
+func VerifyRefreshToken(userID, organizationID, role, sessionID, refreshToken string) (*TokenPair, error) {
 	// In production, verify the refresh token against the stored hash in Redis/database
-	storedHash, exists := RefreshSecrets[userID]
+	refreshMu.Lock()
+	storedHash, exists := RefreshSecrets[refreshKey(userID, sessionID)]
+	refreshMu.Unlock()
 	if !exists {
 		return nil, fmt.Errorf("no refresh token found for user")
 	}
 
 	// Verify the token hash
-	if err := bcrypt.CompareHashAndPassword([]byte(storedHash), []byte(refreshToken)); err != nil {
+	if subtle.ConstantTimeCompare([]byte(storedHash), []byte(hashToken(refreshToken))) != 1 {
 		return nil, fmt.Errorf("invalid refresh token")
 	}
 
 	// Generate new token pair
-	return GenerateTokenPair(userID, organizationID, role)
+	return GenerateTokenPair(userID, organizationID, role, sessionID)
+}
+
+// InvalidateRefreshToken removes the refresh token of a session, or of a user
+// whose tokens are not bound to a session
+func InvalidateRefreshToken(userID, sessionID string) {
+	refreshMu.Lock()
+	delete(RefreshSecrets, refreshKey(userID, sessionID))
+	refreshMu.Unlock()
+}
+
+// refreshKey is the key a refresh token is stored under.
+func refreshKey(userID, sessionID string) string {
+	if sessionID != "" {
+		return "session:" + sessionID
+	}
+	return userID
 }
 
-// InvalidateRefreshToken removes a user's refresh token
-func InvalidateRefreshToken(userID string) {
-	delete(RefreshSecrets, userID)
+// hashToken hashes a refresh token for storage. JWTs are long random strings,
+// so a plain SHA-256 suffices (and bcrypt would reject them beyond 72 bytes).
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
 }
 
 // generateToken is a helper function to generate a JWT token
-func generateToken(userID, organizationID, role string, manager *jwtmanager.Manager) (string, time.Time, error) {
-	tokenString, err := manager.Sign(userID, organizationID, role)
+func generateToken(userID, organizationID, role, sessionID string, manager *jwtmanager.Manager) (string, time.Time, error) {
+	tokenString, err := manager.SignSession(userID, organizationID, role, sessionID)
 	if err != nil {
 		return "", time.Time{}, err
 	}
@@ -2,13 +2,36 @@ package config
 
 import (
 	"container/list"
+	"context"
+	"fmt"
 	"net/http"
+	"regexp"
+	"runtime/debug"
 	"strings"
 
 	"github.com/gin-gonic/gin"
+	uuid "github.com/satori/go.uuid"
 	"golang.org/x/exp/slices"
+
+	"boilerplate-golang/internal/infrastructure/logger"
 )
 
+// sessionCheck validates the login session of session-bound tokens.
+var sessionCheck func(ctx context.Context, userID, sessionID string) error
+
+// OnSessionCheck registers fn to validate the session of every token that
+// carries one; AuthMiddleware rejects the request when fn returns an error.
+// The check lives with the session store, which config cannot import.
+func OnSessionCheck(fn func(ctx context.Context, userID, sessionID string) error) {
+	sessionCheck = fn
+}
+
+// versionRe matches the version segment of API paths, e.g. /api/v1/.
+var versionRe = regexp.MustCompile(`^/api/v\d+/`)
+
+// requestIDRe limits client-supplied request IDs to something safe to log.
+var requestIDRe = regexp.MustCompile(`^[A-Za-z0-9._-]{1,128}$`)
+
 // CORSMiddleware handles CORS configuration
 func CORSMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -23,8 +46,8 @@ func CORSMiddleware() gin.HandlerFunc {
 		if origin != "" && slices.Contains(allowedOrigins, origin) {
 			c.Header("Access-Control-Allow-Origin", origin)
 			c.Header("Access-Control-Allow-Methods", "POST, GET, OPTIONS, PUT, DELETE, UPDATE")
-			c.Header("Access-Control-Allow-Headers", "Origin, X-Requested-With, Content-Type, Accept, Authorization")
-			c.Header("Access-Control-Expose-Headers", "Content-Length, Access-Control-Allow-Origin, Access-Control-Allow-Headers, Cache-Control, Content-Language, Content-Type")
+			c.Header("Access-Control-Allow-Headers", "Origin, X-Requested-With, Content-Type, Accept, Authorization, If-Match, X-Request-ID, API-Version")
+			c.Header("Access-Control-Expose-Headers", "Content-Length, Access-Control-Allow-Origin, Access-Control-Allow-Headers, Cache-Control, Content-Language, Content-Type, ETag, X-Request-ID, API-Version, Deprecation, Sunset, Link")
 			c.Header("Access-Control-Allow-Credentials", "true")
 			c.Header("Access-Control-Max-Age", "86400")
 		}
@@ -37,6 +60,24 @@ func CORSMiddleware() gin.HandlerFunc {
 	}
 }
 
+// RequestIDMiddleware tags each request with an ID, taken from a valid
+// X-Request-ID header or generated, echoes it in the response and stores it
+// in the request context so logs (including SQL logs) can be correlated. The
+// client IP is stored there too, for the audit log.
+func RequestIDMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader("X-Request-ID")
+		if !requestIDRe.MatchString(id) {
+			id = uuid.NewV4().String()
+		}
+		c.Set("request_id", id)
+		c.Header("X-Request-ID", id)
+		ctx := logger.WithRequestID(c.Request.Context(), id)
+		c.Request = c.Request.WithContext(logger.WithClientIP(ctx, c.ClientIP()))
+		c.Next()
+	}
+}
+
 // getWhitelist returns a list of paths that don't require authentication
 func getWhitelist() *list.List {
 	whitelist := list.New()
@@ -46,6 +87,8 @@ func getWhitelist() *list.List {
 	whitelist.PushBack("/api/auth/login")
 	whitelist.PushBack("/api/auth/forgot-password")
 	whitelist.PushBack("/api/auth/reset-password")
+	whitelist.PushBack("/api/auth/confirm-email")
+	whitelist.PushBack("/api/auth/invitation")
 
 	// Public product routes
 	whitelist.PushBack("/api/products")
@@ -56,16 +99,12 @@ func getWhitelist() *list.List {
 	whitelist.PushBack("/api/categories/:id")
 
 	// Public file routes
-	whitelist.PushBack("/api/files/:filename")
+	whitelist.PushBack("/api/files/")
 
 	// Health check
 	whitelist.PushBack("/health")
 	whitelist.PushBack("/api/health")
 
-	// Swagger docs
-	whitelist.PushBack("/swagger/*any")
-	whitelist.PushBack("/docs")
-
 	return whitelist
 }
 
@@ -74,9 +113,10 @@ func AuthMiddleware() gin.HandlerFunc {
 	whitelist := getWhitelist()
 
 	return func(c *gin.Context) {
-		// Skip authentication for whitelisted routes
+		// Skip authentication for whitelisted routes, in every API version
+		path := versionRe.ReplaceAllString(c.Request.URL.Path, "/api/")
 		for e := whitelist.Front(); e != nil; e = e.Next() {
-			if strings.HasPrefix(c.Request.URL.Path, e.Value.(string)) {
+			if strings.HasPrefix(path, e.Value.(string)) {
 				c.Next()
 				return
 			}
@@ -85,14 +125,14 @@ func AuthMiddleware() gin.HandlerFunc {
 		// Get token from Authorization header
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Authorization header is required"})
+			abortWith(c, http.StatusUnauthorized, "missing_token", "Authorization header is required")
 			return
 		}
 
 		// Check if token is in Bearer format
 		parts := strings.Split(authHeader, " ")
 		if len(parts) != 2 || parts[0] != "Bearer" {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid authorization header format"})
+			abortWith(c, http.StatusUnauthorized, "invalid_token", "Invalid authorization header format")
 			return
 		}
 
@@ -101,14 +141,30 @@ func AuthMiddleware() gin.HandlerFunc {
 		// Verify token
 		claims, err := JWT.Verify(tokenString)
 		if err != nil {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
+			abortWith(c, http.StatusUnauthorized, "invalid_token", "Invalid or expired token")
+			return
+		}
+
+		// Every login issues session-bound tokens; tokens of a revoked or
+		// expired session stop working before they expire
+		if claims.SessionID == "" {
+			abortWith(c, http.StatusUnauthorized, "invalid_token", "Invalid or expired token")
 			return
 		}
+		if sessionCheck != nil {
+			if err := sessionCheck(c.Request.Context(), claims.UserID, claims.SessionID); err != nil {
+				abortWith(c, http.StatusUnauthorized, "session_revoked", "Session has expired or been revoked")
+				return
+			}
+		}
 
 		// Add user info to context
 		c.Set("user_id", claims.UserID)
 		c.Set("organization_id", claims.OrganizationID)
 		c.Set("role", claims.Role)
+		c.Set("session_id", claims.SessionID)
+		// Also on the request context, where services and history tracking read it
+		c.Request = c.Request.WithContext(logger.WithUserID(c.Request.Context(), claims.UserID))
 		c.Next()
 	}
 }
@@ -118,14 +174,13 @@ func AdminMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		role, exists := c.Get("role")
 		if !exists {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			abortWith(c, http.StatusUnauthorized, "", "Unauthorized")
 			return
 		}
 
 		// Check if user has admin or super_admin role
-		userRole := role.(string)
-		if userRole != "admin" && userRole != "super_admin" {
-			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Admin access required"})
+		if !IsAdminRole(role.(string)) {
+			abortWith(c, http.StatusForbidden, "admin_required", "Admin access required")
 			return
 		}
 
@@ -133,20 +188,73 @@ func AdminMiddleware() gin.HandlerFunc {
 	}
 }
 
-// RecoveryMiddleware handles panics and returns a 500 error
+// IsAdminRole reports whether a token role grants admin privileges.
+func IsAdminRole(role string) bool {
+	return role == "admin" || role == "super_admin"
+}
+
+// ClientCertMiddleware requires a verified TLS client certificate (mTLS).
+// It is a no-op unless TLS is enabled with a client CA and admin_client_auth is set.
+func ClientCertMiddleware() gin.HandlerFunc {
+	tlsCfg := Get().Server.TLS
+	if !tlsCfg.Enabled || tlsCfg.ClientCAFile == "" || !tlsCfg.AdminClientAuth {
+		return func(c *gin.Context) { c.Next() }
+	}
+
+	return func(c *gin.Context) {
+		if c.Request.TLS == nil || len(c.Request.TLS.VerifiedChains) == 0 {
+			abortWith(c, http.StatusForbidden, "client_certificate_required", "Client certificate required")
+			return
+		}
+		c.Next()
+	}
+}
+
+// RecoveryMiddleware turns a panic in a later handler into an internal error
+// for the error middleware to report; the panic and its stack are only logged.
 func RecoveryMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		defer func() {
 			if r := recover(); r != nil {
-				// Log the error
-				// logger.Error("Recovered from panic", zap.Any("error", r))
-
-				// Return a 500 error
-				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
-					"error": "Internal server error",
-				})
+				if r == http.ErrAbortHandler {
+					panic(r)
+				}
+				logger.Error("panic serving %s %s (request %s): %v\n%s", c.Request.Method, c.Request.URL.Path,
+					logger.RequestID(c.Request.Context()), r, debug.Stack())
+				_ = c.Error(&HTTPError{Status: http.StatusInternalServerError, Err: fmt.Errorf("panic: %v", r)})
+				c.Abort()
 			}
 		}()
 		c.Next()
 	}
 }
+
+// HTTPError is the error the middlewares stop a request with. The application's
+// error middleware renders it with its status and machine code; config can't
+// depend on the application's error types.
+type HTTPError struct {
+	Status int
+	// Code is the stable machine code, empty for the status' default
+	Code    string
+	Message string
+	// Err is the cause, logged but never sent to the client
+	Err error
+}
+
+func (e *HTTPError) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *HTTPError) Unwrap() error {
+	return e.Err
+}
+
+// abortWith stops the handler chain with the failure, which the error
+// middleware renders as the response.
+func abortWith(c *gin.Context, status int, code, message string) {
+	_ = c.Error(&HTTPError{Status: status, Code: code, Message: message})
+	c.Abort()
+}
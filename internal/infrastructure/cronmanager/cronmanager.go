@@ -1,7 +1,9 @@
 package cronmanager
 
 import (
+	"context"
 	"log"
+	"sync"
 	"time"
 
 	"github.com/robfig/cron/v3"
@@ -11,6 +13,41 @@ import (
 
 var c *cron.Cron
 
+// cleanupTask is a named unit of work run by the cleanup job.
+type cleanupTask struct {
+	name string
+	fn   func(ctx context.Context) error
+}
+
+var (
+	cleanupMu    sync.Mutex
+	cleanupTasks []cleanupTask
+)
+
+// OnCleanup registers fn to run on the CronJob.CleanupInterval schedule.
+// Tasks run one after another; a failing task is logged and doesn't stop the rest.
+func OnCleanup(name string, fn func(ctx context.Context) error) {
+	cleanupMu.Lock()
+	defer cleanupMu.Unlock()
+	cleanupTasks = append(cleanupTasks, cleanupTask{name: name, fn: fn})
+}
+
+// runCleanup runs the registered cleanup tasks.
+func runCleanup() {
+	cleanupMu.Lock()
+	tasks := append([]cleanupTask(nil), cleanupTasks...)
+	cleanupMu.Unlock()
+
+	for _, t := range tasks {
+		start := time.Now()
+		if err := t.fn(context.Background()); err != nil {
+			log.Printf("cron: cleanup task %s failed: %v", t.name, err)
+			continue
+		}
+		log.Printf("cron: cleanup task %s finished in %s", t.name, time.Since(start).Round(time.Millisecond))
+	}
+}
+
 // Init starts the cron scheduler and registers jobs from config.
 // If no cron jobs are configured, the scheduler won't be started.
 func Init() {
@@ -30,7 +67,7 @@ func Init() {
 	if cfg.CronJob.CleanupInterval != "" {
 		if _, err := c.AddFunc(cfg.CronJob.CleanupInterval, func() {
 			log.Println("cron: running cleanup task")
-			// TODO: add cleanup logic or call into a job package
+			runCleanup()
 		}); err != nil {
 			log.Printf("cron: failed to schedule cleanup: %v", err)
 		} else {
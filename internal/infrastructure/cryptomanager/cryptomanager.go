@@ -0,0 +1,139 @@
+package cryptomanager
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"boilerplate-golang/internal/infrastructure/config"
+)
+
+// prefix marks an encrypted value: enc:<key id>:<base64 nonce+ciphertext>.
+// Values without it are legacy plaintext and are returned as stored.
+const prefix = "enc:"
+
+var (
+	loadOnce  sync.Once
+	loadErr   error
+	activeKID string
+	aeads     map[string]cipher.AEAD
+	bidxKey   []byte
+)
+
+// load reads the keys from [encryption] once.
+func load() error {
+	loadOnce.Do(func() {
+		cfg := config.Get().Encryption
+		aeads = make(map[string]cipher.AEAD, len(cfg.Keys))
+		for kid, encoded := range cfg.Keys {
+			if strings.Contains(kid, ":") {
+				loadErr = fmt.Errorf("encryption key id %q must not contain ':'", kid)
+				return
+			}
+			key, err := base64.StdEncoding.DecodeString(encoded)
+			if err != nil || len(key) != 32 {
+				loadErr = fmt.Errorf("encryption key %q must be 32 bytes, base64 encoded", kid)
+				return
+			}
+			block, err := aes.NewCipher(key)
+			if err != nil {
+				loadErr = err
+				return
+			}
+			if aeads[kid], err = cipher.NewGCM(block); err != nil {
+				loadErr = err
+				return
+			}
+		}
+		if _, ok := aeads[cfg.ActiveKey]; !ok {
+			loadErr = fmt.Errorf("encryption.active_key %q is not one of encryption.keys", cfg.ActiveKey)
+			return
+		}
+		activeKID = cfg.ActiveKey
+
+		if bidxKey, loadErr = base64.StdEncoding.DecodeString(cfg.BlindIndexKey); loadErr != nil || len(bidxKey) < 32 {
+			loadErr = errors.New("encryption.blind_index_key must be at least 32 bytes, base64 encoded")
+		}
+	})
+	return loadErr
+}
+
+// ActiveKeyID returns the id of the key new values are encrypted with.
+func ActiveKeyID() (string, error) {
+	if err := load(); err != nil {
+		return "", err
+	}
+	return activeKID, nil
+}
+
+// Encrypt seals plaintext with AES-GCM under the active key. aad binds the
+// ciphertext to its context (e.g. table and column) so it can't be moved elsewhere.
+func Encrypt(plaintext, aad string) (string, error) {
+	if err := load(); err != nil {
+		return "", err
+	}
+	aead := aeads[activeKID]
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := aead.Seal(nonce, nonce, []byte(plaintext), []byte(aad))
+	return prefix + activeKID + ":" + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt opens a value produced by Encrypt with whichever key it names.
+// Values that were never encrypted are returned unchanged.
+func Decrypt(value, aad string) (string, error) {
+	if !strings.HasPrefix(value, prefix) {
+		return value, nil
+	}
+	if err := load(); err != nil {
+		return "", err
+	}
+	kid, encoded, ok := strings.Cut(strings.TrimPrefix(value, prefix), ":")
+	if !ok {
+		return "", errors.New("malformed encrypted value")
+	}
+	aead, ok := aeads[kid]
+	if !ok {
+		return "", fmt.Errorf("unknown encryption key %q", kid)
+	}
+	sealed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(sealed) < aead.NonceSize() {
+		return "", errors.New("malformed encrypted value")
+	}
+	plaintext, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], []byte(aad))
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt value with key %q: %w", kid, err)
+	}
+	return string(plaintext), nil
+}
+
+// KeyID returns the key id a stored value is encrypted with, or "" for plaintext.
+func KeyID(value string) string {
+	if !strings.HasPrefix(value, prefix) {
+		return ""
+	}
+	kid, _, _ := strings.Cut(strings.TrimPrefix(value, prefix), ":")
+	return kid
+}
+
+// BlindIndex returns a keyed hash of value for equality lookups on encrypted
+// columns. Values are trimmed and lower-cased first, so lookups are
+// case-insensitive like the plaintext comparisons they replace.
+func BlindIndex(value string) (string, error) {
+	if err := load(); err != nil {
+		return "", err
+	}
+	mac := hmac.New(sha256.New, bidxKey)
+	mac.Write([]byte(strings.ToLower(strings.TrimSpace(value))))
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
@@ -0,0 +1,165 @@
+package cryptomanager
+
+import (
+	"bytes"
+	"encoding/base64"
+	"strings"
+	"sync"
+	"testing"
+
+	"boilerplate-golang/internal/infrastructure/config"
+)
+
+func testKey(b byte) string {
+	return base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{b}, 32))
+}
+
+// useKeys configures the keys and reloads them, as a restart would.
+func useKeys(t *testing.T, active string, keys map[string]string, blindIndexKey string) {
+	t.Helper()
+	cfg := config.Get()
+	cfg.Encryption.ActiveKey = active
+	cfg.Encryption.Keys = keys
+	cfg.Encryption.BlindIndexKey = blindIndexKey
+	config.Set(cfg)
+	loadOnce = sync.Once{}
+}
+
+func TestEncryptDecryptRoundTrip(t *testing.T) {
+	useKeys(t, "k1", map[string]string{"k1": testKey(1)}, testKey(9))
+
+	for _, plaintext := range []string{"jane@example.com", "", "ünïcødé ✓", strings.Repeat("x", 4096)} {
+		sealed, err := Encrypt(plaintext, "users.email")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasPrefix(sealed, "enc:k1:") || (plaintext != "" && strings.Contains(sealed, plaintext)) {
+			t.Errorf("Encrypt(%q) = %q", plaintext, sealed)
+		}
+		if KeyID(sealed) != "k1" {
+			t.Errorf("KeyID = %q, want k1", KeyID(sealed))
+		}
+		got, err := Decrypt(sealed, "users.email")
+		if err != nil || got != plaintext {
+			t.Errorf("Decrypt = %q, %v, want %q", got, err, plaintext)
+		}
+	}
+
+	a, _ := Encrypt("same", "users.email")
+	b, _ := Encrypt("same", "users.email")
+	if a == b {
+		t.Error("equal plaintexts gave equal ciphertexts, the nonce is not random")
+	}
+}
+
+func TestDecryptRejects(t *testing.T) {
+	useKeys(t, "k1", map[string]string{"k1": testKey(1)}, testKey(9))
+	sealed, err := Encrypt("secret", "users.email")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body := strings.TrimPrefix(sealed, "enc:k1:")
+	raw, _ := base64.StdEncoding.DecodeString(body)
+	raw[len(raw)-1] ^= 1
+	flipped := "enc:k1:" + base64.StdEncoding.EncodeToString(raw)
+
+	tests := []struct {
+		name    string
+		value   string
+		aad     string
+		wantErr string
+	}{
+		{"other column", sealed, "users.full_name", "failed to decrypt"},
+		{"altered ciphertext", flipped, "users.email", "failed to decrypt"},
+		{"unknown key", "enc:k7:" + body, "users.email", `unknown encryption key "k7"`},
+		{"no key id", "enc:" + body, "users.email", "malformed encrypted value"},
+		{"not base64", "enc:k1:***", "users.email", "malformed encrypted value"},
+		{"too short", "enc:k1:AAAA", "users.email", "malformed encrypted value"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Decrypt(tt.value, tt.aad)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Decrypt error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDecryptPassesPlaintextThrough(t *testing.T) {
+	useKeys(t, "k1", map[string]string{"k1": testKey(1)}, testKey(9))
+	got, err := Decrypt("legacy@example.com", "users.email")
+	if err != nil || got != "legacy@example.com" {
+		t.Errorf("Decrypt of a legacy value = %q, %v", got, err)
+	}
+	if KeyID("legacy@example.com") != "" {
+		t.Error("legacy value has a key id")
+	}
+}
+
+func TestKeyRotation(t *testing.T) {
+	keys := map[string]string{"k1": testKey(1)}
+	useKeys(t, "k1", keys, testKey(9))
+	old, err := Encrypt("secret", "users.email")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keys = map[string]string{"k1": testKey(1), "k2": testKey(2)}
+	useKeys(t, "k2", keys, testKey(9))
+	fresh, err := Encrypt("secret", "users.email")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if KeyID(fresh) != "k2" {
+		t.Errorf("new value encrypted with %q, want the active key k2", KeyID(fresh))
+	}
+	if got, err := Decrypt(old, "users.email"); err != nil || got != "secret" {
+		t.Errorf("value under the retired key: %q, %v", got, err)
+	}
+}
+
+func TestBlindIndex(t *testing.T) {
+	useKeys(t, "k1", map[string]string{"k1": testKey(1)}, testKey(9))
+	a, err := BlindIndex("Jane@Example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := BlindIndex("  jane@example.com ")
+	c, _ := BlindIndex("john@example.com")
+	if a != b {
+		t.Error("blind index is not case and space insensitive")
+	}
+	if a == c || len(a) != 64 {
+		t.Errorf("blind indexes %q and %q", a, c)
+	}
+
+	// Another key gives other indexes, so they can't be precomputed
+	useKeys(t, "k1", map[string]string{"k1": testKey(1)}, testKey(8))
+	if d, _ := BlindIndex("jane@example.com"); d == a {
+		t.Error("blind index does not depend on its key")
+	}
+}
+
+func TestLoadRejectsBadConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		active  string
+		keys    map[string]string
+		bidx    string
+		wantErr string
+	}{
+		{"short key", "k1", map[string]string{"k1": base64.StdEncoding.EncodeToString([]byte("short"))}, testKey(9), "must be 32 bytes"},
+		{"colon in id", "k:1", map[string]string{"k:1": testKey(1)}, testKey(9), "must not contain ':'"},
+		{"unknown active key", "k2", map[string]string{"k1": testKey(1)}, testKey(9), "is not one of encryption.keys"},
+		{"short blind index key", "k1", map[string]string{"k1": testKey(1)}, base64.StdEncoding.EncodeToString([]byte("short")), "blind_index_key"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useKeys(t, tt.active, tt.keys, tt.bidx)
+			if _, err := Encrypt("x", "t.c"); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Encrypt error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
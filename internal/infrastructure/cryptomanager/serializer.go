@@ -0,0 +1,69 @@
+package cryptomanager
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"gorm.io/gorm/schema"
+)
+
+// SerializerName is the GORM serializer that encrypts string fields, used as
+// `gorm:"serializer:encrypted"`.
+const SerializerName = "encrypted"
+
+func init() {
+	schema.RegisterSerializer(SerializerName, Serializer{})
+}
+
+// Serializer encrypts string fields on write and decrypts them on read. The
+// table and column are bound in as associated data. Empty strings are stored
+// as is, and only whole values can be compared, so look rows up through a
+// blind index column instead.
+type Serializer struct{}
+
+// Scan implements schema.SerializerInterface.
+func (Serializer) Scan(ctx context.Context, field *schema.Field, dst reflect.Value, dbValue interface{}) error {
+	var stored string
+	switch v := dbValue.(type) {
+	case nil:
+	case string:
+		stored = v
+	case []byte:
+		stored = string(v)
+	default:
+		return fmt.Errorf("unsupported encrypted value type %T for %s", dbValue, field.Name)
+	}
+
+	plaintext, err := Decrypt(stored, aad(field))
+	if err != nil {
+		return fmt.Errorf("%s: %w", field.Name, err)
+	}
+	field.ReflectValueOf(ctx, dst).SetString(plaintext)
+	return nil
+}
+
+// Value implements schema.SerializerValuerInterface.
+func (Serializer) Value(ctx context.Context, field *schema.Field, dst reflect.Value, fieldValue interface{}) (interface{}, error) {
+	plaintext, ok := fieldValue.(string)
+	if !ok {
+		return nil, fmt.Errorf("encrypted field %s must be a string", field.Name)
+	}
+	if plaintext == "" {
+		return "", nil
+	}
+	return Encrypt(plaintext, aad(field))
+}
+
+// EncryptColumn encrypts value the way Serializer would for table.column, for
+// writes that bypass the serializer such as map updates.
+func EncryptColumn(table, column, value string) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+	return Encrypt(value, table+"."+column)
+}
+
+func aad(field *schema.Field) string {
+	return field.Schema.Table + "." + field.DBName
+}
@@ -1,59 +1,221 @@
 package dbmanager
 
 import (
+	"database/sql"
 	"fmt"
 	"log"
+	"os"
+	"strings"
+	"time"
 
+	"github.com/glebarez/sqlite"
 	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
 
-	"boilerplate-golang/internal/application/entity"
 	"boilerplate-golang/internal/infrastructure/config"
 )
 
+// Supported values for database.driver.
+const (
+	DriverMySQL    = "mysql"
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite"
+)
+
 var _db *gorm.DB
 
-// Init initializes the global GORM DB connection and runs migrations.
-// Database connection is optional and will log a warning if it fails.
+// Init initializes the global GORM DB connection and checks the schema version.
+// Database connection is optional and will log a warning if it fails, but a
+// connected database with pending migrations stops startup unless auto_migrate is on.
 func Init() {
+	if err := Connect(); err != nil {
+		log.Printf("Warning: %v", err)
+		log.Println("Application will continue to run without database connection")
+		return
+	}
+	if _db == nil {
+		return
+	}
+
+	if config.Get().Database.AutoMigrate {
+		applied, err := MigrateUp(_db, false, os.Stdout)
+		if err != nil {
+			log.Fatalf("dbmanager: migration failed: %v", err)
+		}
+		log.Printf("dbmanager: connected and applied %d migration(s)", applied)
+	} else {
+		pending, err := PendingMigrations(_db)
+		if err != nil {
+			log.Fatalf("dbmanager: failed to check schema version: %v", err)
+		}
+		if len(pending) > 0 {
+			log.Fatalf("dbmanager: database schema is behind by %d migration(s) (next: %04d_%s); run `migrate up` or enable database.auto_migrate",
+				len(pending), pending[0].Version, pending[0].Name)
+		}
+		log.Println("dbmanager: connected, schema is up to date")
+	}
+
+	// Rows from before a blind index column was added can't be found by it
+	n, err := BackfillBlindIndexes(_db, 500)
+	if err != nil {
+		log.Printf("Warning: failed to backfill blind indexes, lookups by email miss the rows without one: %v", err)
+	} else if n > 0 {
+		log.Printf("dbmanager: backfilled the blind indexes of %d row(s)", n)
+	}
+}
+
+// Connect opens the global GORM DB connection without touching the schema.
+// Pool settings apply to the primary and every read replica, and reads are
+// routed to the replicas when any are configured.
+// It is a no-op when no database host (or SQLite file) is configured.
+func Connect() error {
 	cfg := config.Get()
 
 	// Skip database initialization if host is not set
-	if cfg.Database.Host == "" {
+	if cfg.Database.Host == "" && cfg.Database.Driver != DriverSQLite {
 		log.Println("Database host not configured, skipping database connection")
-		return
+		return nil
+	}
+
+	dialector, err := openDialector(primaryEndpoint())
+	if err != nil {
+		return err
 	}
 
-	dsn := fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?charset=%s&parseTime=%t&loc=%s&timeout=%s",
-		cfg.Database.User,
-		cfg.Database.Password,
-		cfg.Database.Host,
-		cfg.Database.Port,
-		cfg.Database.Name,
-		cfg.Database.Charset,
-		cfg.Database.ParseTime,
-		cfg.Database.Loc,
-		cfg.Database.Timeout,
-	)
-
-	var err error
-	_db, err = gorm.Open(mysql.Open(dsn), &gorm.Config{
-		Logger: logger.Default.LogMode(logger.Info),
+	// Statements are logged by the observer plugin, GORM's own logger stays quiet
+	db, err := gorm.Open(dialector, &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
 	})
 	if err != nil {
-		log.Printf("Warning: Failed to connect to database: %v", err)
-		log.Println("Application will continue to run without database connection")
-		return
+		return fmt.Errorf("failed to connect to database: %w", err)
+	}
+	if err := db.Use(newObserver()); err != nil {
+		return fmt.Errorf("failed to register query observer: %w", err)
+	}
+	if err := db.Use(historian{}); err != nil {
+		return fmt.Errorf("failed to register history tracking: %w", err)
 	}
 
-	// Auto-migrate all models if database is connected
-	err = _db.AutoMigrate(
-		&entity.User{},
-	)
+	sqlDB, err := db.DB()
 	if err != nil {
+		return fmt.Errorf("failed to get sql.DB: %w", err)
+	}
+	if db.Dialector.Name() == DriverSQLite {
+		// SQLite allows a single writer; one connection avoids SQLITE_BUSY and
+		// keeps an in-memory database alive for the lifetime of the process
+		sqlDB.SetMaxOpenConns(1)
+	} else {
+		applyPoolSettings(sqlDB)
+	}
+	pools = map[string]*sql.DB{"primary": sqlDB}
+
+	if err := useReplicas(db); err != nil {
+		return err
+	}
+
+	_db = db
+	return nil
+}
+
+// endpoint identifies a database server to connect to.
+type endpoint struct {
+	Host     string
+	Port     int
+	User     string
+	Password string
+}
+
+func primaryEndpoint() endpoint {
+	cfg := config.Get()
+	return endpoint{
+		Host:     cfg.Database.Host,
+		Port:     cfg.Database.Port,
+		User:     cfg.Database.User,
+		Password: cfg.Database.Password,
+	}
+}
+
+// openDialector builds the GORM dialector for the configured driver and server.
+func openDialector(ep endpoint) (gorm.Dialector, error) {
+	cfg := config.Get()
+
+	switch cfg.Database.Driver {
+	case "", DriverMySQL:
+		dsn := fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?charset=%s&parseTime=%t&loc=%s&timeout=%s",
+			ep.User,
+			ep.Password,
+			ep.Host,
+			ep.Port,
+			cfg.Database.Name,
+			cfg.Database.Charset,
+			cfg.Database.ParseTime,
+			cfg.Database.Loc,
+			cfg.Database.Timeout,
+		)
+		return mysql.Open(dsn), nil
+	case DriverPostgres:
+		sslMode := cfg.Database.SSLMode
+		if sslMode == "" {
+			sslMode = "disable"
+		}
+		timeout, err := time.ParseDuration(cfg.Database.Timeout)
+		if err != nil {
+			timeout = 10 * time.Second
+		}
+		dsn := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s connect_timeout=%d TimeZone=UTC",
+			ep.Host,
+			ep.Port,
+			ep.User,
+			ep.Password,
+			cfg.Database.Name,
+			sslMode,
+			int(timeout.Seconds()),
+		)
+		return postgres.Open(dsn), nil
+	case DriverSQLite:
+		// Name is the database file; ":memory:" gives a throwaway in-process database
+		name := cfg.Database.Name
+		if name == "" || name == ":memory:" {
+			name = "file::memory:?cache=shared"
+		}
+		return sqlite.Open(name + sqliteParams(name)), nil
+	default:
+		return nil, fmt.Errorf("unsupported database driver %q", cfg.Database.Driver)
+	}
+}
+
+// sqliteParams enables foreign keys and waits on locks instead of failing immediately.
+func sqliteParams(name string) string {
+	sep := "?"
+	if strings.Contains(name, "?") {
+		sep = "&"
+	}
+	return sep + "_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)"
+}
+
+// Dialect returns the name of the connected database driver (mysql, postgres or sqlite).
+func Dialect() string {
+	if _db == nil {
+		return config.Get().Database.Driver
+	}
+	return _db.Dialector.Name()
+}
+
+// CaseInsensitiveLike returns a case-insensitive LIKE condition on column for the
+// connected dialect. Bind the value through EscapeLike so wildcards in user input
+// are matched literally.
+func CaseInsensitiveLike(column string) string {
+	if Dialect() == DriverPostgres {
+		return column + " ILIKE ? ESCAPE '!'"
 	}
-	log.Println("dbmanager: connected and migrated")
+	return "LOWER(" + column + ") LIKE LOWER(?) ESCAPE '!'"
+}
+
+// EscapeLike escapes LIKE wildcards in s for use with CaseInsensitiveLike.
+func EscapeLike(s string) string {
+	return strings.NewReplacer("!", "!!", "%", "!%", "_", "!_").Replace(s)
 }
 
 // GetDB is an alias for DB() to maintain backward compatibility
@@ -0,0 +1,39 @@
+// Package dbtest connects dbmanager to a throwaway SQLite database for tests.
+package dbtest
+
+import (
+	"io"
+	"path/filepath"
+	"testing"
+
+	"gorm.io/gorm"
+
+	"boilerplate-golang/internal/infrastructure/config"
+	"boilerplate-golang/internal/infrastructure/dbmanager"
+)
+
+// Open connects dbmanager to a new SQLite database with every migration
+// applied, and closes it when the test ends. Tests using it must not run in
+// parallel, the connection is global.
+func Open(t testing.TB) *gorm.DB {
+	t.Helper()
+
+	cfg := config.Get()
+	cfg.Database.Driver = dbmanager.DriverSQLite
+	cfg.Database.Name = filepath.Join(t.TempDir(), "test.db")
+	config.Set(cfg)
+
+	if err := dbmanager.Connect(); err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	db := dbmanager.GetDB()
+	if _, err := dbmanager.MigrateUp(db, false, io.Discard); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return db
+}
@@ -0,0 +1,149 @@
+package dbmanager
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"boilerplate-golang/internal/application/entity"
+)
+
+// Models lists the entities whose schema is managed by migrations.
+// Generated migrations compare these against the live database.
+var Models = []interface{}{
+	&entity.User{},
+	&entity.OutboxMessage{},
+	&entity.EntityHistory{},
+	&entity.UserSession{},
+	&entity.UserToken{},
+	&entity.UserImportJob{},
+	&entity.DataRequest{},
+	&entity.Invitation{},
+	&entity.AuditLog{},
+}
+
+var (
+	createTableRe = regexp.MustCompile("(?i)^CREATE TABLE [`\"]?(\\w+)[`\"]?")
+	addColumnRe   = regexp.MustCompile("(?i)^ALTER TABLE [`\"]?(\\w+)[`\"]? ADD [`\"]?(\\w+)[`\"]?")
+	createIndexRe = regexp.MustCompile("(?i)^CREATE (?:UNIQUE )?INDEX [`\"]?(\\w+)[`\"]? ON [`\"]?(\\w+)[`\"]?")
+)
+
+// GenerateMigration diffs Models against the connected database and writes the
+// DDL needed to bring it in line as the next numbered up/down migration in dir.
+// The files carry the dialect suffix since the DDL is database specific.
+// It returns the up file path, or an empty string if the schema is already in sync.
+func GenerateMigration(db *gorm.DB, name, dir string) (string, error) {
+	if !regexp.MustCompile(`^[a-z0-9_]+$`).MatchString(name) {
+		return "", fmt.Errorf("migration name must be lower_snake_case")
+	}
+
+	statements, err := diffSchema(primaryOnly(db))
+	if err != nil {
+		return "", fmt.Errorf("failed to diff entities: %w", err)
+	}
+	if len(statements) == 0 {
+		return "", nil
+	}
+
+	dialect := db.Dialector.Name()
+	migrations, err := LoadMigrations(dialect)
+	if err != nil {
+		return "", err
+	}
+	next := int64(1)
+	if len(migrations) > 0 {
+		next = migrations[len(migrations)-1].Version + 1
+	}
+
+	var up, down strings.Builder
+	for _, stmt := range statements {
+		up.WriteString(stmt + ";\n")
+	}
+	for i := len(statements) - 1; i >= 0; i-- {
+		down.WriteString(reverseDDL(dialect, statements[i]) + "\n")
+	}
+
+	base := filepath.Join(dir, fmt.Sprintf("%04d_%s", next, name))
+	if err := os.WriteFile(base+".up."+dialect+".sql", []byte(up.String()), 0o644); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(base+".down."+dialect+".sql", []byte(down.String()), 0o644); err != nil {
+		return "", err
+	}
+	return base + ".up." + dialect + ".sql", nil
+}
+
+// diffSchema returns the statements AutoMigrate runs to bring the database in
+// line with Models. Where DDL is transactional they run in a transaction that
+// is rolled back, so each sees the changes of the previous ones, as SQLite's
+// table rebuilds need; MySQL commits DDL, so there they are only recorded.
+func diffSchema(db *gorm.DB) ([]string, error) {
+	rec := &ddlRecorder{ConnPool: db.Statement.ConnPool, dialector: db.Dialector}
+	if db.Dialector.Name() != DriverMySQL {
+		tx := db.Begin()
+		if tx.Error != nil {
+			return nil, tx.Error
+		}
+		defer tx.Rollback()
+		rec.ConnPool, rec.execute = tx.Statement.ConnPool, true
+	}
+
+	session := db.Session(&gorm.Session{Logger: logger.Discard})
+	session.Statement.ConnPool = rec
+	if err := session.AutoMigrate(Models...); err != nil {
+		return nil, err
+	}
+	return rec.statements, nil
+}
+
+// reverseDDL returns the statement undoing a generated DDL statement.
+// Column changes can't be reversed automatically and are left for review.
+func reverseDDL(dialect, stmt string) string {
+	if m := createTableRe.FindStringSubmatch(stmt); m != nil {
+		return fmt.Sprintf("DROP TABLE IF EXISTS %s;", m[1])
+	}
+	if m := addColumnRe.FindStringSubmatch(stmt); m != nil {
+		return fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s;", m[1], m[2])
+	}
+	if m := createIndexRe.FindStringSubmatch(stmt); m != nil {
+		if dialect == DriverMySQL {
+			return fmt.Sprintf("DROP INDEX %s ON %s;", m[1], m[2])
+		}
+		return fmt.Sprintf("DROP INDEX IF EXISTS %s;", m[1])
+	}
+	return "-- TODO: write the reverse of: " + strings.ReplaceAll(stmt, "\n", " ")
+}
+
+// ddlRecorder is a connection pool that records the statements the migrator
+// executes, running them only when execute is set, while its queries read the
+// live schema. It passes for a transaction: dbresolver then leaves it in
+// place, and the transactions of the migrator become savepoints.
+type ddlRecorder struct {
+	gorm.ConnPool
+	dialector  gorm.Dialector
+	execute    bool
+	statements []string
+}
+
+func (r *ddlRecorder) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	switch strings.ToUpper(strings.SplitN(strings.TrimSpace(query), " ", 2)[0]) {
+	case "SAVEPOINT", "RELEASE", "ROLLBACK":
+	default:
+		r.statements = append(r.statements, r.dialector.Explain(query, args...))
+	}
+	if r.execute {
+		return r.ConnPool.ExecContext(ctx, query, args...)
+	}
+	return driver.RowsAffected(0), nil
+}
+
+func (r *ddlRecorder) Commit() error   { return nil }
+func (r *ddlRecorder) Rollback() error { return nil }
@@ -0,0 +1,212 @@
+package dbmanager
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+	"gorm.io/plugin/dbresolver"
+
+	"boilerplate-golang/internal/application/entity"
+	"boilerplate-golang/internal/infrastructure/logger"
+)
+
+// historyBeforeKey stores the row as it was before an update or delete.
+const historyBeforeKey = "history:before"
+
+// Tracked is implemented by entities that opt into change history. The name
+// identifies the entity in entity_histories and in the admin history API.
+//
+// Fields can opt out with the gorm tag setting history:skip (not recorded) or
+// history:mask (recorded as changed without values).
+type Tracked interface {
+	HistoryName() string
+}
+
+// masked stands in for the values of history:mask fields.
+const masked = "[changed]"
+
+// FieldChange is the old and new value of one field in a history record.
+type FieldChange struct {
+	Old interface{} `json:"old"`
+	New interface{} `json:"new"`
+}
+
+// historian is a GORM plugin that records creates, updates and deletes of
+// Tracked entities, with the acting user and request ID from the statement
+// context, in the same transaction as the change. Only statements on a single
+// row identified by its primary key are recorded, bulk updates are not.
+type historian struct{}
+
+// Name implements gorm.Plugin.
+func (historian) Name() string {
+	return "history"
+}
+
+// Initialize implements gorm.Plugin.
+func (h historian) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	if err := cb.Create().After("gorm:create").Register("history:after_create", h.afterCreate); err != nil {
+		return err
+	}
+	if err := cb.Update().Before("gorm:update").Register("history:before_update", h.before); err != nil {
+		return err
+	}
+	if err := cb.Update().After("gorm:update").Register("history:after_update", h.after(entity.HistoryUpdate)); err != nil {
+		return err
+	}
+	if err := cb.Delete().Before("gorm:delete").Register("history:before_delete", h.before); err != nil {
+		return err
+	}
+	return cb.Delete().After("gorm:delete").Register("history:after_delete", h.after(entity.HistoryDelete))
+}
+
+// TrackedEntities returns the history names of the tracked models.
+func TrackedEntities() []string {
+	var names []string
+	for _, m := range Models {
+		if t, ok := m.(Tracked); ok {
+			names = append(names, t.HistoryName())
+		}
+	}
+	return names
+}
+
+func (h historian) afterCreate(db *gorm.DB) {
+	name, id, ok := trackedRow(db)
+	if !ok || db.Error != nil {
+		return
+	}
+	changes := diff(db.Statement, nil, db.Statement.ReflectValue)
+	h.record(db, name, id, entity.HistoryCreate, changes)
+}
+
+func (h historian) before(db *gorm.DB) {
+	if _, id, ok := trackedRow(db); ok {
+		if row, err := loadRow(db, id); err == nil {
+			db.InstanceSet(historyBeforeKey, row)
+		}
+	}
+}
+
+func (h historian) after(action string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		v, ok := db.InstanceGet(historyBeforeKey)
+		if !ok || db.Error != nil || db.Statement.RowsAffected == 0 {
+			return
+		}
+		name, id, _ := trackedRow(db)
+		before := v.(reflect.Value)
+
+		// A soft delete leaves the row in place, so compare against it as well
+		var afterRow reflect.Value
+		if row, err := loadRow(db, id); err == nil {
+			afterRow = row
+		}
+		changes := diff(db.Statement, &before, afterRow)
+		if len(changes) == 0 {
+			return
+		}
+		h.record(db, name, id, action, changes)
+	}
+}
+
+// record writes the history row on the statement's connection, inside its transaction.
+func (h historian) record(db *gorm.DB, name, id, action string, changes map[string]FieldChange) {
+	data, err := json.Marshal(changes)
+	if err != nil {
+		db.AddError(fmt.Errorf("history: %w", err))
+		return
+	}
+	ctx := db.Statement.Context
+	row := entity.EntityHistory{
+		Entity:    name,
+		EntityID:  id,
+		Action:    action,
+		Changes:   string(data),
+		ActorID:   logger.UserID(ctx),
+		RequestID: logger.RequestID(ctx),
+		CreatedAt: time.Now().UTC(),
+	}
+	if err := db.Session(&gorm.Session{NewDB: true}).Create(&row).Error; err != nil {
+		db.AddError(fmt.Errorf("history: %w", err))
+	}
+}
+
+// trackedRow returns the history name and primary key of the single row the
+// statement works on, if its model is Tracked.
+func trackedRow(db *gorm.DB) (string, string, bool) {
+	stmt := db.Statement
+	if stmt.Schema == nil || stmt.Schema.PrioritizedPrimaryField == nil ||
+		stmt.ReflectValue.Kind() != reflect.Struct || !stmt.ReflectValue.CanAddr() {
+		return "", "", false
+	}
+	t, ok := stmt.ReflectValue.Addr().Interface().(Tracked)
+	if !ok {
+		return "", "", false
+	}
+	pk, zero := stmt.Schema.PrioritizedPrimaryField.ValueOf(stmt.Context, stmt.ReflectValue)
+	if zero {
+		return "", "", false
+	}
+	return t.HistoryName(), fmt.Sprint(pk), true
+}
+
+// loadRow reads the current state of the row, including soft-deleted ones.
+// The read goes to the primary: a replica may not have the row, or the
+// change the statement just made, yet.
+func loadRow(db *gorm.DB, id string) (reflect.Value, error) {
+	sch := db.Statement.Schema
+	row := reflect.New(sch.ModelType)
+	err := db.Session(&gorm.Session{NewDB: true}).Clauses(dbresolver.Write).Unscoped().
+		Where(sch.PrioritizedPrimaryField.DBName+" = ?", id).Take(row.Interface()).Error
+	return row.Elem(), err
+}
+
+// diff compares two states of a row field by field; a missing before or after
+// state (create, hard delete) reports every field as set or cleared.
+func diff(stmt *gorm.Statement, before *reflect.Value, after reflect.Value) map[string]FieldChange {
+	changes := make(map[string]FieldChange)
+	for _, f := range stmt.Schema.Fields {
+		if f.DBName == "" || f.TagSettings["HISTORY"] == "skip" {
+			continue
+		}
+
+		var oldValue, newValue interface{}
+		if before != nil {
+			oldValue = fieldValue(stmt, f, *before)
+		}
+		if after.IsValid() {
+			newValue = fieldValue(stmt, f, after)
+		}
+		if reflect.DeepEqual(oldValue, newValue) {
+			continue
+		}
+		if f.TagSettings["HISTORY"] == "mask" {
+			changes[f.DBName] = FieldChange{Old: masked, New: masked}
+			continue
+		}
+		changes[f.DBName] = FieldChange{Old: oldValue, New: newValue}
+	}
+	return changes
+}
+
+// fieldValue returns the comparable value of a field, nil for zero values.
+// It reads the Go value directly; ValueOf would wrap serializer fields.
+func fieldValue(stmt *gorm.Statement, f *schema.Field, row reflect.Value) interface{} {
+	fv := f.ReflectValueOf(stmt.Context, row)
+	if fv.IsZero() {
+		return nil
+	}
+	if fv.Kind() == reflect.Ptr {
+		fv = fv.Elem()
+	}
+	v := fv.Interface()
+	if t, ok := v.(time.Time); ok {
+		return t.UTC().Truncate(time.Millisecond)
+	}
+	return v
+}
@@ -0,0 +1,385 @@
+package dbmanager
+
+import (
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+//go:embed migrations/*.sql
+var migrationFS embed.FS
+
+// migrationsDir is the embedded directory holding the versioned SQL files.
+const migrationsDir = "migrations"
+
+// migrationLockName identifies the advisory lock held while migrating.
+const migrationLockName = "schema_migrations"
+
+// migrationFileRe matches files such as 0002_add_user_locale.up.sql. A dialect
+// suffix (0002_add_user_locale.up.postgres.sql) overrides the portable file for
+// that database only.
+var migrationFileRe = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)(?:\.(mysql|postgres|sqlite))?\.sql$`)
+
+// migrationLockKey is the PostgreSQL advisory lock key, an arbitrary constant.
+// Every release must use the same one, or two of them could migrate at once.
+const migrationLockKey int64 = 0x2c9e4d5b
+
+// Migration is a single versioned schema change embedded in the binary.
+type Migration struct {
+	Version  int64
+	Name     string
+	Up       string
+	Down     string
+	Checksum string
+}
+
+// MigrationStatus reports whether a migration has been applied.
+type MigrationStatus struct {
+	Migration
+	Applied   bool
+	AppliedAt *time.Time
+}
+
+// schemaMigration is a row of the schema_migrations bookkeeping table.
+type schemaMigration struct {
+	Version   int64     `gorm:"column:version;primaryKey;autoIncrement:false"`
+	Name      string    `gorm:"column:name;type:varchar(255)"`
+	Checksum  string    `gorm:"column:checksum;type:varchar(64)"`
+	AppliedAt time.Time `gorm:"column:applied_at"`
+}
+
+// TableName specifies the table name for the schemaMigration model
+func (schemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// LoadMigrations returns the embedded migrations for dialect ordered by version.
+func LoadMigrations(dialect string) ([]Migration, error) {
+	entries, err := fs.ReadDir(migrationFS, migrationsDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	byVersion := make(map[int64]*Migration)
+	overridden := make(map[string]bool)
+	for _, e := range entries {
+		m := migrationFileRe.FindStringSubmatch(e.Name())
+		if m == nil {
+			return nil, fmt.Errorf("invalid migration file name: %s", e.Name())
+		}
+		if m[4] != "" && m[4] != dialect {
+			continue
+		}
+		version, _ := strconv.ParseInt(m[1], 10, 64)
+
+		// Dialect-specific files win over the portable one regardless of order
+		key := m[1] + "." + m[3]
+		if m[4] == "" && overridden[key] {
+			continue
+		}
+		if m[4] != "" {
+			overridden[key] = true
+		}
+
+		content, err := fs.ReadFile(migrationFS, path.Join(migrationsDir, e.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %w", e.Name(), err)
+		}
+
+		mig, ok := byVersion[version]
+		if !ok {
+			mig = &Migration{Version: version, Name: m[2]}
+			byVersion[version] = mig
+		} else if mig.Name != m[2] {
+			return nil, fmt.Errorf("migration version %d used by both %s and %s", version, mig.Name, m[2])
+		}
+		if m[3] == "up" {
+			mig.Up = string(content)
+		} else {
+			mig.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, mig := range byVersion {
+		if mig.Up == "" {
+			return nil, fmt.Errorf("migration %04d_%s has no up file for %s", mig.Version, mig.Name, dialect)
+		}
+		sum := sha256.Sum256([]byte(mig.Up))
+		mig.Checksum = hex.EncodeToString(sum[:])
+		migrations = append(migrations, *mig)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// MigrationStatuses lists every embedded migration with its applied state.
+// It fails if an applied migration no longer matches its recorded checksum.
+func MigrationStatuses(db *gorm.DB) ([]MigrationStatus, error) {
+	db = primaryOnly(db)
+	migrations, err := LoadMigrations(db.Dialector.Name())
+	if err != nil {
+		return nil, err
+	}
+
+	// Before the first migration nothing is applied; the table is only
+	// created by migrating, so statuses and dry runs never write
+	var applied []schemaMigration
+	if db.Migrator().HasTable(&schemaMigration{}) {
+		if err := db.Order("version").Find(&applied).Error; err != nil {
+			return nil, fmt.Errorf("failed to read schema_migrations: %w", err)
+		}
+	}
+	appliedByVersion := make(map[int64]schemaMigration, len(applied))
+	for _, a := range applied {
+		appliedByVersion[a.Version] = a
+	}
+
+	statuses := make([]MigrationStatus, len(migrations))
+	for i, m := range migrations {
+		statuses[i] = MigrationStatus{Migration: m}
+		a, ok := appliedByVersion[m.Version]
+		if !ok {
+			continue
+		}
+		if a.Checksum != m.Checksum {
+			return nil, fmt.Errorf("migration %04d_%s was modified after being applied (checksum mismatch)", m.Version, m.Name)
+		}
+		appliedAt := a.AppliedAt
+		statuses[i].Applied = true
+		statuses[i].AppliedAt = &appliedAt
+		delete(appliedByVersion, m.Version)
+	}
+	for version := range appliedByVersion {
+		log.Printf("Warning: migration %04d is applied in the database but unknown to this binary", version)
+	}
+	return statuses, nil
+}
+
+// PendingMigrations returns the migrations that have not been applied yet.
+func PendingMigrations(db *gorm.DB) ([]Migration, error) {
+	statuses, err := MigrationStatuses(db)
+	if err != nil {
+		return nil, err
+	}
+
+	var pending []Migration
+	for _, s := range statuses {
+		if !s.Applied {
+			pending = append(pending, s.Migration)
+		}
+	}
+	return pending, nil
+}
+
+// MigrateUp applies all pending migrations in order while holding the migration lock.
+// With dryRun set, the SQL is written to w and nothing is executed.
+func MigrateUp(db *gorm.DB, dryRun bool, w io.Writer) (int, error) {
+	db = primaryOnly(db)
+	if dryRun {
+		pending, err := PendingMigrations(db)
+		if err != nil {
+			return 0, err
+		}
+		for _, m := range pending {
+			fmt.Fprintf(w, "-- %04d_%s (up)\n%s\n", m.Version, m.Name, strings.TrimSpace(m.Up))
+		}
+		return len(pending), nil
+	}
+
+	applied := 0
+	err := withMigrationLock(db, func(conn *gorm.DB) error {
+		if err := conn.AutoMigrate(&schemaMigration{}); err != nil {
+			return fmt.Errorf("failed to create schema_migrations table: %w", err)
+		}
+		// Re-read under the lock, another replica may have migrated meanwhile
+		pending, err := PendingMigrations(conn)
+		if err != nil {
+			return err
+		}
+		for _, m := range pending {
+			if err := applyMigration(conn, m, true); err != nil {
+				return err
+			}
+			log.Printf("dbmanager: applied migration %04d_%s", m.Version, m.Name)
+			applied++
+		}
+		return nil
+	})
+	return applied, err
+}
+
+// MigrateDown rolls back the last steps applied migrations while holding the migration lock.
+// With dryRun set, the SQL is written to w and nothing is executed.
+func MigrateDown(db *gorm.DB, steps int, dryRun bool, w io.Writer) (int, error) {
+	db = primaryOnly(db)
+	rollback := func(conn *gorm.DB) ([]Migration, error) {
+		statuses, err := MigrationStatuses(conn)
+		if err != nil {
+			return nil, err
+		}
+		var targets []Migration
+		for i := len(statuses) - 1; i >= 0 && len(targets) < steps; i-- {
+			if statuses[i].Applied {
+				targets = append(targets, statuses[i].Migration)
+			}
+		}
+		return targets, nil
+	}
+
+	if dryRun {
+		targets, err := rollback(db)
+		if err != nil {
+			return 0, err
+		}
+		for _, m := range targets {
+			fmt.Fprintf(w, "-- %04d_%s (down)\n%s\n", m.Version, m.Name, strings.TrimSpace(m.Down))
+		}
+		return len(targets), nil
+	}
+
+	reverted := 0
+	err := withMigrationLock(db, func(conn *gorm.DB) error {
+		targets, err := rollback(conn)
+		if err != nil {
+			return err
+		}
+		for _, m := range targets {
+			if strings.TrimSpace(m.Down) == "" {
+				return fmt.Errorf("migration %04d_%s has no down file", m.Version, m.Name)
+			}
+			if err := applyMigration(conn, m, false); err != nil {
+				return err
+			}
+			log.Printf("dbmanager: reverted migration %04d_%s", m.Version, m.Name)
+			reverted++
+		}
+		return nil
+	})
+	return reverted, err
+}
+
+// applyMigration runs the up or down SQL of a migration and records the result.
+// Statements run in a transaction where the database supports transactional DDL.
+func applyMigration(db *gorm.DB, m Migration, up bool) error {
+	sql := m.Down
+	if up {
+		sql = m.Up
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		for _, stmt := range splitStatements(sql) {
+			if err := tx.Exec(stmt).Error; err != nil {
+				return fmt.Errorf("migration %04d_%s failed: %w", m.Version, m.Name, err)
+			}
+		}
+		if up {
+			return tx.Create(&schemaMigration{
+				Version:   m.Version,
+				Name:      m.Name,
+				Checksum:  m.Checksum,
+				AppliedAt: time.Now().UTC(),
+			}).Error
+		}
+		return tx.Delete(&schemaMigration{}, "version = ?", m.Version).Error
+	})
+}
+
+// withMigrationLock runs fn on a single connection holding a database-wide
+// advisory lock so that replicas starting together don't migrate concurrently.
+// SQLite is single-process, so its own write lock is sufficient.
+func withMigrationLock(db *gorm.DB, fn func(conn *gorm.DB) error) error {
+	return db.Connection(func(conn *gorm.DB) error {
+		switch conn.Dialector.Name() {
+		case DriverMySQL:
+			var acquired int
+			if err := conn.Raw("SELECT GET_LOCK(?, ?)", migrationLockName, 60).Scan(&acquired).Error; err != nil {
+				return fmt.Errorf("failed to acquire migration lock: %w", err)
+			}
+			if acquired != 1 {
+				return fmt.Errorf("timed out waiting for migration lock")
+			}
+			defer conn.Exec("SELECT RELEASE_LOCK(?)", migrationLockName)
+		case DriverPostgres:
+			if err := acquirePostgresLock(conn, 60*time.Second); err != nil {
+				return err
+			}
+			defer conn.Exec("SELECT pg_advisory_unlock(?)", migrationLockKey)
+		}
+
+		return fn(conn)
+	})
+}
+
+// acquirePostgresLock polls pg_try_advisory_lock so waiting is bounded.
+func acquirePostgresLock(conn *gorm.DB, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		var acquired bool
+		if err := conn.Raw("SELECT pg_try_advisory_lock(?)", migrationLockKey).Scan(&acquired).Error; err != nil {
+			return fmt.Errorf("failed to acquire migration lock: %w", err)
+		}
+		if acquired {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("timed out waiting for migration lock")
+		}
+		time.Sleep(time.Second)
+	}
+}
+
+// splitStatements splits a migration file into individual statements on
+// semicolons, ignoring semicolons inside quotes and comments.
+func splitStatements(sql string) []string {
+	var (
+		statements []string
+		current    strings.Builder
+		quote      rune
+		lineCmt    bool
+	)
+
+	runes := []rune(sql)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case lineCmt:
+			if r == '\n' {
+				lineCmt = false
+			}
+			continue
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '-' && i+1 < len(runes) && runes[i+1] == '-':
+			lineCmt = true
+			continue
+		case r == '\'' || r == '"' || r == '`':
+			quote = r
+		case r == ';':
+			if stmt := strings.TrimSpace(current.String()); stmt != "" {
+				statements = append(statements, stmt)
+			}
+			current.Reset()
+			continue
+		}
+		current.WriteRune(r)
+	}
+	if stmt := strings.TrimSpace(current.String()); stmt != "" {
+		statements = append(statements, stmt)
+	}
+	return statements
+}
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS `users` (
+  `id` varchar(255) NOT NULL COMMENT 'Primary Key',
+  `username` varchar(50) DEFAULT NULL COMMENT 'username to login',
+  `email` varchar(100) DEFAULT NULL COMMENT 'email to login',
+  `password` varchar(255) DEFAULT NULL COMMENT 'password to login',
+  `full_name` varchar(100) DEFAULT NULL COMMENT 'full name',
+  `is_active` boolean DEFAULT NULL COMMENT 'is active',
+  `is_admin` boolean DEFAULT NULL COMMENT 'is admin',
+  `last_login` timestamp NULL DEFAULT NULL COMMENT 'last login',
+  `created_at` timestamp NULL DEFAULT NULL COMMENT 'created at',
+  `updated_at` timestamp NULL DEFAULT NULL COMMENT 'updated at',
+  `deleted_at` timestamp NULL DEFAULT NULL COMMENT 'deleted at',
+  PRIMARY KEY (`id`)
+);
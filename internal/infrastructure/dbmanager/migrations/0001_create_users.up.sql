@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS users (
+  id VARCHAR(255) NOT NULL PRIMARY KEY,
+  username VARCHAR(50),
+  email VARCHAR(100),
+  password VARCHAR(255),
+  full_name VARCHAR(100),
+  is_active BOOLEAN,
+  is_admin BOOLEAN,
+  last_login TIMESTAMP,
+  created_at TIMESTAMP,
+  updated_at TIMESTAMP,
+  deleted_at TIMESTAMP
+);
@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS `outbox_messages` (
+  `id` bigint NOT NULL AUTO_INCREMENT COMMENT 'Primary Key, defines publish order',
+  `event_id` varchar(36) NOT NULL COMMENT 'event id for consumer deduplication',
+  `aggregate_type` varchar(100) NOT NULL COMMENT 'aggregate type',
+  `aggregate_id` varchar(255) NOT NULL COMMENT 'aggregate id',
+  `event_type` varchar(100) NOT NULL COMMENT 'event type',
+  `payload` text COMMENT 'event payload as JSON',
+  `status` varchar(20) NOT NULL COMMENT 'pending, published or dead',
+  `attempts` int NOT NULL DEFAULT 0 COMMENT 'publish attempts',
+  `last_error` text COMMENT 'last publish error',
+  `available_at` timestamp NULL DEFAULT NULL COMMENT 'next publish attempt',
+  `created_at` timestamp NULL DEFAULT NULL COMMENT 'created at',
+  `published_at` timestamp NULL DEFAULT NULL COMMENT 'published at',
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `idx_outbox_messages_event_id` (`event_id`),
+  KEY `idx_outbox_messages_status` (`status`, `id`),
+  KEY `idx_outbox_messages_aggregate` (`aggregate_type`, `aggregate_id`)
+);
@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS outbox_messages (
+  id BIGSERIAL PRIMARY KEY,
+  event_id VARCHAR(36) NOT NULL,
+  aggregate_type VARCHAR(100) NOT NULL,
+  aggregate_id VARCHAR(255) NOT NULL,
+  event_type VARCHAR(100) NOT NULL,
+  payload TEXT,
+  status VARCHAR(20) NOT NULL,
+  attempts INTEGER NOT NULL DEFAULT 0,
+  last_error TEXT,
+  available_at TIMESTAMP,
+  created_at TIMESTAMP,
+  published_at TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_outbox_messages_event_id ON outbox_messages (event_id);
+CREATE INDEX IF NOT EXISTS idx_outbox_messages_status ON outbox_messages (status, id);
+CREATE INDEX IF NOT EXISTS idx_outbox_messages_aggregate ON outbox_messages (aggregate_type, aggregate_id);
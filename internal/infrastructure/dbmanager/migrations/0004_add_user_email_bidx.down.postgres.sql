@@ -0,0 +1,3 @@
+-- email and full_name stay widened: encrypted values would not fit the old size
+DROP INDEX idx_users_email_bidx;
+ALTER TABLE users DROP COLUMN email_bidx;
@@ -0,0 +1,2 @@
+DROP INDEX idx_users_email_bidx;
+ALTER TABLE users DROP COLUMN email_bidx;
@@ -0,0 +1,5 @@
+-- Encrypted values are longer than the plaintext they replace
+ALTER TABLE `users` MODIFY COLUMN `email` varchar(512) DEFAULT NULL COMMENT 'email to login, encrypted';
+ALTER TABLE `users` MODIFY COLUMN `full_name` varchar(512) DEFAULT NULL COMMENT 'full name, encrypted';
+ALTER TABLE `users` ADD COLUMN `email_bidx` varchar(64) DEFAULT NULL COMMENT 'blind index of email';
+CREATE INDEX `idx_users_email_bidx` ON `users` (`email_bidx`);
@@ -0,0 +1,5 @@
+-- Encrypted values are longer than the plaintext they replace
+ALTER TABLE users ALTER COLUMN email TYPE VARCHAR(512);
+ALTER TABLE users ALTER COLUMN full_name TYPE VARCHAR(512);
+ALTER TABLE users ADD COLUMN email_bidx VARCHAR(64);
+CREATE INDEX idx_users_email_bidx ON users (email_bidx);
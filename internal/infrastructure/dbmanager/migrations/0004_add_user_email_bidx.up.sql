@@ -0,0 +1,3 @@
+-- SQLite doesn't enforce VARCHAR lengths, so encrypted values fit as is
+ALTER TABLE users ADD COLUMN email_bidx VARCHAR(64);
+CREATE INDEX idx_users_email_bidx ON users (email_bidx);
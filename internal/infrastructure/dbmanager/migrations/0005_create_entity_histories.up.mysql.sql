@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS `entity_histories` (
+  `id` bigint NOT NULL AUTO_INCREMENT COMMENT 'Primary Key',
+  `entity` varchar(100) NOT NULL COMMENT 'entity name, e.g. user',
+  `entity_id` varchar(255) NOT NULL COMMENT 'primary key of the changed row',
+  `action` varchar(20) NOT NULL COMMENT 'create, update or delete',
+  `changes` text COMMENT 'changed fields with old and new values, encrypted',
+  `actor_id` varchar(255) DEFAULT NULL COMMENT 'user who made the change',
+  `request_id` varchar(128) DEFAULT NULL COMMENT 'request the change was made in',
+  `created_at` timestamp NULL DEFAULT NULL COMMENT 'created at',
+  PRIMARY KEY (`id`),
+  KEY `idx_entity_histories_entity` (`entity`, `entity_id`, `id`)
+);
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS entity_histories (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  entity VARCHAR(100) NOT NULL,
+  entity_id VARCHAR(255) NOT NULL,
+  action VARCHAR(20) NOT NULL,
+  changes TEXT,
+  actor_id VARCHAR(255),
+  request_id VARCHAR(128),
+  created_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_entity_histories_entity ON entity_histories (entity, entity_id, id);
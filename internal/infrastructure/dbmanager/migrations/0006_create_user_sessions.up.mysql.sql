@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS `user_sessions` (
+  `id` varchar(255) NOT NULL COMMENT 'Primary Key',
+  `user_id` varchar(255) NOT NULL COMMENT 'owner of the session',
+  `user_agent` varchar(255) DEFAULT NULL COMMENT 'client the session was created from',
+  `ip` varchar(64) DEFAULT NULL COMMENT 'client IP at login',
+  `created_at` timestamp NULL DEFAULT NULL COMMENT 'created at',
+  `expires_at` timestamp NULL DEFAULT NULL COMMENT 'session end',
+  `revoked_at` timestamp NULL DEFAULT NULL COMMENT 'set when the session was ended early',
+  PRIMARY KEY (`id`),
+  KEY `idx_user_sessions_user_id` (`user_id`)
+);
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS user_sessions (
+  id VARCHAR(255) NOT NULL PRIMARY KEY,
+  user_id VARCHAR(255) NOT NULL,
+  user_agent VARCHAR(255),
+  ip VARCHAR(64),
+  created_at TIMESTAMP,
+  expires_at TIMESTAMP,
+  revoked_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_sessions_user_id ON user_sessions (user_id);
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS `user_tokens` (
+  `id` varchar(255) NOT NULL COMMENT 'Primary Key',
+  `user_id` varchar(255) NOT NULL COMMENT 'user the token was issued to',
+  `purpose` varchar(30) NOT NULL COMMENT 'what the token confirms, e.g. email_change',
+  `token_hash` varchar(64) NOT NULL COMMENT 'SHA-256 of the secret',
+  `payload` text COMMENT 'data applied on use, encrypted',
+  `created_at` timestamp NULL DEFAULT NULL COMMENT 'created at',
+  `expires_at` timestamp NULL DEFAULT NULL COMMENT 'token end',
+  `used_at` timestamp NULL DEFAULT NULL COMMENT 'set when the token was used or superseded',
+  PRIMARY KEY (`id`),
+  KEY `idx_user_tokens_user_id` (`user_id`),
+  UNIQUE KEY `idx_user_tokens_token_hash` (`token_hash`)
+);
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS user_tokens (
+  id VARCHAR(255) NOT NULL PRIMARY KEY,
+  user_id VARCHAR(255) NOT NULL,
+  purpose VARCHAR(30) NOT NULL,
+  token_hash VARCHAR(64) NOT NULL,
+  payload TEXT,
+  created_at TIMESTAMP,
+  expires_at TIMESTAMP,
+  used_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_tokens_user_id ON user_tokens (user_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_user_tokens_token_hash ON user_tokens (token_hash);
@@ -0,0 +1,5 @@
+ALTER TABLE `users`
+  ADD COLUMN `locale` varchar(35) DEFAULT NULL COMMENT 'preferred language, BCP 47 tag',
+  ADD COLUMN `timezone` varchar(64) DEFAULT NULL COMMENT 'IANA time zone',
+  ADD COLUMN `bio` varchar(500) DEFAULT NULL COMMENT 'profile text',
+  ADD COLUMN `avatar_key` varchar(255) DEFAULT NULL COMMENT 'storage key prefix of the avatar variants';
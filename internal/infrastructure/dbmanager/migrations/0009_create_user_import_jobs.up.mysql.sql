@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS `user_import_jobs` (
+  `id` varchar(255) NOT NULL COMMENT 'Primary Key',
+  `created_by` varchar(255) DEFAULT NULL COMMENT 'admin who started the import',
+  `filename` varchar(255) DEFAULT NULL COMMENT 'uploaded file name',
+  `status` varchar(20) NOT NULL COMMENT 'pending, running, completed or failed',
+  `send_invites` tinyint(1) DEFAULT NULL COMMENT 'mail created users a link to set their password',
+  `total` bigint NOT NULL DEFAULT 0 COMMENT 'data rows in the file',
+  `processed` bigint NOT NULL DEFAULT 0 COMMENT 'rows handled so far',
+  `created` bigint NOT NULL DEFAULT 0 COMMENT 'users created',
+  `skipped` bigint NOT NULL DEFAULT 0 COMMENT 'rows rejected by validation',
+  `failed` bigint NOT NULL DEFAULT 0 COMMENT 'rows whose user could not be created',
+  `errors` text COMMENT 'row errors as JSON',
+  `created_at` timestamp NULL DEFAULT NULL COMMENT 'created at',
+  `updated_at` timestamp NULL DEFAULT NULL COMMENT 'last progress update',
+  `started_at` timestamp NULL DEFAULT NULL COMMENT 'processing start',
+  `finished_at` timestamp NULL DEFAULT NULL COMMENT 'processing end',
+  PRIMARY KEY (`id`),
+  KEY `idx_user_import_jobs_created_by` (`created_by`)
+);
@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS user_import_jobs (
+  id VARCHAR(255) NOT NULL PRIMARY KEY,
+  created_by VARCHAR(255),
+  filename VARCHAR(255),
+  status VARCHAR(20) NOT NULL,
+  send_invites BOOLEAN,
+  total INTEGER NOT NULL DEFAULT 0,
+  processed INTEGER NOT NULL DEFAULT 0,
+  created INTEGER NOT NULL DEFAULT 0,
+  skipped INTEGER NOT NULL DEFAULT 0,
+  failed INTEGER NOT NULL DEFAULT 0,
+  errors TEXT,
+  created_at TIMESTAMP,
+  updated_at TIMESTAMP,
+  started_at TIMESTAMP,
+  finished_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_import_jobs_created_by ON user_import_jobs (created_by);
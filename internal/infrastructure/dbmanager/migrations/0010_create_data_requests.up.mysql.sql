@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS `data_requests` (
+  `id` varchar(255) NOT NULL COMMENT 'Primary Key',
+  `user_id` varchar(255) NOT NULL COMMENT 'user the data belongs to',
+  `kind` varchar(20) NOT NULL COMMENT 'export or erasure',
+  `status` varchar(20) NOT NULL COMMENT 'request status',
+  `file_key` varchar(255) DEFAULT NULL COMMENT 'storage key of the export archive',
+  `note` varchar(500) DEFAULT NULL COMMENT 'reason given when rejected',
+  `reviewed_by` varchar(255) DEFAULT NULL COMMENT 'admin who approved or rejected the erasure',
+  `reviewed_at` timestamp NULL DEFAULT NULL COMMENT 'approved or rejected at',
+  `scheduled_for` timestamp NULL DEFAULT NULL COMMENT 'end of the erasure grace period',
+  `expires_at` timestamp NULL DEFAULT NULL COMMENT 'export archive deleted at',
+  `completed_at` timestamp NULL DEFAULT NULL COMMENT 'export built or erasure carried out at',
+  `created_at` timestamp NULL DEFAULT NULL COMMENT 'created at',
+  `updated_at` timestamp NULL DEFAULT NULL COMMENT 'updated at',
+  PRIMARY KEY (`id`),
+  KEY `idx_data_requests_user_id` (`user_id`),
+  KEY `idx_data_requests_status` (`status`)
+);
@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS data_requests (
+  id VARCHAR(255) NOT NULL PRIMARY KEY,
+  user_id VARCHAR(255) NOT NULL,
+  kind VARCHAR(20) NOT NULL,
+  status VARCHAR(20) NOT NULL,
+  file_key VARCHAR(255),
+  note VARCHAR(500),
+  reviewed_by VARCHAR(255),
+  reviewed_at TIMESTAMP,
+  scheduled_for TIMESTAMP,
+  expires_at TIMESTAMP,
+  completed_at TIMESTAMP,
+  created_at TIMESTAMP,
+  updated_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_data_requests_user_id ON data_requests (user_id);
+CREATE INDEX IF NOT EXISTS idx_data_requests_status ON data_requests (status);
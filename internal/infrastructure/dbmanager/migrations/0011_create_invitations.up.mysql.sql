@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS `invitations` (
+  `id` varchar(255) NOT NULL COMMENT 'Primary Key',
+  `email` varchar(512) DEFAULT NULL COMMENT 'invited email, encrypted',
+  `email_bidx` varchar(64) DEFAULT NULL COMMENT 'blind index of email',
+  `role` varchar(20) NOT NULL COMMENT 'role granted on accept, user or admin',
+  `org_id` varchar(255) DEFAULT NULL COMMENT 'organization invited to, if any',
+  `invited_by` varchar(255) NOT NULL COMMENT 'user who sent the invitation',
+  `status` varchar(20) NOT NULL COMMENT 'pending, accepted or revoked',
+  `token_hash` varchar(64) NOT NULL COMMENT 'SHA-256 of the link secret',
+  `sent_count` int NOT NULL DEFAULT 1 COMMENT 'times the invitation was mailed',
+  `accepted_by` varchar(255) DEFAULT NULL COMMENT 'user created or linked on accept',
+  `accepted_at` timestamp NULL DEFAULT NULL COMMENT 'accepted at',
+  `revoked_at` timestamp NULL DEFAULT NULL COMMENT 'revoked at',
+  `expires_at` timestamp NULL DEFAULT NULL COMMENT 'link end',
+  `created_at` timestamp NULL DEFAULT NULL COMMENT 'created at',
+  `updated_at` timestamp NULL DEFAULT NULL COMMENT 'updated at',
+  PRIMARY KEY (`id`),
+  KEY `idx_invitations_email_bidx` (`email_bidx`),
+  KEY `idx_invitations_status` (`status`),
+  UNIQUE KEY `idx_invitations_token_hash` (`token_hash`)
+);
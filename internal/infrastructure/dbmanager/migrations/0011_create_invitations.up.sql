@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS invitations (
+  id VARCHAR(255) NOT NULL PRIMARY KEY,
+  email VARCHAR(512),
+  email_bidx VARCHAR(64),
+  role VARCHAR(20) NOT NULL,
+  org_id VARCHAR(255),
+  invited_by VARCHAR(255) NOT NULL,
+  status VARCHAR(20) NOT NULL,
+  token_hash VARCHAR(64) NOT NULL,
+  sent_count INTEGER NOT NULL DEFAULT 1,
+  accepted_by VARCHAR(255),
+  accepted_at TIMESTAMP,
+  revoked_at TIMESTAMP,
+  expires_at TIMESTAMP,
+  created_at TIMESTAMP,
+  updated_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_invitations_email_bidx ON invitations (email_bidx);
+CREATE INDEX IF NOT EXISTS idx_invitations_status ON invitations (status);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_invitations_token_hash ON invitations (token_hash);
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS `audit_logs` (
+  `id` bigint NOT NULL AUTO_INCREMENT COMMENT 'Primary Key',
+  `actor_id` varchar(255) DEFAULT NULL COMMENT 'user who performed the action',
+  `action` varchar(100) NOT NULL COMMENT 'what was done, e.g. user.suspend',
+  `target_type` varchar(100) NOT NULL COMMENT 'kind of record acted on, e.g. user',
+  `target_id` varchar(255) NOT NULL COMMENT 'primary key of the record acted on',
+  `details` text COMMENT 'action details, encrypted',
+  `ip` varchar(64) DEFAULT NULL COMMENT 'client IP of the request',
+  `request_id` varchar(128) DEFAULT NULL COMMENT 'request the action was made in',
+  `created_at` timestamp NULL DEFAULT NULL COMMENT 'created at',
+  PRIMARY KEY (`id`),
+  KEY `idx_audit_logs_actor_id` (`actor_id`),
+  KEY `idx_audit_logs_target` (`target_type`, `target_id`)
+);
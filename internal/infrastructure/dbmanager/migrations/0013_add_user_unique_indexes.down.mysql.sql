@@ -0,0 +1,4 @@
+DROP INDEX `uniq_users_email_bidx` ON `users`;
+DROP INDEX `uniq_users_username` ON `users`;
+ALTER TABLE `users` DROP COLUMN `active_email_bidx`;
+ALTER TABLE `users` DROP COLUMN `active_username`;
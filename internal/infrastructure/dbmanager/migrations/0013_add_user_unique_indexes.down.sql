@@ -0,0 +1,2 @@
+DROP INDEX uniq_users_email_bidx;
+DROP INDEX uniq_users_username;
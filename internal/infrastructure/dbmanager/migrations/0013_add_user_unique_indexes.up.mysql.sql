@@ -0,0 +1,7 @@
+-- Usernames and emails are unique among users that aren't deleted; this fails
+-- while two such users share one, which has to be resolved first. MySQL has no
+-- partial indexes, so the unique indexes cover columns that are NULL once deleted
+ALTER TABLE `users` ADD COLUMN `active_username` varchar(50) GENERATED ALWAYS AS (IF(`deleted_at` IS NULL, `username`, NULL)) VIRTUAL COMMENT 'username of users that are not deleted';
+ALTER TABLE `users` ADD COLUMN `active_email_bidx` varchar(64) GENERATED ALWAYS AS (IF(`deleted_at` IS NULL, `email_bidx`, NULL)) VIRTUAL COMMENT 'email blind index of users that are not deleted';
+CREATE UNIQUE INDEX `uniq_users_username` ON `users` (`active_username`);
+CREATE UNIQUE INDEX `uniq_users_email_bidx` ON `users` (`active_email_bidx`);
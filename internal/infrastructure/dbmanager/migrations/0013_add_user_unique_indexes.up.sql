@@ -0,0 +1,4 @@
+-- Usernames and emails are unique among users that aren't deleted; this fails
+-- while two such users share one, which has to be resolved first
+CREATE UNIQUE INDEX uniq_users_username ON users (username) WHERE deleted_at IS NULL;
+CREATE UNIQUE INDEX uniq_users_email_bidx ON users (email_bidx) WHERE deleted_at IS NULL;
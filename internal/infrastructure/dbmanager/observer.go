@@ -0,0 +1,265 @@
+package dbmanager
+
+import (
+	"errors"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+
+	"boilerplate-golang/internal/infrastructure/config"
+	"boilerplate-golang/internal/infrastructure/cryptomanager"
+	"boilerplate-golang/internal/infrastructure/logger"
+)
+
+// observerStartKey stores the start time of a statement on its instance.
+const observerStartKey = "observer:start"
+
+// redacted replaces sensitive bound values in logged SQL.
+const redacted = "[REDACTED]"
+
+// alwaysRedacted are the columns masked in SQL logs whatever the configuration:
+// password hashes and the hashes of emailed tokens.
+var alwaysRedacted = []string{"password", "token_hash"}
+
+var (
+	placeholderRe = regexp.MustCompile(`\?|\$\d+`)
+	// columnBeforeRe finds the column compared with or assigned to the
+	// placeholder that follows, e.g. "`users`.`email` = " or "LOWER(name) LIKE LOWER("
+	columnBeforeRe = regexp.MustCompile("(?i)[`\"]?(\\w+)[`\"]?\\)?\\s*(?:=|<>|!=|>=|<=|>|<|LIKE|ILIKE|IN\\s*\\()\\s*(?:LOWER\\()?$")
+	insertColsRe   = regexp.MustCompile("(?is)^INSERT INTO\\s+\\S+\\s*\\(([^)]*)\\)\\s*VALUES")
+)
+
+// observer is a GORM plugin that logs statements through the logger package
+// with the request ID of their context, flags slow queries and records
+// latency and error counts per table and operation. Statements are only
+// logged when they fail, are slow, or database.log_queries is set.
+type observer struct {
+	slow   time.Duration
+	logAll bool
+	redact map[string]bool
+}
+
+func newObserver() *observer {
+	cfg := config.Get().Database
+	o := &observer{slow: cfg.SlowQueryThreshold, logAll: cfg.LogQueries, redact: make(map[string]bool)}
+	for _, col := range append(alwaysRedacted, cfg.RedactColumns...) {
+		o.redact[strings.ToLower(col)] = true
+	}
+	return o
+}
+
+// Name implements gorm.Plugin.
+func (o *observer) Name() string {
+	return "observer"
+}
+
+// Initialize implements gorm.Plugin by wrapping the statement callbacks.
+func (o *observer) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	hooks := []struct {
+		op     string
+		before func() error
+		after  func() error
+	}{
+		{"create",
+			func() error { return cb.Create().Before("gorm:create").Register("observer:before_create", o.before) },
+			func() error {
+				return cb.Create().After("gorm:create").Register("observer:after_create", o.after("create"))
+			}},
+		{"query",
+			func() error { return cb.Query().Before("gorm:query").Register("observer:before_query", o.before) },
+			func() error { return cb.Query().After("gorm:query").Register("observer:after_query", o.after("query")) }},
+		{"update",
+			func() error { return cb.Update().Before("gorm:update").Register("observer:before_update", o.before) },
+			func() error {
+				return cb.Update().After("gorm:update").Register("observer:after_update", o.after("update"))
+			}},
+		{"delete",
+			func() error { return cb.Delete().Before("gorm:delete").Register("observer:before_delete", o.before) },
+			func() error {
+				return cb.Delete().After("gorm:delete").Register("observer:after_delete", o.after("delete"))
+			}},
+		{"row",
+			func() error { return cb.Row().Before("gorm:row").Register("observer:before_row", o.before) },
+			func() error { return cb.Row().After("gorm:row").Register("observer:after_row", o.after("row")) }},
+		{"raw",
+			func() error { return cb.Raw().Before("gorm:raw").Register("observer:before_raw", o.before) },
+			func() error { return cb.Raw().After("gorm:raw").Register("observer:after_raw", o.after("raw")) }},
+	}
+	for _, h := range hooks {
+		if err := h.before(); err != nil {
+			return err
+		}
+		if err := h.after(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (o *observer) before(db *gorm.DB) {
+	db.InstanceSet(observerStartKey, time.Now())
+}
+
+func (o *observer) after(op string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		v, ok := db.InstanceGet(observerStartKey)
+		if !ok || db.DryRun {
+			return
+		}
+		elapsed := time.Since(v.(time.Time))
+
+		table := db.Statement.Table
+		if table == "" {
+			table = "-"
+		}
+		failed := db.Error != nil && !errors.Is(db.Error, gorm.ErrRecordNotFound)
+		slow := o.slow > 0 && elapsed >= o.slow
+		recordQuery(table, op, elapsed, failed, slow)
+
+		if !failed && !slow && !o.logAll {
+			return
+		}
+		sql := db.Dialector.Explain(db.Statement.SQL.String(), o.redactVars(db.Statement)...)
+		reqID := logger.RequestID(db.Statement.Context)
+		switch {
+		case failed:
+			logger.Error("sql failed op=%s table=%s duration=%s request_id=%s error=%q sql=%q", op, table, elapsed, reqID, db.Error.Error(), sql)
+		case slow:
+			logger.Warn("sql slow op=%s table=%s duration=%s rows=%d request_id=%s sql=%q", op, table, elapsed, db.Statement.RowsAffected, reqID, sql)
+		default:
+			logger.Info("sql op=%s table=%s duration=%s rows=%d request_id=%s sql=%q", op, table, elapsed, db.Statement.RowsAffected, reqID, sql)
+		}
+	}
+}
+
+// redactVars returns the statement's bound values with those of sensitive
+// columns masked. The column of each placeholder is taken from the INSERT
+// column list or from the comparison or assignment right before it.
+func (o *observer) redactVars(stmt *gorm.Statement) []interface{} {
+	sql := stmt.SQL.String()
+	vars := append([]interface{}(nil), stmt.Vars...)
+	sensitive := o.sensitiveColumns(stmt.Schema)
+
+	var insertCols []string
+	if m := insertColsRe.FindStringSubmatch(sql); m != nil {
+		for _, col := range strings.Split(m[1], ",") {
+			insertCols = append(insertCols, strings.ToLower(strings.Trim(col, " `\"")))
+		}
+	}
+
+	var (
+		col     string
+		prevEnd int
+	)
+	for i, loc := range placeholderRe.FindAllStringIndex(sql, -1) {
+		idx := i
+		if sql[loc[0]] == '$' {
+			n, _ := strconv.Atoi(sql[loc[0]+1 : loc[1]])
+			idx = n - 1
+		}
+		if idx < 0 || idx >= len(vars) {
+			continue
+		}
+
+		switch {
+		case len(insertCols) > 0 && i < len(vars):
+			col = insertCols[i%len(insertCols)]
+		case i > 0 && strings.TrimSpace(sql[prevEnd:loc[0]]) == ",":
+			// Next value of an IN list, same column as before
+		default:
+			col = ""
+			start := loc[0] - 128
+			if start < 0 {
+				start = 0
+			}
+			if m := columnBeforeRe.FindStringSubmatch(sql[start:loc[0]]); m != nil {
+				col = strings.ToLower(m[1])
+			}
+		}
+		prevEnd = loc[1]
+
+		if sensitive[col] {
+			vars[idx] = redacted
+		}
+	}
+	return vars
+}
+
+// sensitiveColumns returns the configured columns plus the encrypted columns of sch.
+func (o *observer) sensitiveColumns(sch *schema.Schema) map[string]bool {
+	if sch == nil {
+		return o.redact
+	}
+	cols := make(map[string]bool, len(o.redact))
+	for col := range o.redact {
+		cols[col] = true
+	}
+	for _, f := range sch.Fields {
+		if f.TagSettings["SERIALIZER"] == cryptomanager.SerializerName {
+			cols[strings.ToLower(f.DBName)] = true
+		}
+	}
+	return cols
+}
+
+// QueryStat aggregates the statements run against one table with one operation.
+type QueryStat struct {
+	Table     string  `json:"table"`
+	Operation string  `json:"operation"`
+	Count     int64   `json:"count"`
+	Errors    int64   `json:"errors"`
+	Slow      int64   `json:"slow"`
+	TotalMs   float64 `json:"total_ms"`
+	AvgMs     float64 `json:"avg_ms"`
+	MaxMs     float64 `json:"max_ms"`
+}
+
+var (
+	queryStatsMu sync.Mutex
+	queryStats   = make(map[[2]string]*QueryStat)
+)
+
+func recordQuery(table, op string, elapsed time.Duration, failed, slow bool) {
+	ms := float64(elapsed.Microseconds()) / 1000
+
+	queryStatsMu.Lock()
+	defer queryStatsMu.Unlock()
+	s, ok := queryStats[[2]string{table, op}]
+	if !ok {
+		s = &QueryStat{Table: table, Operation: op}
+		queryStats[[2]string{table, op}] = s
+	}
+	s.Count++
+	s.TotalMs += ms
+	if ms > s.MaxMs {
+		s.MaxMs = ms
+	}
+	if failed {
+		s.Errors++
+	}
+	if slow {
+		s.Slow++
+	}
+}
+
+// QueryStats returns the statement metrics collected since startup, busiest first.
+func QueryStats() []QueryStat {
+	queryStatsMu.Lock()
+	stats := make([]QueryStat, 0, len(queryStats))
+	for _, s := range queryStats {
+		stat := *s
+		stat.AvgMs = stat.TotalMs / float64(stat.Count)
+		stats = append(stats, stat)
+	}
+	queryStatsMu.Unlock()
+
+	sort.Slice(stats, func(i, j int) bool { return stats[i].TotalMs > stats[j].TotalMs })
+	return stats
+}
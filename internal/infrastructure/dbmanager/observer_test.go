@@ -0,0 +1,66 @@
+package dbmanager
+
+import (
+	"reflect"
+	"testing"
+
+	"gorm.io/gorm"
+
+	"boilerplate-golang/internal/infrastructure/config"
+)
+
+func TestRedactVars(t *testing.T) {
+	prev := config.Get()
+	t.Cleanup(func() { config.Set(prev) })
+	cfg := prev
+	cfg.Database.RedactColumns = []string{"email"}
+	config.Set(cfg)
+	o := newObserver()
+
+	tests := []struct {
+		name string
+		sql  string
+		vars []interface{}
+		want []interface{}
+	}{
+		{
+			"insert",
+			"INSERT INTO `users` (`id`,`username`,`password`) VALUES (?,?,?),(?,?,?)",
+			[]interface{}{"u1", "jane", "h1", "u2", "john", "h2"},
+			[]interface{}{"u1", "jane", redacted, "u2", "john", redacted},
+		},
+		{
+			"update",
+			"UPDATE `users` SET `password`=?,`updated_at`=? WHERE `id` = ?",
+			[]interface{}{"h1", "now", "u1"},
+			[]interface{}{redacted, "now", "u1"},
+		},
+		{
+			"in list",
+			"SELECT * FROM `user_tokens` WHERE token_hash IN (?,?) AND purpose = ?",
+			[]interface{}{"t1", "t2", "reset"},
+			[]interface{}{redacted, redacted, "reset"},
+		},
+		{
+			"numbered placeholders",
+			`UPDATE "users" SET "email"=$2,"password"=$1 WHERE "id" = $3`,
+			[]interface{}{"h1", "jane@example.com", "u1"},
+			[]interface{}{redacted, redacted, "u1"},
+		},
+		{
+			"configured column",
+			"SELECT * FROM `users` WHERE LOWER(email) LIKE LOWER(?) LIMIT ?",
+			[]interface{}{"%jane%", 10},
+			[]interface{}{redacted, 10},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stmt := &gorm.Statement{Vars: tt.vars}
+			stmt.SQL.WriteString(tt.sql)
+			if got := o.redactVars(stmt); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("redactVars() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
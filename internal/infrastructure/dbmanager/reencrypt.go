@@ -0,0 +1,173 @@
+package dbmanager
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+
+	"boilerplate-golang/internal/infrastructure/cryptomanager"
+)
+
+// Reencrypt rewrites the encrypted columns of every model in Models under the
+// active encryption key, together with their blind index columns (named
+// *_bidx by convention), which the entities' save hooks recompute. Rows already
+// under the active key are skipped unless all is set, e.g. after changing the
+// blind index key. With dryRun set, it only counts the rows it would rewrite.
+func Reencrypt(db *gorm.DB, batchSize int, all, dryRun bool, w io.Writer) (int, error) {
+	activeKID, err := cryptomanager.ActiveKeyID()
+	if err != nil {
+		return 0, err
+	}
+
+	total := 0
+	for _, model := range Models {
+		stmt := &gorm.Statement{DB: db}
+		if err := stmt.Parse(model); err != nil {
+			return total, err
+		}
+		sch := stmt.Schema
+
+		var encrypted, columns []string
+		for _, f := range sch.Fields {
+			if f.TagSettings["SERIALIZER"] == cryptomanager.SerializerName {
+				encrypted = append(encrypted, f.DBName)
+			}
+		}
+		if len(encrypted) == 0 {
+			continue
+		}
+		columns = append(columns, encrypted...)
+		for _, f := range sch.Fields {
+			if strings.HasSuffix(f.DBName, "_bidx") {
+				columns = append(columns, f.DBName)
+			}
+		}
+
+		n, err := reencryptTable(db, model, sch, encrypted, columns, "", activeKID, batchSize, all, dryRun)
+		total += n
+		if err != nil {
+			return total, fmt.Errorf("reencrypting %s: %w", sch.Table, err)
+		}
+		if dryRun {
+			fmt.Fprintf(w, "%s: %d row(s) to reencrypt\n", sch.Table, n)
+		} else {
+			fmt.Fprintf(w, "%s: %d row(s) reencrypted\n", sch.Table, n)
+		}
+	}
+	return total, nil
+}
+
+// BackfillBlindIndexes computes the blind indexes left empty, such as those of
+// the rows that existed before their column was added, rewriting the rows
+// like Reencrypt does. Lookups by an encrypted value miss these rows until
+// then. It returns the number of rows rewritten.
+func BackfillBlindIndexes(db *gorm.DB, batchSize int) (int, error) {
+	total := 0
+	for _, model := range Models {
+		stmt := &gorm.Statement{DB: db}
+		if err := stmt.Parse(model); err != nil {
+			return total, err
+		}
+		sch := stmt.Schema
+
+		var encrypted, indexes []string
+		for _, f := range sch.Fields {
+			if f.TagSettings["SERIALIZER"] == cryptomanager.SerializerName {
+				encrypted = append(encrypted, f.DBName)
+			}
+			if strings.HasSuffix(f.DBName, "_bidx") {
+				indexes = append(indexes, f.DBName)
+			}
+		}
+		if len(indexes) == 0 {
+			continue
+		}
+
+		missing := strings.Join(indexes, " IS NULL OR ") + " IS NULL"
+		columns := append(append([]string{}, encrypted...), indexes...)
+		n, err := reencryptTable(db, model, sch, encrypted, columns, missing, "", batchSize, true, false)
+		total += n
+		if err != nil {
+			return total, fmt.Errorf("backfilling blind indexes of %s: %w", sch.Table, err)
+		}
+	}
+	return total, nil
+}
+
+// reencryptTable walks the table in primary key order and rewrites the rows
+// matching filter, if any, that have a value outside the active key.
+func reencryptTable(db *gorm.DB, model interface{}, sch *schema.Schema, encrypted, columns []string, filter, activeKID string, batchSize int, all, dryRun bool) (int, error) {
+	pk := sch.PrioritizedPrimaryField.DBName
+	rewritten := 0
+	var last interface{}
+
+	for {
+		// Read the stored values directly, the serializer would hide the key id
+		var rows []map[string]interface{}
+		query := db.Table(sch.Table).Select(append([]string{pk}, encrypted...)).Order(pk).Limit(batchSize)
+		if filter != "" {
+			query = query.Where(filter)
+		}
+		if last != nil {
+			query = query.Where(pk+" > ?", last)
+		}
+		if err := query.Find(&rows).Error; err != nil {
+			return rewritten, err
+		}
+		if len(rows) == 0 {
+			return rewritten, nil
+		}
+		last = rows[len(rows)-1][pk]
+
+		var ids []interface{}
+		for _, row := range rows {
+			if all || !underKey(row, encrypted, activeKID) {
+				ids = append(ids, row[pk])
+			}
+		}
+		if len(ids) > 0 && !dryRun {
+			records := reflect.New(reflect.SliceOf(reflect.PointerTo(sch.ModelType)))
+			if err := db.Unscoped().Model(model).Where(pk+" IN ?", ids).Find(records.Interface()).Error; err != nil {
+				return rewritten, err
+			}
+			err := db.Transaction(func(tx *gorm.DB) error {
+				list := records.Elem()
+				for i := 0; i < list.Len(); i++ {
+					if err := tx.Unscoped().Select(columns).Updates(list.Index(i).Interface()).Error; err != nil {
+						return err
+					}
+				}
+				return nil
+			})
+			if err != nil {
+				return rewritten, err
+			}
+		}
+		rewritten += len(ids)
+
+		if len(rows) < batchSize {
+			return rewritten, nil
+		}
+	}
+}
+
+// underKey reports whether every non-empty encrypted column of row uses kid.
+func underKey(row map[string]interface{}, columns []string, kid string) bool {
+	for _, col := range columns {
+		var value string
+		switch v := row[col].(type) {
+		case string:
+			value = v
+		case []byte:
+			value = string(v)
+		}
+		if value != "" && cryptomanager.KeyID(value) != kid {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,57 @@
+package dbmanager_test
+
+import (
+	"bytes"
+	"encoding/base64"
+	"testing"
+
+	"boilerplate-golang/internal/application/entity"
+	"boilerplate-golang/internal/infrastructure/config"
+	"boilerplate-golang/internal/infrastructure/cryptomanager"
+	"boilerplate-golang/internal/infrastructure/dbmanager"
+	"boilerplate-golang/internal/infrastructure/dbmanager/dbtest"
+)
+
+func TestBackfillBlindIndexes(t *testing.T) {
+	key := func(b byte) string { return base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{b}, 32)) }
+	cfg := config.Get()
+	cfg.Encryption.ActiveKey = "k1"
+	cfg.Encryption.Keys = map[string]string{"k1": key(1)}
+	cfg.Encryption.BlindIndexKey = key(9)
+	config.Set(cfg)
+
+	db := dbtest.Open(t)
+	for _, u := range []*entity.User{
+		{ID: "u1", Username: "jane", Email: "jane@example.com"},
+		{ID: "u2", Username: "john", Email: "john@example.com"},
+	} {
+		if err := db.Create(u).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	// As left by the migration adding the column
+	if err := db.Exec("UPDATE users SET email_bidx = NULL WHERE id = ?", "u1").Error; err != nil {
+		t.Fatal(err)
+	}
+
+	n, err := dbmanager.BackfillBlindIndexes(db, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Errorf("backfilled %d row(s), want 1", n)
+	}
+
+	want, _ := cryptomanager.BlindIndex("jane@example.com")
+	var user entity.User
+	if err := db.Where("email_bidx = ?", want).First(&user).Error; err != nil {
+		t.Fatalf("user not found by email after the backfill: %v", err)
+	}
+	if user.ID != "u1" || user.Email != "jane@example.com" {
+		t.Errorf("found %s <%s>, want u1 <jane@example.com>", user.ID, user.Email)
+	}
+
+	if n, err := dbmanager.BackfillBlindIndexes(db, 1); err != nil || n != 0 {
+		t.Errorf("second backfill = %d, %v, want nothing to do", n, err)
+	}
+}
@@ -0,0 +1,190 @@
+package dbmanager
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"sort"
+
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"gorm.io/plugin/dbresolver"
+
+	"boilerplate-golang/internal/infrastructure/config"
+)
+
+// pools holds every connection pool by name ("primary", "replica-0", ...) for stats.
+var pools map[string]*sql.DB
+
+// unrouted shares the primary pool without dbresolver when replicas are in
+// use. dbresolver sends raw SELECTs to replicas and gives every statement a
+// pool of its own, which the migration lock can't work with.
+var unrouted *gorm.DB
+
+// useReplicas registers the configured read replicas with dbresolver so that
+// queries go to a replica while writes and transactions stay on the primary.
+func useReplicas(db *gorm.DB) error {
+	cfg := config.Get()
+	if len(cfg.Database.Replicas) == 0 {
+		return nil
+	}
+	if db.Dialector.Name() == DriverSQLite {
+		log.Println("Warning: read replicas are not supported with sqlite, ignoring")
+		return nil
+	}
+
+	primary := primaryEndpoint()
+	replicas := make([]gorm.Dialector, 0, len(cfg.Database.Replicas))
+	for i, r := range cfg.Database.Replicas {
+		ep := endpoint{Host: r.Host, Port: r.Port, User: r.User, Password: r.Password}
+		// Replicas usually share credentials and port with the primary
+		if ep.Port == 0 {
+			ep.Port = primary.Port
+		}
+		if ep.User == "" {
+			ep.User, ep.Password = primary.User, primary.Password
+		}
+
+		dialector, sqlDB, err := openReplica(db.Dialector.Name(), ep)
+		if err != nil {
+			return fmt.Errorf("failed to connect to replica %s:%d: %w", ep.Host, ep.Port, err)
+		}
+		pools[fmt.Sprintf("replica-%d", i)] = sqlDB
+		replicas = append(replicas, dialector)
+	}
+
+	var policy dbresolver.Policy = dbresolver.RandomPolicy{}
+	if cfg.Database.ReplicaPolicy == "round_robin" {
+		policy = dbresolver.RoundRobinPolicy()
+	}
+
+	var err error
+	if unrouted, err = openUnrouted(db); err != nil {
+		return fmt.Errorf("failed to open the migration connection: %w", err)
+	}
+
+	if err := db.Use(dbresolver.Register(dbresolver.Config{
+		Replicas: replicas,
+		Policy:   policy,
+	})); err != nil {
+		return fmt.Errorf("failed to register read replicas: %w", err)
+	}
+	log.Printf("dbmanager: routing reads to %d replica(s)", len(replicas))
+	return nil
+}
+
+// openReplica opens a replica pool we own, so its settings and stats are
+// reachable, and wraps it in a dialector for dbresolver.
+func openReplica(driver string, ep endpoint) (gorm.Dialector, *sql.DB, error) {
+	dialector, err := openDialector(ep)
+	if err != nil {
+		return nil, nil, err
+	}
+	replica, err := gorm.Open(dialector, &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		return nil, nil, err
+	}
+	sqlDB, err := replica.DB()
+	if err != nil {
+		return nil, nil, err
+	}
+	applyPoolSettings(sqlDB)
+
+	if driver == DriverPostgres {
+		return postgres.New(postgres.Config{Conn: sqlDB}), sqlDB, nil
+	}
+	return mysql.New(mysql.Config{Conn: sqlDB, SkipInitializeWithVersion: true}), sqlDB, nil
+}
+
+// openUnrouted opens a handle on the pool of db, before dbresolver routes it.
+func openUnrouted(db *gorm.DB) (*gorm.DB, error) {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	dialector := gorm.Dialector(mysql.New(mysql.Config{Conn: sqlDB}))
+	if db.Dialector.Name() == DriverPostgres {
+		dialector = postgres.New(postgres.Config{Conn: sqlDB})
+	}
+	return gorm.Open(dialector, &gorm.Config{Logger: logger.Discard})
+}
+
+// primaryOnly returns a handle on the primary that dbresolver doesn't route,
+// for migrations: their lock, reads and DDL must all use one connection.
+func primaryOnly(db *gorm.DB) *gorm.DB {
+	if _, routed := db.Config.Plugins[(&dbresolver.DBResolver{}).Name()]; routed && unrouted != nil {
+		return unrouted.WithContext(db.Statement.Context)
+	}
+	return db
+}
+
+// applyPoolSettings applies database.pool to sqlDB, leaving unset values at
+// the database/sql defaults.
+func applyPoolSettings(sqlDB *sql.DB) {
+	pool := config.Get().Database.Pool
+	if pool.MaxOpenConns > 0 {
+		sqlDB.SetMaxOpenConns(pool.MaxOpenConns)
+	}
+	if pool.MaxIdleConns > 0 {
+		sqlDB.SetMaxIdleConns(pool.MaxIdleConns)
+	}
+	if pool.ConnMaxLifetime > 0 {
+		sqlDB.SetConnMaxLifetime(pool.ConnMaxLifetime)
+	}
+	if pool.ConnMaxIdleTime > 0 {
+		sqlDB.SetConnMaxIdleTime(pool.ConnMaxIdleTime)
+	}
+}
+
+// Primary returns a DB handle whose queries always go to the primary. Use it
+// for reads that must see a write made just before (read-after-write).
+// Inside a transaction it returns the transaction, which is on the primary already.
+func Primary(ctx context.Context) *gorm.DB {
+	if st, ok := ctx.Value(txKey{}).(*txState); ok {
+		return st.tx
+	}
+	if _db == nil {
+		return nil
+	}
+	// Session makes the handle safe to reuse across several queries
+	return _db.WithContext(ctx).Clauses(dbresolver.Write).Session(&gorm.Session{})
+}
+
+// PoolStats describes one connection pool for monitoring.
+type PoolStats struct {
+	Name               string `json:"name"`
+	MaxOpenConnections int    `json:"max_open_connections"`
+	OpenConnections    int    `json:"open_connections"`
+	InUse              int    `json:"in_use"`
+	Idle               int    `json:"idle"`
+	WaitCount          int64  `json:"wait_count"`
+	WaitDurationMs     int64  `json:"wait_duration_ms"`
+	MaxIdleClosed      int64  `json:"max_idle_closed"`
+	MaxIdleTimeClosed  int64  `json:"max_idle_time_closed"`
+	MaxLifetimeClosed  int64  `json:"max_lifetime_closed"`
+}
+
+// Stats returns the current statistics of the primary and replica pools.
+func Stats() []PoolStats {
+	stats := make([]PoolStats, 0, len(pools))
+	for name, sqlDB := range pools {
+		s := sqlDB.Stats()
+		stats = append(stats, PoolStats{
+			Name:               name,
+			MaxOpenConnections: s.MaxOpenConnections,
+			OpenConnections:    s.OpenConnections,
+			InUse:              s.InUse,
+			Idle:               s.Idle,
+			WaitCount:          s.WaitCount,
+			WaitDurationMs:     s.WaitDuration.Milliseconds(),
+			MaxIdleClosed:      s.MaxIdleClosed,
+			MaxIdleTimeClosed:  s.MaxIdleTimeClosed,
+			MaxLifetimeClosed:  s.MaxLifetimeClosed,
+		})
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Name < stats[j].Name })
+	return stats
+}
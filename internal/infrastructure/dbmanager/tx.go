@@ -0,0 +1,128 @@
+package dbmanager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"gorm.io/gorm"
+)
+
+// txKey is the context key under which the active transaction is stored.
+type txKey struct{}
+
+// txState is the transaction carried in a context together with the hooks
+// waiting for it to commit.
+type txState struct {
+	tx          *gorm.DB
+	depth       int
+	afterCommit []func()
+}
+
+// DB returns the transaction carried by ctx, or the global connection bound
+// to ctx when there is none. Repositories and services should always obtain
+// their handle through DB so they join the caller's unit of work.
+func DB(ctx context.Context) *gorm.DB {
+	if st, ok := ctx.Value(txKey{}).(*txState); ok {
+		return st.tx
+	}
+	if _db == nil {
+		return nil
+	}
+	return _db.WithContext(ctx)
+}
+
+// WithTx runs fn in a transaction carried by the context passed to fn.
+// The transaction commits when fn returns nil and rolls back when it returns
+// an error or panics (the panic is re-raised). If ctx already carries a
+// transaction, fn runs in a savepoint of it instead, so a failing inner unit
+// of work only undoes its own changes.
+func WithTx(ctx context.Context, fn func(ctx context.Context) error) (err error) {
+	if st, ok := ctx.Value(txKey{}).(*txState); ok {
+		return st.savepoint(ctx, fn)
+	}
+	if _db == nil {
+		return errors.New("database not connected")
+	}
+
+	tx := _db.WithContext(ctx).Begin()
+	if tx.Error != nil {
+		return fmt.Errorf("failed to begin transaction: %w", tx.Error)
+	}
+	st := &txState{tx: tx}
+
+	panicked := true
+	defer func() {
+		if panicked {
+			tx.Rollback()
+		}
+	}()
+	err = fn(context.WithValue(ctx, txKey{}, st))
+	panicked = false
+
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Commit().Error; err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	for _, hook := range st.afterCommit {
+		runHook(hook)
+	}
+	return nil
+}
+
+// AfterCommit registers fn to run once the outermost transaction in ctx has
+// committed; it is dropped if the transaction (or its savepoint) rolls back.
+// Without a transaction in ctx, fn runs immediately.
+func AfterCommit(ctx context.Context, fn func()) {
+	if st, ok := ctx.Value(txKey{}).(*txState); ok {
+		st.afterCommit = append(st.afterCommit, fn)
+		return
+	}
+	runHook(fn)
+}
+
+// savepoint runs fn inside a savepoint of the current transaction.
+func (st *txState) savepoint(ctx context.Context, fn func(ctx context.Context) error) (err error) {
+	st.depth++
+	defer func() { st.depth-- }()
+
+	name := fmt.Sprintf("sp_%d", st.depth)
+	if err := st.tx.SavePoint(name).Error; err != nil {
+		return fmt.Errorf("failed to create savepoint: %w", err)
+	}
+	hooks := len(st.afterCommit)
+	rollback := func() {
+		st.tx.RollbackTo(name)
+		st.afterCommit = st.afterCommit[:hooks]
+	}
+
+	panicked := true
+	defer func() {
+		if panicked {
+			rollback()
+		}
+	}()
+	err = fn(ctx)
+	panicked = false
+
+	if err != nil {
+		rollback()
+	}
+	return err
+}
+
+// runHook runs an after-commit hook; a panicking hook is logged rather than
+// failing a request whose data is already committed.
+func runHook(fn func()) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("dbmanager: after-commit hook panicked: %v", r)
+		}
+	}()
+	fn()
+}
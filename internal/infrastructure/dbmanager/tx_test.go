@@ -0,0 +1,178 @@
+package dbmanager_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+
+	"boilerplate-golang/internal/infrastructure/dbmanager"
+	"boilerplate-golang/internal/infrastructure/dbmanager/dbtest"
+)
+
+var errAbort = errors.New("abort")
+
+func setupTx(t *testing.T) {
+	t.Helper()
+	db := dbtest.Open(t)
+	if err := db.Exec("CREATE TABLE tx_items (name TEXT NOT NULL)").Error; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func insert(ctx context.Context, name string) error {
+	return dbmanager.DB(ctx).Exec("INSERT INTO tx_items (name) VALUES (?)", name).Error
+}
+
+func items(t *testing.T) []string {
+	t.Helper()
+	var names []string
+	if err := dbmanager.GetDB().Raw("SELECT name FROM tx_items").Scan(&names).Error; err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestWithTx(t *testing.T) {
+	tests := []struct {
+		name      string
+		fn        func(ctx context.Context) error
+		wantErr   error
+		wantItems []string
+	}{
+		{
+			name: "commits",
+			fn: func(ctx context.Context) error {
+				return insert(ctx, "a")
+			},
+			wantItems: []string{"a"},
+		},
+		{
+			name: "rolls back on error",
+			fn: func(ctx context.Context) error {
+				if err := insert(ctx, "a"); err != nil {
+					return err
+				}
+				return errAbort
+			},
+			wantErr: errAbort,
+		},
+		{
+			name: "failed savepoint keeps the outer work",
+			fn: func(ctx context.Context) error {
+				if err := insert(ctx, "outer"); err != nil {
+					return err
+				}
+				err := dbmanager.WithTx(ctx, func(ctx context.Context) error {
+					if err := insert(ctx, "inner"); err != nil {
+						return err
+					}
+					return errAbort
+				})
+				if !errors.Is(err, errAbort) {
+					return err
+				}
+				return insert(ctx, "after")
+			},
+			wantItems: []string{"after", "outer"},
+		},
+		{
+			name: "successful savepoint commits with the transaction",
+			fn: func(ctx context.Context) error {
+				return dbmanager.WithTx(ctx, func(ctx context.Context) error {
+					return dbmanager.WithTx(ctx, func(ctx context.Context) error {
+						return insert(ctx, "nested")
+					})
+				})
+			},
+			wantItems: []string{"nested"},
+		},
+		{
+			name: "failed outer transaction discards savepoints",
+			fn: func(ctx context.Context) error {
+				if err := dbmanager.WithTx(ctx, func(ctx context.Context) error {
+					return insert(ctx, "inner")
+				}); err != nil {
+					return err
+				}
+				return errAbort
+			},
+			wantErr: errAbort,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupTx(t)
+			err := dbmanager.WithTx(context.Background(), tt.fn)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("WithTx error = %v, want %v", err, tt.wantErr)
+			}
+			if got := items(t); !reflect.DeepEqual(got, tt.wantItems) {
+				t.Errorf("items = %v, want %v", got, tt.wantItems)
+			}
+		})
+	}
+}
+
+func TestWithTxRollsBackAndRepanics(t *testing.T) {
+	setupTx(t)
+	defer func() {
+		if r := recover(); r != "boom" {
+			t.Errorf("recovered %v, want the panic re-raised", r)
+		}
+		if got := items(t); len(got) != 0 {
+			t.Errorf("items = %v after a panic, want none", got)
+		}
+	}()
+	_ = dbmanager.WithTx(context.Background(), func(ctx context.Context) error {
+		if err := insert(ctx, "a"); err != nil {
+			return err
+		}
+		panic("boom")
+	})
+}
+
+func TestAfterCommit(t *testing.T) {
+	setupTx(t)
+	var ran []string
+	hook := func(name string) func() { return func() { ran = append(ran, name) } }
+
+	err := dbmanager.WithTx(context.Background(), func(ctx context.Context) error {
+		dbmanager.AfterCommit(ctx, hook("outer"))
+		_ = dbmanager.WithTx(ctx, func(ctx context.Context) error {
+			dbmanager.AfterCommit(ctx, hook("rolled back savepoint"))
+			return errAbort
+		})
+		_ = dbmanager.WithTx(ctx, func(ctx context.Context) error {
+			dbmanager.AfterCommit(ctx, hook("savepoint"))
+			dbmanager.AfterCommit(ctx, func() { panic("hook failed") })
+			return nil
+		})
+		if len(ran) != 0 {
+			t.Errorf("hooks ran before commit: %v", ran)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"outer", "savepoint"}; !reflect.DeepEqual(ran, want) {
+		t.Errorf("hooks = %v, want %v", ran, want)
+	}
+
+	ran = nil
+	_ = dbmanager.WithTx(context.Background(), func(ctx context.Context) error {
+		dbmanager.AfterCommit(ctx, hook("rolled back"))
+		return errAbort
+	})
+	if len(ran) != 0 {
+		t.Errorf("hooks of a rolled back transaction ran: %v", ran)
+	}
+
+	dbmanager.AfterCommit(context.Background(), hook("no transaction"))
+	if want := []string{"no transaction"}; !reflect.DeepEqual(ran, want) {
+		t.Errorf("hooks = %v, want %v", ran, want)
+	}
+}
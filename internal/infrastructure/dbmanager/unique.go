@@ -0,0 +1,15 @@
+package dbmanager
+
+import "strings"
+
+// IsUniqueViolation reports whether err is the violation of the unique index
+// on column of table. Unique indexes are named uniq_<table>_<column>, which
+// MySQL and PostgreSQL report; SQLite reports the table and column.
+func IsUniqueViolation(err error, table, column string) bool {
+	if err == nil {
+		return false
+	}
+	msg := err.Error()
+	return strings.Contains(msg, "uniq_"+table+"_"+column) ||
+		strings.Contains(msg, "UNIQUE constraint failed: "+table+"."+column)
+}
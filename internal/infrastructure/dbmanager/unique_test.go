@@ -0,0 +1,52 @@
+package dbmanager_test
+
+import (
+	"testing"
+
+	"boilerplate-golang/internal/infrastructure/dbmanager"
+	"boilerplate-golang/internal/infrastructure/dbmanager/dbtest"
+)
+
+func TestUserUniqueIndexes(t *testing.T) {
+	db := dbtest.Open(t)
+	insert := func(id, username, bidx string) error {
+		return db.Exec("INSERT INTO users (id, username, email_bidx) VALUES (?, ?, ?)", id, username, bidx).Error
+	}
+	if err := insert("u1", "jane", "b1"); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		username string
+		bidx     string
+		column   string
+	}{
+		{"taken username", "jane", "b2", "username"},
+		{"taken email", "john", "b1", "email_bidx"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := insert("u2", tt.username, tt.bidx)
+			if !dbmanager.IsUniqueViolation(err, "users", tt.column) {
+				t.Fatalf("insert error = %v, want a violation of the unique %s", err, tt.column)
+			}
+			for _, other := range []string{"username", "email_bidx"} {
+				if other != tt.column && dbmanager.IsUniqueViolation(err, "users", other) {
+					t.Errorf("error %v also reported as a violation on %s", err, other)
+				}
+			}
+		})
+	}
+
+	// Deleted users free their username and email
+	if err := db.Exec("UPDATE users SET deleted_at = CURRENT_TIMESTAMP WHERE id = ?", "u1").Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := insert("u2", "jane", "b1"); err != nil {
+		t.Errorf("reusing the username and email of a deleted user: %v", err)
+	}
+	if dbmanager.IsUniqueViolation(nil, "users", "username") {
+		t.Error("nil error reported as a violation")
+	}
+}
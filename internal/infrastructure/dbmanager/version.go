@@ -0,0 +1,27 @@
+package dbmanager
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+// ErrVersionConflict is returned by UpdateVersioned when the row no longer has
+// the expected version, i.e. someone else updated it first.
+var ErrVersionConflict = errors.New("record was modified concurrently")
+
+// UpdateVersioned applies updates to the row of model (whose primary key must
+// be set) only if its version column still equals version, and increments the
+// version in the same statement. Entities opt in with a `version` column.
+func UpdateVersioned(db *gorm.DB, model interface{}, version int64, updates map[string]interface{}) error {
+	updates["version"] = gorm.Expr("version + 1")
+
+	res := db.Model(model).Where("version = ?", version).Updates(updates)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrVersionConflict
+	}
+	return nil
+}
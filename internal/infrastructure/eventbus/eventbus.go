@@ -0,0 +1,67 @@
+package eventbus
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Wildcard subscribes a handler to every event type.
+const Wildcard = "*"
+
+// Event is a domain event delivered to subscribers. Delivery from the outbox
+// is at-least-once, so handlers should deduplicate on ID.
+type Event struct {
+	ID            string          `json:"id"`
+	Type          string          `json:"type"`
+	AggregateType string          `json:"aggregate_type"`
+	AggregateID   string          `json:"aggregate_id"`
+	Payload       json.RawMessage `json:"payload"`
+	OccurredAt    time.Time       `json:"occurred_at"`
+}
+
+// Handler processes an event; returning an error makes the publish fail so
+// the outbox retries it.
+type Handler func(ctx context.Context, e Event) error
+
+var (
+	mu       sync.RWMutex
+	handlers = make(map[string][]Handler)
+)
+
+// Subscribe registers h for events of the given type, or for all events with Wildcard.
+// Subscribers should be registered at startup, before the outbox relay runs.
+func Subscribe(eventType string, h Handler) {
+	mu.Lock()
+	defer mu.Unlock()
+	handlers[eventType] = append(handlers[eventType], h)
+}
+
+// Publish delivers e synchronously to its subscribers in registration order.
+// Every subscriber runs even if an earlier one fails; the errors are joined.
+func Publish(ctx context.Context, e Event) error {
+	mu.RLock()
+	subs := append(append([]Handler(nil), handlers[e.Type]...), handlers[Wildcard]...)
+	mu.RUnlock()
+
+	var errs []error
+	for _, h := range subs {
+		if err := call(ctx, h, e); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// call runs a handler, turning a panic into an error.
+func call(ctx context.Context, h Handler, e Event) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("handler for %s panicked: %v", e.Type, r)
+		}
+	}()
+	return h(ctx, e)
+}
@@ -19,6 +19,8 @@ type Claims struct {
 	UserID         string `json:"uid"`
 	OrganizationID string `json:"org_id"`
 	Role           string `json:"role"`
+	// SessionID ties the token to a login session so it can be revoked early
+	SessionID string `json:"sid,omitempty"`
 	jwt.RegisteredClaims
 }
 
@@ -29,10 +31,16 @@ func New(secret, issuer string, expireIn time.Duration) *Manager {
 
 // Sign creates a signed JWT string.
 func (m *Manager) Sign(userID, organizationID, role string) (string, error) {
+	return m.SignSession(userID, organizationID, role, "")
+}
+
+// SignSession creates a signed JWT string bound to a login session.
+func (m *Manager) SignSession(userID, organizationID, role, sessionID string) (string, error) {
 	claims := &Claims{
 		UserID:         userID,
 		OrganizationID: organizationID,
 		Role:           role,
+		SessionID:      sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
 			Issuer:    m.Issuer,
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(m.ExpireIn)),
@@ -0,0 +1,80 @@
+package mailmanager
+
+import (
+	"fmt"
+	"log"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"boilerplate-golang/internal/infrastructure/config"
+)
+
+// Supported values for mail.driver.
+const (
+	DriverLog  = "log"
+	DriverSMTP = "smtp"
+)
+
+// Message is a plain-text email.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Send delivers msg with the configured driver. The log driver (the default)
+// only writes the message to the log, which is enough to follow links sent by
+// email in development.
+func Send(msg Message) error {
+	cfg := config.Get().Mail
+
+	switch cfg.Driver {
+	case "", DriverLog:
+		log.Printf("mailmanager: to=%s subject=%q\n%s", msg.To, msg.Subject, msg.Body)
+		return nil
+	case DriverSMTP:
+		return sendSMTP(msg)
+	default:
+		return fmt.Errorf("unsupported mail driver %q", cfg.Driver)
+	}
+}
+
+// SendAsync sends msg in the background and logs a failure. Use it after the
+// data the message refers to has been committed, so requests don't wait on SMTP.
+func SendAsync(msg Message) {
+	go func() {
+		if err := Send(msg); err != nil {
+			log.Printf("mailmanager: failed to send %q to %s: %v", msg.Subject, msg.To, err)
+		}
+	}()
+}
+
+// sendSMTP delivers msg through the configured SMTP server, authenticating
+// when a username is set.
+func sendSMTP(msg Message) error {
+	cfg := config.Get().Mail
+	if cfg.Host == "" || cfg.From == "" {
+		return fmt.Errorf("mail.host and mail.from are required for the smtp driver")
+	}
+
+	var auth smtp.Auth
+	if cfg.Username != "" {
+		auth = smtp.PlainAuth("", cfg.Username, cfg.Password, cfg.Host)
+	}
+
+	// Header values come from our own templates; strip line breaks anyway so a
+	// crafted address or subject can't inject headers
+	clean := strings.NewReplacer("\r", "", "\n", "")
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", clean.Replace(cfg.From))
+	fmt.Fprintf(&b, "To: %s\r\n", clean.Replace(msg.To))
+	fmt.Fprintf(&b, "Subject: %s\r\n", clean.Replace(msg.Subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	b.WriteString(strings.ReplaceAll(msg.Body, "\n", "\r\n"))
+
+	addr := fmt.Sprintf("%s:%d", cfg.Host, cfg.Port)
+	return smtp.SendMail(addr, auth, cfg.From, []string{clean.Replace(msg.To)}, []byte(b.String()))
+}
@@ -0,0 +1,203 @@
+package servermanager
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"boilerplate-golang/internal/infrastructure/config"
+)
+
+// Run starts the HTTP(S) server for the given handler and blocks until it stops.
+// TLS, client certificate verification, HTTP/2, h2c and the HTTP→HTTPS redirect
+// listener are all driven by the [server] section of the config.
+func Run(handler http.Handler) error {
+	cfg := config.Get()
+
+	srv := &http.Server{
+		Addr:              fmt.Sprintf(":%d", cfg.App.Port),
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+		Protocols:         new(http.Protocols),
+	}
+	srv.Protocols.SetHTTP1(true)
+
+	if !cfg.Server.TLS.Enabled {
+		// h2c serves HTTP/2 without TLS, typically behind a proxy that terminates TLS
+		if cfg.Server.H2C {
+			srv.Protocols.SetUnencryptedHTTP2(true)
+		}
+		log.Printf("servermanager: listening on %s (http, h2c=%t)", srv.Addr, cfg.Server.H2C)
+		return srv.ListenAndServe()
+	}
+
+	reloader, err := newCertReloader(cfg.Server.TLS.CertFile, cfg.Server.TLS.KeyFile, cfg.Server.TLS.ClientCAFile)
+	if err != nil {
+		return err
+	}
+	interval := cfg.Server.TLS.ReloadInterval
+	if interval <= 0 {
+		interval = 30 * time.Second
+	}
+	go reloader.watch(interval)
+
+	if cfg.Server.HTTP2 {
+		srv.Protocols.SetHTTP2(true)
+	}
+	srv.TLSConfig = &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		GetConfigForClient: reloader.configForClient(cfg.Server.HTTP2),
+	}
+
+	if cfg.Server.RedirectPort > 0 {
+		go runRedirect(cfg.Server.RedirectPort, cfg.App.Port)
+	}
+
+	log.Printf("servermanager: listening on %s (https, http2=%t, mtls=%t)", srv.Addr, cfg.Server.HTTP2, reloader.clientCAFile != "")
+	// Certificates come from GetConfigForClient, so no files are passed here
+	return srv.ListenAndServeTLS("", "")
+}
+
+// runRedirect serves a plain HTTP listener that redirects every request to HTTPS.
+func runRedirect(port, tlsPort int) {
+	redirect := &http.Server{
+		Addr:              fmt.Sprintf(":%d", port),
+		ReadHeaderTimeout: 10 * time.Second,
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			host, _, err := net.SplitHostPort(r.Host)
+			if err != nil {
+				host = r.Host
+			}
+			if tlsPort != 443 {
+				host = net.JoinHostPort(host, strconv.Itoa(tlsPort))
+			}
+			http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusPermanentRedirect)
+		}),
+	}
+
+	log.Printf("servermanager: redirecting http on %s to https", redirect.Addr)
+	if err := redirect.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		log.Printf("servermanager: redirect listener stopped: %v", err)
+	}
+}
+
+// certReloader keeps the server certificate and client CA pool in memory and
+// reloads them when the files on disk change.
+type certReloader struct {
+	certFile     string
+	keyFile      string
+	clientCAFile string
+
+	mu       sync.RWMutex
+	cert     *tls.Certificate
+	clientCA *x509.CertPool
+	modTime  time.Time
+}
+
+func newCertReloader(certFile, keyFile, clientCAFile string) (*certReloader, error) {
+	if certFile == "" || keyFile == "" {
+		return nil, fmt.Errorf("servermanager: tls enabled but cert_file or key_file is not set")
+	}
+
+	r := &certReloader{certFile: certFile, keyFile: keyFile, clientCAFile: clientCAFile}
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// load reads the certificate, key and optional client CA bundle from disk.
+func (r *certReloader) load() error {
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("servermanager: failed to load certificate: %w", err)
+	}
+
+	var pool *x509.CertPool
+	if r.clientCAFile != "" {
+		pem, err := os.ReadFile(r.clientCAFile)
+		if err != nil {
+			return fmt.Errorf("servermanager: failed to read client CA file: %w", err)
+		}
+		pool = x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("servermanager: no certificates found in client CA file %s", r.clientCAFile)
+		}
+	}
+
+	r.mu.Lock()
+	r.cert = &cert
+	r.clientCA = pool
+	r.modTime = r.latestModTime()
+	r.mu.Unlock()
+	return nil
+}
+
+// latestModTime returns the most recent modification time of the watched files.
+func (r *certReloader) latestModTime() time.Time {
+	var latest time.Time
+	for _, f := range []string{r.certFile, r.keyFile, r.clientCAFile} {
+		if f == "" {
+			continue
+		}
+		if info, err := os.Stat(f); err == nil && info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest
+}
+
+// watch polls the files and reloads them when they change. Polling rather than
+// inotify keeps this working with symlink swaps used by Kubernetes secrets.
+func (r *certReloader) watch(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		r.mu.RLock()
+		current := r.modTime
+		r.mu.RUnlock()
+
+		if !r.latestModTime().After(current) {
+			continue
+		}
+		if err := r.load(); err != nil {
+			log.Printf("Warning: %v (keeping previous certificate)", err)
+			continue
+		}
+		log.Println("servermanager: reloaded TLS certificate")
+	}
+}
+
+// configForClient builds the per-handshake TLS config from the current certificate.
+// Client certificates are requested but only verified when presented; routes that
+// require them enforce it with config.ClientCertMiddleware.
+func (r *certReloader) configForClient(http2 bool) func(*tls.ClientHelloInfo) (*tls.Config, error) {
+	nextProtos := []string{"http/1.1"}
+	if http2 {
+		nextProtos = []string{"h2", "http/1.1"}
+	}
+
+	return func(*tls.ClientHelloInfo) (*tls.Config, error) {
+		r.mu.RLock()
+		defer r.mu.RUnlock()
+
+		tlsCfg := &tls.Config{
+			MinVersion:   tls.VersionTLS12,
+			Certificates: []tls.Certificate{*r.cert},
+			NextProtos:   nextProtos,
+		}
+		if r.clientCA != nil {
+			tlsCfg.ClientCAs = r.clientCA
+			tlsCfg.ClientAuth = tls.VerifyClientCertIfGiven
+		}
+		return tlsCfg, nil
+	}
+}
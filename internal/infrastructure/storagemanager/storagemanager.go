@@ -0,0 +1,276 @@
+package storagemanager
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"boilerplate-golang/internal/infrastructure/awsmanager"
+	"boilerplate-golang/internal/infrastructure/config"
+)
+
+// Supported values for storage.driver.
+const (
+	DriverLocal = "local"
+	DriverS3    = "s3"
+)
+
+// PrivatePrefix starts the keys of files that are never served publicly,
+// such as data exports; they are downloaded through SignedURL only. With s3,
+// keep the prefix out of any public-read bucket policy.
+const PrivatePrefix = "private/"
+
+// ErrInvalidSignature is returned by LocalFile for a private file requested
+// without a valid, unexpired signature.
+var ErrInvalidSignature = errors.New("invalid or expired signature")
+
+// ErrInvalidKey is returned by CleanKey for a key with an empty, "." or ".."
+// segment.
+var ErrInvalidKey = errors.New("invalid storage key")
+
+// Storage stores files generated or uploaded by the application under keys
+// such as "avatars/<user>/<hash>/small.jpg".
+type Storage interface {
+	Put(ctx context.Context, key string, data []byte, contentType string) error
+	Get(ctx context.Context, key string) ([]byte, error)
+	Delete(ctx context.Context, key string) error
+	// SignedURL returns a URL that downloads key until ttl has passed
+	SignedURL(ctx context.Context, key string, ttl time.Duration) (string, error)
+}
+
+var (
+	store      Storage
+	publicURL  string
+	signingKey []byte
+)
+
+// Init selects the storage driver from [storage]. S3 requires awsmanager to
+// be initialized first; local files are served by the /api/files route.
+func Init() error {
+	cfg := config.Get()
+
+	switch cfg.Storage.Driver {
+	case "", DriverLocal:
+		dir := cfg.Storage.LocalDir
+		if dir == "" {
+			dir = "uploads"
+		}
+		publicURL = strings.TrimSuffix(cfg.App.BaseURL, "/") + "/api/files"
+		// Signed links are checked by the API, so they never use a CDN public_url
+		store = localStorage{dir: dir, url: publicURL}
+	case DriverS3:
+		if !awsmanager.Enabled() {
+			return errors.New("storage driver s3 requires [aws] to be configured")
+		}
+		store = s3Storage{}
+		publicURL = strings.TrimSuffix(awsmanager.ObjectURL(""), "/")
+	default:
+		return fmt.Errorf("unsupported storage driver %q", cfg.Storage.Driver)
+	}
+
+	signingKey = []byte(cfg.Storage.SigningSecret)
+	if len(signingKey) == 0 {
+		// Signed links then only work until restart and on this instance
+		log.Printf("storagemanager: storage.signing_secret is not set, using a random key")
+		signingKey = make([]byte, 32)
+		_, _ = rand.Read(signingKey)
+	}
+
+	if cfg.Storage.PublicURL != "" {
+		publicURL = strings.TrimSuffix(cfg.Storage.PublicURL, "/")
+	}
+	log.Printf("storagemanager: using %s storage, served from %s", driverName(cfg.Storage.Driver), publicURL)
+	return nil
+}
+
+// Put stores data under key, replacing any existing file.
+func Put(ctx context.Context, key string, data []byte, contentType string) error {
+	if store == nil {
+		return errors.New("storage not initialized")
+	}
+	return store.Put(ctx, key, data, contentType)
+}
+
+// Get returns the contents of the file stored under key.
+func Get(ctx context.Context, key string) ([]byte, error) {
+	if store == nil {
+		return nil, errors.New("storage not initialized")
+	}
+	return store.Get(ctx, key)
+}
+
+// Delete removes the file stored under key; a missing file is not an error.
+func Delete(ctx context.Context, key string) error {
+	if store == nil {
+		return errors.New("storage not initialized")
+	}
+	return store.Delete(ctx, key)
+}
+
+// URL returns the stable public URL of key.
+func URL(key string) string {
+	return publicURL + "/" + key
+}
+
+// SignedURL returns a URL that downloads key, typically a private file, until
+// ttl has passed.
+func SignedURL(ctx context.Context, key string, ttl time.Duration) (string, error) {
+	if store == nil {
+		return "", errors.New("storage not initialized")
+	}
+	return store.SignedURL(ctx, key, ttl)
+}
+
+// CleanKey returns key as a stored file is named, without a leading slash. It
+// fails with ErrInvalidKey when a segment is empty, "." or "..", so that no
+// spelling of a key reaches a file under another prefix.
+func CleanKey(key string) (string, error) {
+	key = strings.TrimPrefix(key, "/")
+	for _, segment := range strings.Split(key, "/") {
+		if segment == "" || segment == "." || segment == ".." {
+			return "", ErrInvalidKey
+		}
+	}
+	return path.Clean("/" + key)[1:], nil
+}
+
+// LocalFile returns the path of the local file for key, as requested through
+// /api/files. Private files require the expires and signature parameters of
+// a link from SignedURL. It fails unless the local driver is in use.
+func LocalFile(key, expires, signature string) (string, error) {
+	l, ok := store.(localStorage)
+	if !ok {
+		return "", os.ErrNotExist
+	}
+	key, err := CleanKey(key)
+	if err != nil {
+		return "", err
+	}
+	if IsPrivate(key) {
+		exp, err := strconv.ParseInt(expires, 10, 64)
+		if err != nil || time.Now().Unix() > exp {
+			return "", ErrInvalidSignature
+		}
+		sig, err := hex.DecodeString(signature)
+		if err != nil || !hmac.Equal(sig, sign(key, exp)) {
+			return "", ErrInvalidSignature
+		}
+	}
+	return l.path(key)
+}
+
+// IsPrivate reports whether the cleaned key names a private file.
+func IsPrivate(key string) bool {
+	return strings.HasPrefix(key, PrivatePrefix)
+}
+
+// sign computes the signature of a link to key that expires at exp.
+func sign(key string, exp int64) []byte {
+	mac := hmac.New(sha256.New, signingKey)
+	mac.Write([]byte(key + "\n" + strconv.FormatInt(exp, 10)))
+	return mac.Sum(nil)
+}
+
+func driverName(driver string) string {
+	if driver == "" {
+		return DriverLocal
+	}
+	return driver
+}
+
+// localStorage keeps files in a directory on disk.
+type localStorage struct {
+	dir string
+	url string
+}
+
+func (l localStorage) path(key string) (string, error) {
+	// Keys are built by the application, but never let one escape the directory
+	clean := filepath.Clean("/" + key)
+	if clean == "/" {
+		return "", fmt.Errorf("invalid storage key %q", key)
+	}
+	return filepath.Join(l.dir, clean), nil
+}
+
+func (l localStorage) Put(_ context.Context, key string, data []byte, _ string) error {
+	path, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+	// Write to a temporary file first so readers never see a partial file
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	return nil
+}
+
+func (l localStorage) Get(_ context.Context, key string) ([]byte, error) {
+	path, err := l.path(key)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	return data, nil
+}
+
+func (l localStorage) SignedURL(_ context.Context, key string, ttl time.Duration) (string, error) {
+	exp := time.Now().Add(ttl).Unix()
+	q := url.Values{}
+	q.Set("expires", strconv.FormatInt(exp, 10))
+	q.Set("signature", hex.EncodeToString(sign(key, exp)))
+	return l.url + "/" + key + "?" + q.Encode(), nil
+}
+
+func (l localStorage) Delete(_ context.Context, key string) error {
+	path, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to delete file: %w", err)
+	}
+	return nil
+}
+
+// s3Storage keeps files in the bucket configured for awsmanager.
+type s3Storage struct{}
+
+func (s3Storage) Put(ctx context.Context, key string, data []byte, contentType string) error {
+	return awsmanager.PutObject(ctx, key, data, contentType)
+}
+
+func (s3Storage) Get(ctx context.Context, key string) ([]byte, error) {
+	return awsmanager.GetObject(ctx, key)
+}
+
+func (s3Storage) Delete(ctx context.Context, key string) error {
+	return awsmanager.DeleteFile(ctx, key)
+}
+
+func (s3Storage) SignedURL(ctx context.Context, key string, ttl time.Duration) (string, error) {
+	return awsmanager.GeneratePresignedURL(ctx, key, ttl)
+}
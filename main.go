@@ -1,14 +1,24 @@
 package main
 
 import (
-	"fmt"
+	"context"
+	"log"
+	"os"
 
+	"boilerplate-golang/internal/application/cli"
+	"boilerplate-golang/internal/application/outbox"
+	"boilerplate-golang/internal/application/purge"
 	"boilerplate-golang/internal/application/router"
+	"boilerplate-golang/internal/application/seed"
+	"boilerplate-golang/internal/application/service"
 	"boilerplate-golang/internal/infrastructure/ai"
+	"boilerplate-golang/internal/infrastructure/awsmanager"
 	"boilerplate-golang/internal/infrastructure/config"
 	"boilerplate-golang/internal/infrastructure/cronmanager"
 	"boilerplate-golang/internal/infrastructure/dbmanager"
 	"boilerplate-golang/internal/infrastructure/redismanager"
+	"boilerplate-golang/internal/infrastructure/servermanager"
+	"boilerplate-golang/internal/infrastructure/storagemanager"
 
 	"github.com/gin-gonic/gin"
 )
@@ -17,11 +27,30 @@ func main() {
 	// Load configuration
 	cfg := config.Load()
 
+	// Run a maintenance command (e.g. `migrate up`) instead of the server when given
+	if len(os.Args) > 1 {
+		os.Exit(cli.Run(os.Args[1:]))
+	}
+
 	// Initialize infrastructure managers
 	dbmanager.Init()
+	if cfg.Seed.Auto && cfg.App.Env == "development" && dbmanager.GetDB() != nil {
+		if err := seed.Run(context.Background(), cfg.App.Env); err != nil {
+			log.Printf("Warning: seeding failed: %v", err)
+		}
+	}
 	redismanager.Init()
+	if err := awsmanager.Init(); err != nil {
+		log.Printf("Warning: %v", err)
+	}
+	if err := storagemanager.Init(); err != nil {
+		log.Fatalf("storage: %v", err)
+	}
+	cronmanager.OnCleanup("purge soft-deleted records", purge.Run)
+	cronmanager.OnCleanup("expire data exports and run erasures", service.IPrivacyService.RunScheduled)
 	cronmanager.Init()
 	ai.Init()
+	outbox.Start(context.Background())
 
 	// Create Gin router with default middleware
 	r := gin.Default()
@@ -38,6 +67,9 @@ func main() {
 		r.SetTrustedProxies([]string{"127.0.0.1", "::1"})
 	}
 
+	// Tag every request with an ID for log correlation
+	r.Use(config.RequestIDMiddleware())
+
 	// Apply CORS middleware from router_config
 	r.Use(config.CORSMiddleware())
 
@@ -46,11 +78,14 @@ func main() {
 
 	// Initialize JWT
 	config.InitJWT()
+	config.OnSessionCheck(service.IAuthService.CheckSession)
 
 	// Register application routes
 	router.Register(r, db)
 
-	// Start server
-	addr := fmt.Sprintf(":%d", cfg.App.Port)
-	_ = r.Run(addr)
+	// Start server (TLS, HTTP/2 and redirect options come from [server]);
+	// unversioned API paths are served by the version the request asks for
+	if err := servermanager.Run(router.Handler(r)); err != nil {
+		log.Fatalf("server stopped: %v", err)
+	}
 }